@@ -3,6 +3,7 @@ package cmd
 import (
 	conf "github.com/FXAZfung/image-board/internal/config"
 	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/service"
 	"github.com/FXAZfung/image-board/internal/setting"
 	"github.com/FXAZfung/image-board/pkg/random"
 	"github.com/FXAZfung/image-board/pkg/utils"
@@ -61,6 +62,30 @@ var ShowTokenCmd = &cobra.Command{
 	},
 }
 
+var DisableOtpCmd = &cobra.Command{
+	Use:   "disable-2fa USERNAME",
+	Short: "Disable two-factor authentication of a user, e.g. when the authenticator is lost",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		Init()
+		defer Release()
+		user, err := op.GetUserByName(args[0])
+		if err != nil {
+			utils.Log.Errorf("failed get user: %+v", err)
+			return
+		}
+		if !user.OtpEnabled && user.OtpSecret == "" {
+			utils.Log.Infof("two-factor authentication of user %s is not enabled", user.Username)
+			return
+		}
+		if err := service.ResetTwoFactor(user); err != nil {
+			utils.Log.Errorf("failed disable two-factor authentication: %+v", err)
+			return
+		}
+		utils.Log.Infof("two-factor authentication of user %s has been disabled", user.Username)
+	},
+}
+
 func setAdminPassword(pwd string) {
 	Init()
 	defer Release()
@@ -84,6 +109,7 @@ func init() {
 	AdminCmd.AddCommand(RandomPasswordCmd)
 	AdminCmd.AddCommand(SetPasswordCmd)
 	AdminCmd.AddCommand(ShowTokenCmd)
+	AdminCmd.AddCommand(DisableOtpCmd)
 	// Here you will define your flags and configuration settings.
 
 	// Cobra supports Persistent Flags which will work for this command
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	apiKeyScopes  string
+	apiKeyExpires time.Duration
+)
+
+// APIKeyCmd represents the apikey command
+var APIKeyCmd = &cobra.Command{
+	Use:   "apikey",
+	Short: "Manage users' api keys",
+}
+
+var ListAPIKeyCmd = &cobra.Command{
+	Use:   "list [USERNAME]",
+	Short: "List api keys, of all users or the given user",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		Init()
+		defer Release()
+		var keys []*model.APIKey
+		var err error
+		if len(args) == 1 {
+			user, uerr := op.GetUserByName(args[0])
+			if uerr != nil {
+				utils.Log.Errorf("failed get user: %+v", uerr)
+				return
+			}
+			keys, err = op.GetAPIKeysByUser(user.ID)
+		} else {
+			keys, err = op.GetAPIKeys()
+		}
+		if err != nil {
+			utils.Log.Errorf("failed get api keys: %+v", err)
+			return
+		}
+		for _, key := range keys {
+			expires, lastUsed := "never", "never"
+			if key.ExpiresAt != nil {
+				expires = key.ExpiresAt.Format(time.RFC3339)
+			}
+			if key.LastUsed != nil {
+				lastUsed = key.LastUsed.Format(time.RFC3339)
+			}
+			utils.Log.Infof("id: %d, user_id: %d, name: %s, prefix: %s, scopes: %s, expires: %s, last_used: %s",
+				key.ID, key.UserID, key.Name, key.Prefix, strings.Join(key.Scopes(), ","), expires, lastUsed)
+		}
+	},
+}
+
+var CreateAPIKeyCmd = &cobra.Command{
+	Use:   "create USERNAME NAME",
+	Short: "Create an api key for the given user",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		scope, err := model.ParseScopes(strings.Split(apiKeyScopes, ","))
+		if err != nil {
+			utils.Log.Errorf("invalid scopes: %v", err)
+			return
+		}
+		Init()
+		defer Release()
+		user, err := op.GetUserByName(args[0])
+		if err != nil {
+			utils.Log.Errorf("failed get user: %+v", err)
+			return
+		}
+		var expiresAt *time.Time
+		if apiKeyExpires > 0 {
+			t := time.Now().Add(apiKeyExpires)
+			expiresAt = &t
+		}
+		secret, key, err := op.CreateAPIKey(user.ID, args[1], scope, expiresAt)
+		if err != nil {
+			utils.Log.Errorf("failed create api key: %+v", err)
+			return
+		}
+		utils.Log.Infof("api key %d has been created for user %s", key.ID, user.Username)
+		utils.Log.Infof("The key can only be shown once, keep it safe")
+		utils.Log.Infof("key: %s", secret)
+	},
+}
+
+var DeleteAPIKeyCmd = &cobra.Command{
+	Use:   "delete ID",
+	Short: "Delete an api key",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		id, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil {
+			utils.Log.Errorf("invalid id: %s", args[0])
+			return
+		}
+		Init()
+		defer Release()
+		if err := op.DeleteAPIKey(0, uint(id)); err != nil {
+			utils.Log.Errorf("failed delete api key: %+v", err)
+			return
+		}
+		utils.Log.Infof("api key %d has been deleted", id)
+	},
+}
+
+func init() {
+	AdminCmd.AddCommand(APIKeyCmd)
+	APIKeyCmd.AddCommand(ListAPIKeyCmd)
+	APIKeyCmd.AddCommand(CreateAPIKeyCmd)
+	APIKeyCmd.AddCommand(DeleteAPIKeyCmd)
+	CreateAPIKeyCmd.Flags().StringVar(&apiKeyScopes, "scopes", "read", "comma separated scopes: read, upload, tags")
+	CreateAPIKeyCmd.Flags().DurationVar(&apiKeyExpires, "expires", 0, "expiration of the key, e.g. 720h, 0 means never")
+}
@@ -15,6 +15,7 @@ func Init() {
 	initialize.InitConfig()
 	initialize.Log()
 	initialize.InitDB()
+	initialize.InitStorage()
 	data.InitData()
 }
 
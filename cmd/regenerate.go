@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/service"
+	"github.com/FXAZfung/image-board/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var regenerateOptions service.RegenerateOptions
+
+// RegenerateCmd represents the regenerate command
+var RegenerateCmd = &cobra.Command{
+	Use:   "regenerate",
+	Short: "Regenerate thumbnails and WebP variants of images whose originals carry an EXIF orientation",
+	Long: `Regenerate thumbnails and WebP variants upright according to the EXIF orientation of the originals,
+and update the stored width, height and perceptual hashes. Cached transform variants of the images are removed.
+Restart the server afterwards so that its caches and similarity index pick up the changes.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		Init()
+		defer Release()
+		result, err := service.RegenerateImages(regenerateOptions, func(img *model.Image, orientation int, err error) {
+			switch {
+			case err != nil:
+				utils.Log.Warnf("failed regenerate image %d (%s): %+v", img.ID, img.FileName, err)
+			case regenerateOptions.DryRun:
+				utils.Log.Infof("image %d (%s) has orientation %d", img.ID, img.FileName, orientation)
+			default:
+				utils.Log.Infof("regenerated image %d (%s), orientation %d, %dx%d", img.ID, img.FileName, orientation, img.Width, img.Height)
+			}
+		})
+		if err != nil {
+			utils.Log.Errorf("failed list images: %+v", err)
+		}
+		if regenerateOptions.DryRun {
+			utils.Log.Infof("scanned %d images, %d need to be regenerated", result.Scanned, result.Regenerated)
+			return
+		}
+		utils.Log.Infof("scanned %d images, regenerated %d, failed %d", result.Scanned, result.Regenerated, result.Failed)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(RegenerateCmd)
+	RegenerateCmd.Flags().BoolVar(&regenerateOptions.All, "all", false, "regenerate all images, not only those with an EXIF orientation")
+	RegenerateCmd.Flags().BoolVar(&regenerateOptions.DryRun, "dry-run", false, "only list the images that would be regenerated")
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/FXAZfung/image-board/cmd/flags"
 	"github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/service"
 	"github.com/FXAZfung/image-board/pkg/utils"
 	"github.com/FXAZfung/image-board/server"
 	"github.com/gin-gonic/gin"
@@ -39,6 +40,11 @@ the address is defined in config file`,
 		r := gin.New()
 		r.Use(gin.LoggerWithWriter(log.StandardLogger().Out), gin.RecoveryWithWriter(log.StandardLogger().Out))
 		server.Init(r)
+		service.StartUploadGC()
+		if err := service.LoadPHashIndex(); err != nil {
+			utils.Log.Errorf("failed to load phash index: %+v", err)
+		}
+		service.StartPHashBackfill()
 		var httpSrv, httpsSrv, unixSrv *http.Server
 		if config.Conf.Scheme.HttpPort != -1 {
 			httpBase := fmt.Sprintf("%s:%d", config.Conf.Scheme.Address, config.Conf.Scheme.HttpPort)
@@ -19,33 +19,33 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/auth/login": {
-            "post": {
-                "description": "通过用户名和密码登录系统并获取令牌",
-                "consumes": [
-                    "application/json"
+        "/api/auth/2fa": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "返回当前用户是否启用两步验证及剩余恢复码数量",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "认证"
                 ],
-                "summary": "用户登录",
+                "summary": "获取两步验证状态",
                 "parameters": [
                     {
-                        "description": "用户登录信息",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handles.LoginReq"
-                        }
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "登录成功，返回令牌信息",
+                        "description": "两步验证状态",
                         "schema": {
                             "allOf": [
                                 {
@@ -55,27 +55,21 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/handles.LoginResp"
+                                            "$ref": "#/definitions/response.TwoFactorStatusResponse"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "无效请求或密码错误",
-                        "schema": {
-                            "$ref": "#/definitions/common.Resp"
-                        }
-                    },
-                    "429": {
-                        "description": "登录尝试次数过多，请稍后再试",
+                    "401": {
+                        "description": "未授权",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
                     "500": {
-                        "description": "生成令牌失败",
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -83,14 +77,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/auth/logout": {
+        "/api/auth/2fa/disable": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "使当前用户令牌失效",
+                "description": "校验密码与验证码（或恢复码）后关闭两步验证。LDAP 用户校验目录中的密码，没有本地密码的 SSO 用户只校验验证码",
                 "consumes": [
                     "application/json"
                 ],
@@ -100,7 +94,7 @@ const docTemplate = `{
                 "tags": [
                     "认证"
                 ],
-                "summary": "用户登出",
+                "summary": "关闭两步验证",
                 "parameters": [
                     {
                         "type": "string",
@@ -108,23 +102,38 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "密码与验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handles.DisableOtpReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "登出成功",
+                        "description": "关闭成功",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "400": {
+                        "description": "密码或验证码错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
                     "401": {
-                        "description": "未授权，缺少有效令牌",
+                        "description": "未授权",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
                     "500": {
-                        "description": "令牌失效操作失败",
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -132,14 +141,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/auth/user/info": {
-            "get": {
+        "/api/auth/2fa/enable": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "获取当前登录用户的详细信息",
+                "description": "校验验证器应用生成的验证码后启用两步验证，返回的恢复码只展示一次",
                 "consumes": [
                     "application/json"
                 ],
@@ -149,7 +158,7 @@ const docTemplate = `{
                 "tags": [
                     "认证"
                 ],
-                "summary": "获取当前登录用户信息",
+                "summary": "启用两步验证",
                 "parameters": [
                     {
                         "type": "string",
@@ -157,11 +166,20 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handles.OtpCodeReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "用户信息",
+                        "description": "恢复码",
                         "schema": {
                             "allOf": [
                                 {
@@ -171,58 +189,50 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.User"
+                                            "$ref": "#/definitions/response.RecoveryCodesResponse"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "400": {
+                        "description": "验证码错误或未绑定",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
                     "401": {
                         "description": "未授权",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
                     }
                 }
             }
         },
-        "/api/auth/users": {
-            "get": {
+        "/api/auth/2fa/enroll": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "分页获取所有用户（需要管理员权限）",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "生成 TOTP 密钥并返回 otpauth 链接，使用验证器应用扫码后调用 /api/auth/2fa/enable 启用",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "认证"
                 ],
-                "summary": "分页获取用户列表",
+                "summary": "绑定两步验证",
                 "parameters": [
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 1,
-                        "description": "页码",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "maximum": 100,
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 10,
-                        "description": "每页数量",
-                        "name": "per_page",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
                         "description": "Bearer 用户令牌",
@@ -233,7 +243,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "分页结果",
+                        "description": "密钥与 otpauth 链接",
                         "schema": {
                             "allOf": [
                                 {
@@ -243,30 +253,27 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "allOf": [
-                                                {
-                                                    "$ref": "#/definitions/common.PageResp"
-                                                },
-                                                {
-                                                    "type": "object",
-                                                    "properties": {
-                                                        "content": {
-                                                            "type": "array",
-                                                            "items": {
-                                                                "$ref": "#/definitions/model.User"
-                                                            }
-                                                        }
-                                                    }
-                                                }
-                                            ]
+                                            "$ref": "#/definitions/response.TwoFactorEnrollResponse"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "400": {
+                        "description": "已启用两步验证",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
                     "403": {
-                        "description": "权限不足",
+                        "description": "游客不能启用两步验证",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -280,21 +287,24 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/auth/users/count": {
-            "get": {
+        "/api/auth/2fa/recovery_codes": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "获取系统中的用户总数（需要管理员权限）",
+                "description": "校验验证码后重新生成恢复码，旧的恢复码全部失效",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "认证"
                 ],
-                "summary": "获取用户总数",
+                "summary": "重新生成恢复码",
                 "parameters": [
                     {
                         "type": "string",
@@ -302,11 +312,20 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "description": "验证码",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handles.OtpCodeReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "用户总数",
+                        "description": "新的恢复码",
                         "schema": {
                             "allOf": [
                                 {
@@ -316,15 +335,21 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "$ref": "#/definitions/response.RecoveryCodesResponse"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "403": {
-                        "description": "权限不足",
+                    "400": {
+                        "description": "验证码错误或未启用",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -338,33 +363,22 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/auth/users/{id}": {
+        "/api/auth/apikeys": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "根据用户ID获取用户详细信息（需要管理员权限）",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "列出当前用户的全部 API 密钥，不包含密钥明文",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "认证"
                 ],
-                "summary": "根据ID获取用户信息",
+                "summary": "获取 API 密钥列表",
                 "parameters": [
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "用户ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "string",
                         "description": "Bearer 用户令牌",
@@ -375,7 +389,7 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "用户信息",
+                        "description": "密钥列表",
                         "schema": {
                             "allOf": [
                                 {
@@ -385,40 +399,43 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.User"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/response.APIKeyResponse"
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "ID格式错误",
+                    "401": {
+                        "description": "未授权",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
                     "403": {
-                        "description": "权限不足",
+                        "description": "不能使用 API 密钥访问",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "404": {
-                        "description": "用户不存在",
+                    "500": {
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "更新指定用户的信息（需要管理员权限或为自己的账号）",
+                "description": "为当前用户创建 API 密钥，密钥明文只在创建时返回一次。使用密钥访问时权限为用户权限与作用域的交集",
                 "consumes": [
                     "application/json"
                 ],
@@ -428,36 +445,28 @@ const docTemplate = `{
                 "tags": [
                     "认证"
                 ],
-                "summary": "更新用户信息",
+                "summary": "创建 API 密钥",
                 "parameters": [
                     {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "用户ID",
-                        "name": "id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
                     },
                     {
-                        "description": "用户信息",
-                        "name": "user",
+                        "description": "密钥信息",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handles.UpdateUserReq"
+                            "$ref": "#/definitions/handles.CreateAPIKeyReq"
                         }
-                    },
-                    {
-                        "type": "string",
-                        "description": "Bearer 用户令牌",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "更新后的用户信息",
+                        "description": "创建的密钥",
                         "schema": {
                             "allOf": [
                                 {
@@ -467,7 +476,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.User"
+                                            "$ref": "#/definitions/response.CreateAPIKeyResponse"
                                         }
                                     }
                                 }
@@ -480,52 +489,56 @@ const docTemplate = `{
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
                     "403": {
-                        "description": "权限不足",
+                        "description": "游客或使用 API 密钥访问",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "404": {
-                        "description": "用户不存在",
+                    "500": {
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/auth/apikeys/{id}": {
             "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "删除指定用户（需要管理员权限）",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "删除当前用户的指定 API 密钥，密钥立即失效",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "认证"
                 ],
-                "summary": "删除用户",
+                "summary": "删除 API 密钥",
                 "parameters": [
-                    {
-                        "minimum": 1,
-                        "type": "integer",
-                        "description": "用户ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "string",
                         "description": "Bearer 用户令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "密钥ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -541,14 +554,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "403": {
-                        "description": "权限不足",
+                    "401": {
+                        "description": "未授权",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
                     "404": {
-                        "description": "用户不存在",
+                        "description": "密钥不存在",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -556,19 +575,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/image/count": {
-            "get": {
-                "description": "获取系统中的图片总量",
-                "produces": [
-                    "application/json"
+        "/api/auth/login": {
+            "post": {
+                "description": "通过用户名和密码登录系统并获取令牌，启用 LDAP 后除管理员外通过 LDAP 认证。开启两步验证的用户返回 LoginOtpResp，需调用 /api/auth/login/otp 完成登录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
                 ],
                 "tags": [
-                    "图片"
+                    "认证"
+                ],
+                "summary": "用户登录",
+                "parameters": [
+                    {
+                        "description": "用户登录信息",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handles.LoginReq"
+                        }
+                    }
                 ],
-                "summary": "获取图片统计",
                 "responses": {
                     "200": {
-                        "description": "统计结果",
+                        "description": "登录成功，返回令牌信息",
                         "schema": {
                             "allOf": [
                                 {
@@ -578,15 +611,27 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "integer"
+                                            "$ref": "#/definitions/handles.LoginResp"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
+                    "400": {
+                        "description": "无效请求或密码错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "429": {
+                        "description": "登录尝试次数过多，请稍后再试",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
                     "500": {
-                        "description": "统计失败",
+                        "description": "生成令牌失败",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -594,14 +639,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/image/delete": {
+        "/api/auth/login/otp": {
             "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "永久删除图片及其关联数据（需要登录）",
+                "description": "使用登录返回的 otp_token 与 TOTP 验证码或恢复码完成登录，每个恢复码只能使用一次。每个验证凭据最多尝试 5 次，之后需重新登录",
                 "consumes": [
                     "application/json"
                 ],
@@ -609,30 +649,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "图片"
+                    "认证"
                 ],
-                "summary": "删除图片",
+                "summary": "两步验证登录",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Bearer 用户令牌",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
-                    },
-                    {
-                        "description": "删除参数",
-                        "name": "image",
+                        "description": "验证信息",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.ImageDeleteReq"
+                            "$ref": "#/definitions/handles.LoginOtpReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "删除结果",
+                        "description": "登录成功，返回令牌信息",
                         "schema": {
                             "allOf": [
                                 {
@@ -642,7 +675,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/response.ImageDeleteResponse"
+                                            "$ref": "#/definitions/handles.LoginResp"
                                         }
                                     }
                                 }
@@ -650,25 +683,68 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "参数格式错误",
+                        "description": "验证码错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
                     "401": {
-                        "description": "未授权，需要登录",
+                        "description": "验证凭据无效、已过期或尝试次数过多",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "403": {
-                        "description": "无删除权限",
+                    "429": {
+                        "description": "尝试次数过多，请稍后再试",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "使当前用户令牌失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "认证"
+                ],
+                "summary": "用户登出",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登出成功",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "404": {
-                        "description": "图片不存在",
+                    "401": {
+                        "description": "未授权，缺少有效令牌",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "令牌失效操作失败",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -676,9 +752,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/image/list": {
-            "post": {
-                "description": "分页获取所有图片基本信息",
+        "/api/auth/me": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "获取当前登录用户的详细信息与拥有的权限",
                 "consumes": [
                     "application/json"
                 ],
@@ -686,23 +767,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "图片"
+                    "认证"
                 ],
-                "summary": "分页获取图片列表",
+                "summary": "获取当前登录用户信息",
                 "parameters": [
                     {
-                        "description": "分页参数",
-                        "name": "page",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.PageReq"
-                        }
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "分页结果",
+                        "description": "用户信息",
                         "schema": {
                             "allOf": [
                                 {
@@ -712,36 +791,15 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "allOf": [
-                                                {
-                                                    "$ref": "#/definitions/common.PageResp"
-                                                },
-                                                {
-                                                    "type": "object",
-                                                    "properties": {
-                                                        "content": {
-                                                            "type": "array",
-                                                            "items": {
-                                                                "$ref": "#/definitions/model.Image"
-                                                            }
-                                                        }
-                                                    }
-                                                }
-                                            ]
+                                            "$ref": "#/definitions/response.UserInfoResponse"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "参数校验失败",
-                        "schema": {
-                            "$ref": "#/definitions/common.Resp"
-                        }
-                    },
-                    "500": {
-                        "description": "服务器错误",
+                    "401": {
+                        "description": "未授权",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -749,14 +807,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/image/tag/add": {
+        "/api/auth/me/password": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "给现有图片添加一个标签（需要登录）",
+                "description": "校验旧密码后修改当前登录用户的密码，其他登录会话全部失效",
                 "consumes": [
                     "application/json"
                 ],
@@ -764,9 +822,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "图片"
+                    "认证"
                 ],
-                "summary": "添加图片标签",
+                "summary": "修改密码",
                 "parameters": [
                     {
                         "type": "string",
@@ -776,54 +834,36 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "添加标签请求",
+                        "description": "密码信息",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/request.AddTagReq"
+                            "$ref": "#/definitions/handles.ChangePasswordReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "添加成功",
+                        "description": "修改成功",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/common.Resp"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/response.ImageTagResponse"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/common.Resp"
                         }
                     },
                     "400": {
-                        "description": "请求格式无效",
+                        "description": "参数错误或旧密码错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
                     "401": {
-                        "description": "未授权，需要登录",
-                        "schema": {
-                            "$ref": "#/definitions/common.Resp"
-                        }
-                    },
-                    "404": {
-                        "description": "图片不存在",
+                        "description": "未授权",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "500": {
-                        "description": "服务器错误",
+                    "403": {
+                        "description": "游客不能修改密码",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -831,9 +871,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/image/tag/list": {
+        "/api/auth/refresh": {
             "post": {
-                "description": "分页获取包含指定标签的所有图片",
+                "description": "使用刷新令牌换取新的访问令牌与刷新令牌并顺延会话有效期，旧的刷新令牌被重复使用时整个会话将被撤销",
                 "consumes": [
                     "application/json"
                 ],
@@ -841,31 +881,23 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "图片"
+                    "认证"
                 ],
-                "summary": "根据标签获取图片",
+                "summary": "刷新令牌",
                 "parameters": [
                     {
-                        "minLength": 1,
-                        "type": "string",
-                        "description": "标签名称",
-                        "name": "tag",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "description": "分页参数",
-                        "name": "page",
+                        "description": "刷新令牌",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/model.PageReq"
+                            "$ref": "#/definitions/handles.RefreshReq"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "分页结果",
+                        "description": "新的令牌",
                         "schema": {
                             "allOf": [
                                 {
@@ -875,22 +907,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "allOf": [
-                                                {
-                                                    "$ref": "#/definitions/common.PageResp"
-                                                },
-                                                {
-                                                    "type": "object",
-                                                    "properties": {
-                                                        "content": {
-                                                            "type": "array",
-                                                            "items": {
-                                                                "$ref": "#/definitions/model.Image"
-                                                            }
-                                                        }
-                                                    }
-                                                }
-                                            ]
+                                            "$ref": "#/definitions/common.TokenPair"
                                         }
                                     }
                                 }
@@ -898,19 +915,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "标签参数缺失或格式错误",
-                        "schema": {
-                            "$ref": "#/definitions/common.Resp"
-                        }
-                    },
-                    "404": {
-                        "description": "标签不存在",
+                        "description": "请求格式错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "500": {
-                        "description": "服务器错误",
+                    "401": {
+                        "description": "刷新令牌无效、已过期或被重复使用",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -918,24 +929,21 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/image/tag/remove": {
-            "post": {
+        "/api/auth/sessions": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "从图片中移除指定标签（需要登录）",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "列出当前用户所有未过期的登录会话，current 标记当前请求使用的会话",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "图片"
+                    "认证"
                 ],
-                "summary": "移除图片标签",
+                "summary": "获取登录会话列表",
                 "parameters": [
                     {
                         "type": "string",
@@ -943,20 +951,11 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "移除标签请求",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.RemoveTagReq"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "操作结果",
+                        "description": "会话列表",
                         "schema": {
                             "allOf": [
                                 {
@@ -966,27 +965,18 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/response.ImageTagResponse"
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Session"
+                                            }
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "请求参数错误",
-                        "schema": {
-                            "$ref": "#/definitions/common.Resp"
-                        }
-                    },
                     "401": {
-                        "description": "未授权，需要登录",
-                        "schema": {
-                            "$ref": "#/definitions/common.Resp"
-                        }
-                    },
-                    "404": {
-                        "description": "图片或标签不存在",
+                        "description": "未授权",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -998,63 +988,39 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/image/upload": {
-            "post": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "上传图片文件并添加元数据（需要登录）",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+                "description": "撤销当前用户的全部会话，keep_current 为 true 时保留当前会话",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "图片"
+                    "认证"
                 ],
-                "summary": "上传新图片",
+                "summary": "撤销全部登录会话",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "用户令牌",
+                        "description": "Bearer 用户令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
                     },
                     {
-                        "type": "file",
-                        "description": "图片文件（支持PNG/JPEG/GIF）",
-                        "name": "image",
-                        "in": "formData",
-                        "required": true
+                        "type": "boolean",
+                        "description": "是否保留当前会话",
+                        "name": "keep_current",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "上传成功",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/common.Resp"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/model.Image"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
-                    },
-                    "400": {
-                        "description": "文件无效/参数错误",
+                        "description": "撤销成功",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -1065,14 +1031,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "413": {
-                        "description": "文件过大",
-                        "schema": {
-                            "$ref": "#/definitions/common.Resp"
-                        }
-                    },
                     "500": {
-                        "description": "上传失败",
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -1080,63 +1040,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/public/register": {
-            "post": {
-                "description": "创建新用户账号",
-                "consumes": [
-                    "application/json"
+        "/api/auth/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "撤销当前用户的指定会话，对应的令牌立即失效",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "用户"
+                    "认证"
                 ],
-                "summary": "注册新用户",
+                "summary": "撤销登录会话",
                 "parameters": [
                     {
-                        "description": "用户信息",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handles.RegisterReq"
-                        }
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "会话ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "注册成功",
+                        "description": "撤销成功",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/common.Resp"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "$ref": "#/definitions/model.User"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "400": {
-                        "description": "参数错误",
+                    "401": {
+                        "description": "未授权",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "403": {
-                        "description": "需要管理权限",
+                    "404": {
+                        "description": "会话不存在",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "409": {
-                        "description": "用户已存在",
+                    "500": {
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -1144,22 +1099,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/setting": {
+        "/api/auth/sso/bind": {
             "get": {
-                "description": "获取所有公开的系统设置",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "返回 OpenID Connect 提供方的授权链接，回调后将提供方账户绑定到当前用户，之后可通过 SSO 登录该用户。同时设置 sso_state cookie，回调必须在同一浏览器中完成",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "设置"
+                    "认证"
+                ],
+                "summary": "绑定 SSO 账户",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
                 ],
-                "summary": "获取公共设置",
                 "responses": {
                     "200": {
-                        "description": "公共设置键值对",
+                        "description": "授权链接",
                         "schema": {
                             "allOf": [
                                 {
@@ -1169,99 +1135,107 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "object",
-                                            "additionalProperties": {
-                                                "type": "string"
-                                            }
+                                            "$ref": "#/definitions/handles.SSOURLResp"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "500": {
-                        "description": "获取设置失败",
+                    "400": {
+                        "description": "提供方配置错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "未启用 SSO 登录或游客",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     }
                 }
-            }
-        },
-        "/api/setting/delete": {
+            },
             "delete": {
-                "description": "删除设置",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "解除当前用户绑定的 SSO 账户",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "设置"
+                    "认证"
                 ],
-                "summary": "删除设置",
+                "summary": "解除绑定 SSO 账户",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Token",
+                        "description": "Bearer 用户令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "key",
-                        "name": "key",
-                        "in": "query",
-                        "required": true
                     }
                 ],
-                "responses": {}
+                "responses": {
+                    "200": {
+                        "description": "解除成功",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
             }
         },
-        "/api/setting/list": {
+        "/api/auth/sso/callback": {
             "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "按分组列出系统设置项（需要认证）",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "校验提供方返回的授权码并登录，首次登录时按配置创建用户。绑定操作返回绑定的用户，登录操作与 /api/auth/login 的响应一致",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "设置"
+                    "认证"
                 ],
-                "summary": "获取系统设置列表",
+                "summary": "SSO 回调",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Bearer 用户令牌",
-                        "name": "Authorization",
-                        "in": "header",
+                        "description": "授权码",
+                        "name": "code",
+                        "in": "query",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "设置分组ID",
-                        "name": "group",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "多个设置分组ID，逗号分隔",
-                        "name": "groups",
-                        "in": "query"
+                        "description": "状态",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "设置项列表",
+                        "description": "登录成功，返回令牌信息",
                         "schema": {
                             "allOf": [
                                 {
@@ -1271,10 +1245,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/model.SettingItem"
-                                            }
+                                            "$ref": "#/definitions/handles.LoginResp"
                                         }
                                     }
                                 }
@@ -1282,19 +1253,19 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "参数格式错误",
+                        "description": "授权失败",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
                     "401": {
-                        "description": "未授权，需要登录",
+                        "description": "状态无效、不是发起授权的浏览器或账户未绑定",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "500": {
-                        "description": "查询失败",
+                    "403": {
+                        "description": "邮箱域名不允许或账户已被绑定",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -1302,66 +1273,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/setting/save": {
-            "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "保存一组系统设置项",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/auth/sso/login": {
+            "get": {
+                "description": "返回 OpenID Connect 提供方的授权链接，用户在提供方登录后回调 /api/auth/sso/callback。同时设置 sso_state cookie，回调必须在同一浏览器中完成。回调地址为 sso_redirect_uri，未配置时使用配置文件中的 site_url",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "设置"
-                ],
-                "summary": "保存系统设置",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Bearer 用户令牌",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
-                    },
-                    {
-                        "description": "设置项列表",
-                        "name": "settings",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/model.SettingItem"
-                            }
-                        }
-                    }
+                    "认证"
                 ],
+                "summary": "SSO 登录",
                 "responses": {
                     "200": {
-                        "description": "保存成功",
+                        "description": "授权链接",
                         "schema": {
-                            "$ref": "#/definitions/common.Resp"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/handles.SSOURLResp"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "请求格式错误",
-                        "schema": {
-                            "$ref": "#/definitions/common.Resp"
-                        }
-                    },
-                    "401": {
-                        "description": "未授权，需要登录",
+                        "description": "提供方配置错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "500": {
-                        "description": "保存失败",
+                    "403": {
+                        "description": "未启用 SSO 登录",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -1369,9 +1317,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/setting/setting": {
+        "/api/auth/users": {
             "get": {
-                "description": "获取设置",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "分页获取所有用户（需要 manage_users 权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -1379,57 +1332,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "设置"
+                    "认证"
                 ],
-                "summary": "获取设置",
+                "summary": "分页获取用户列表",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Token",
-                        "name": "Authorization",
-                        "in": "header",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "key",
-                        "name": "key",
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "keys",
-                        "name": "keys",
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "每页数量",
+                        "name": "per_page",
                         "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "设置",
-                        "schema": {
-                            "type": "string"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/setting/token": {
-            "post": {
-                "description": "重置token",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "设置"
-                ],
-                "summary": "重置token",
-                "parameters": [
+                    },
                     {
                         "type": "string",
-                        "description": "Token",
+                        "description": "Bearer 用户令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
@@ -1437,22 +1363,59 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "分页结果",
                         "schema": {
-                            "type": "string"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/common.PageResp"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "content": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/model.User"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
                         }
                     }
                 }
-            }
-        },
-        "/api/tag/delete/{id}": {
-            "delete": {
+            },
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "删除标签并移除与所有图片的关联",
+                "description": "创建新的普通用户账号（需要 manage_users 权限），默认拥有上传与删除自己图片的权限。管理员与游客均只存在一个，不能创建",
                 "consumes": [
                     "application/json"
                 ],
@@ -1460,28 +1423,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "标签"
+                    "用户"
                 ],
-                "summary": "删除标签",
+                "summary": "注册新用户",
                 "parameters": [
+                    {
+                        "description": "用户信息",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handles.RegisterReq"
+                        }
+                    },
                     {
                         "type": "string",
                         "description": "Bearer 用户令牌",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "标签ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "删除结果",
+                        "description": "注册成功",
                         "schema": {
                             "allOf": [
                                 {
@@ -1491,7 +1456,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/response.TagDeleteResponse"
+                                            "$ref": "#/definitions/model.User"
                                         }
                                     }
                                 }
@@ -1499,25 +1464,19 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "ID格式错误",
-                        "schema": {
-                            "$ref": "#/definitions/common.Resp"
-                        }
-                    },
-                    "401": {
-                        "description": "未授权",
+                        "description": "参数错误或角色无效",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "404": {
-                        "description": "标签不存在",
+                    "403": {
+                        "description": "需要管理权限",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "500": {
-                        "description": "服务器内部错误",
+                    "409": {
+                        "description": "用户已存在",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -1525,31 +1484,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/tag/image/{image_id}": {
+        "/api/auth/users/count": {
             "get": {
-                "description": "获取指定图片关联的所有标签",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "获取系统中的用户总数（需要 manage_users 权限）",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "标签"
+                    "认证"
                 ],
-                "summary": "获取图片关联标签",
+                "summary": "获取用户总数",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "图片ID",
-                        "name": "image_id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "标签列表",
+                        "description": "用户总数",
                         "schema": {
                             "allOf": [
                                 {
@@ -1559,30 +1520,21 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/model.Tag"
-                                            }
+                                            "type": "integer"
                                         }
                                     }
                                 }
                             ]
                         }
                     },
-                    "400": {
-                        "description": "ID格式错误",
-                        "schema": {
-                            "$ref": "#/definitions/common.Resp"
-                        }
-                    },
-                    "404": {
-                        "description": "图片不存在",
+                    "403": {
+                        "description": "权限不足",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
                     "500": {
-                        "description": "服务器内部错误",
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -1590,9 +1542,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/tag/list": {
-            "post": {
-                "description": "使用分页方式获取所有标签列表",
+        "/api/auth/users/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "根据用户ID获取用户详细信息（需要 manage_users 权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -1600,23 +1557,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "标签"
+                    "认证"
                 ],
-                "summary": "分页获取标签列表",
+                "summary": "根据ID获取用户信息",
                 "parameters": [
                     {
-                        "description": "分页参数",
-                        "name": "page",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/model.PageReq"
-                        }
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "分页结果",
+                        "description": "用户信息",
                         "schema": {
                             "allOf": [
                                 {
@@ -1626,22 +1589,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "allOf": [
-                                                {
-                                                    "$ref": "#/definitions/common.PageResp"
-                                                },
-                                                {
-                                                    "type": "object",
-                                                    "properties": {
-                                                        "content": {
-                                                            "type": "array",
-                                                            "items": {
-                                                                "$ref": "#/definitions/model.Tag"
-                                                            }
-                                                        }
-                                                    }
-                                                }
-                                            ]
+                                            "$ref": "#/definitions/model.User"
                                         }
                                     }
                                 }
@@ -1649,23 +1597,32 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "参数绑定错误",
+                        "description": "ID格式错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "500": {
-                        "description": "服务器内部错误",
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     }
                 }
-            }
-        },
-        "/api/tag/name": {
-            "get": {
-                "description": "通过标签名称查询标签信息",
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "更新指定用户的信息（需要 manage_users 权限，非管理员只能修改权限不超过自己的用户，只能授予/撤销自己拥有的权限，修改自己的密码请使用 /api/auth/me/password。重置密码或禁用后该用户的全部会话与 API 密钥失效）",
                 "consumes": [
                     "application/json"
                 ],
@@ -1673,21 +1630,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "标签"
+                    "认证"
                 ],
-                "summary": "根据名称查询标签",
+                "summary": "更新用户信息",
                 "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "用户信息",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.UpdateUserReq"
+                        }
+                    },
                     {
                         "type": "string",
-                        "description": "标签名称",
-                        "name": "name",
-                        "in": "query",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "标签详情",
+                        "description": "更新后的用户信息",
                         "schema": {
                             "allOf": [
                                 {
@@ -1697,7 +1671,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.Tag"
+                                            "$ref": "#/definitions/model.User"
                                         }
                                     }
                                 }
@@ -1705,23 +1679,32 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "名称参数缺失",
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
                     "404": {
-                        "description": "标签不存在",
+                        "description": "用户不存在",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     }
                 }
-            }
-        },
-        "/api/tag/popular": {
-            "get": {
-                "description": "根据使用次数降序排列获取最常用标签",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除指定用户（需要 manage_users 权限，非管理员只能删除权限不超过自己的用户），其上传的图片默认转移给管理员，也可一并删除",
                 "consumes": [
                     "application/json"
                 ],
@@ -1729,22 +1712,78 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "标签"
+                    "认证"
                 ],
-                "summary": "获取热门标签列表",
+                "summary": "删除用户",
                 "parameters": [
                     {
                         "minimum": 1,
                         "type": "integer",
-                        "default": 10,
-                        "description": "返回数量限制",
-                        "name": "limit",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "reassign",
+                            "delete"
+                        ],
+                        "type": "string",
+                        "default": "reassign",
+                        "description": "图片处理方式",
+                        "name": "images",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "标签列表",
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "400": {
+                        "description": "ID格式错误或图片处理方式无效",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足、目标用户拥有自己没有的权限或删除内置用户",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/image/count": {
+            "get": {
+                "description": "获取系统中的图片总量",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "获取图片统计",
+                "responses": {
+                    "200": {
+                        "description": "统计结果",
                         "schema": {
                             "allOf": [
                                 {
@@ -1754,10 +1793,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/model.Tag"
-                                            }
+                                            "type": "integer"
                                         }
                                     }
                                 }
@@ -1765,7 +1801,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "服务器内部错误",
+                        "description": "统计失败",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -1773,9 +1809,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/tag/search": {
-            "get": {
-                "description": "通过名称前缀模糊搜索标签",
+        "/api/image/delete": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "永久删除图片及其关联数据（需要登录）",
                 "consumes": [
                     "application/json"
                 ],
@@ -1783,29 +1824,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "标签"
+                    "图片"
                 ],
-                "summary": "根据前缀搜索标签",
+                "summary": "删除图片",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "搜索前缀",
-                        "name": "prefix",
-                        "in": "query",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
                     },
                     {
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 20,
-                        "description": "最大返回数量",
-                        "name": "limit",
-                        "in": "query"
+                        "description": "删除参数",
+                        "name": "image",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.ImageDeleteReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "匹配的标签列表",
+                        "description": "删除结果",
                         "schema": {
                             "allOf": [
                                 {
@@ -1815,10 +1857,7 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "type": "array",
-                                            "items": {
-                                                "$ref": "#/definitions/model.Tag"
-                                            }
+                                            "$ref": "#/definitions/response.ImageDeleteResponse"
                                         }
                                     }
                                 }
@@ -1826,13 +1865,25 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "前缀参数缺失",
+                        "description": "参数格式错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "500": {
-                        "description": "服务器内部错误",
+                    "401": {
+                        "description": "未授权，需要登录",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "无删除权限",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "图片不存在",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -1840,31 +1891,56 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/tag/{id}": {
+        "/api/image/duplicates": {
             "get": {
-                "description": "查询指定ID的标签完整信息",
-                "consumes": [
-                    "application/json"
-                ],
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按感知哈希将汉明距离不超过阈值的图片归为一组，按组内图片数从多到少分页返回（需要 delete_any 与 view_private 权限）",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "标签"
+                    "图片"
                 ],
-                "summary": "根据ID获取标签详情",
+                "summary": "列出相似图片分组",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "标签ID",
-                        "name": "id",
-                        "in": "path",
+                        "type": "string",
+                        "description": "用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "汉明距离阈值（0-64），默认使用设置中的值",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页分组数",
+                        "name": "per_page",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "标签详情",
+                        "description": "分页结果",
                         "schema": {
                             "allOf": [
                                 {
@@ -1874,7 +1950,22 @@ const docTemplate = `{
                                     "type": "object",
                                     "properties": {
                                         "data": {
-                                            "$ref": "#/definitions/model.Tag"
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/common.PageResp"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "content": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/response.DuplicateCluster"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
                                         }
                                     }
                                 }
@@ -1882,13 +1973,25 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "ID格式错误",
+                        "description": "参数错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "404": {
-                        "description": "标签不存在",
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "权限不足",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -1896,31 +1999,53 @@ const docTemplate = `{
                 }
             }
         },
-        "/images/image/random": {
+        "/api/image/info/{id}": {
             "get": {
-                "description": "随机获取一张图片（15分钟内同一IP最多请求15次）",
+                "description": "获取图片信息、标签与 EXIF/XMP 元数据。拍摄位置只对上传者与可以查看私有图片的用户返回",
                 "produces": [
-                    "image/*"
+                    "application/json"
                 ],
                 "tags": [
                     "图片"
                 ],
-                "summary": "获取随机图片",
+                "summary": "获取图片详情",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "图片ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
                 "responses": {
                     "200": {
-                        "description": "图片文件",
+                        "description": "图片详情",
                         "schema": {
-                            "type": "file"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Image"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "404": {
-                        "description": "无可用图片",
+                    "400": {
+                        "description": "ID格式错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     },
-                    "429": {
-                        "description": "请求过于频繁",
+                    "404": {
+                        "description": "图片不存在",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -1928,35 +2053,72 @@ const docTemplate = `{
                 }
             }
         },
-        "/images/image/{name}": {
-            "get": {
-                "description": "根据文件名直接返回图片二进制内容",
+        "/api/image/list": {
+            "post": {
+                "description": "分页获取所有图片基本信息",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
-                    "image/*"
+                    "application/json"
                 ],
                 "tags": [
                     "图片"
                 ],
-                "summary": "获取原始图片文件",
+                "summary": "分页获取图片列表",
                 "parameters": [
                     {
-                        "type": "string",
-                        "example": "\"example.jpg\"",
-                        "description": "文件名",
-                        "name": "name",
-                        "in": "path",
-                        "required": true
+                        "description": "分页参数",
+                        "name": "page",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PageReq"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "图片文件",
+                        "description": "分页结果",
                         "schema": {
-                            "type": "file"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/common.PageResp"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "content": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/model.Image"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "404": {
-                        "description": "图片不存在",
+                    "400": {
+                        "description": "参数校验失败",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
@@ -1964,101 +2126,2436 @@ const docTemplate = `{
                 }
             }
         },
-        "/images/thumbnail/{name}": {
+        "/api/image/metadata/facets": {
             "get": {
-                "description": "获取指定文件的缩略图（自动降级返回原图）",
+                "description": "统计可见图片中各相机型号与各拍摄月份的图片数，可作为 /api/image/metadata/search 的筛选项",
                 "produces": [
-                    "image/*"
+                    "application/json"
                 ],
                 "tags": [
                     "图片"
                 ],
-                "summary": "获取图片缩略图",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "example": "\"example_thumb.jpg\"",
-                        "description": "文件名",
-                        "name": "name",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                "summary": "获取相机与拍摄月份统计",
                 "responses": {
                     "200": {
-                        "description": "缩略图文件",
+                        "description": "统计结果",
                         "schema": {
-                            "type": "file"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/response.MetadataFacets"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "404": {
-                        "description": "文件不存在",
+                    "500": {
+                        "description": "服务器错误",
                         "schema": {
                             "$ref": "#/definitions/common.Resp"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "common.PageResp": {
-            "type": "object",
-            "properties": {
-                "content": {
-                    "description": "分页内容"
-                },
-                "total": {
-                    "description": "总记录数\nExample: 100",
-                    "type": "integer"
-                }
-            }
-        },
-        "common.Resp": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "description": "状态码\nExample: 200",
-                    "type": "integer"
-                },
-                "data": {
-                    "description": "返回数据"
-                },
-                "message": {
-                    "description": "消息描述\nExample: success",
-                    "type": "string"
-                }
-            }
         },
-        "handles.LoginReq": {
-            "type": "object",
-            "required": [
-                "password",
-                "username"
-            ],
-            "properties": {
-                "password": {
-                    "description": "密码",
-                    "type": "string",
-                    "example": "admin"
-                },
-                "username": {
-                    "description": "用户名",
-                    "type": "string",
-                    "example": "admin"
-                }
+        "/api/image/metadata/search": {
+            "post": {
+                "description": "按相机厂商、型号、镜头与拍摄时间筛选图片，只返回带有元数据的图片，按拍摄时间从新到旧排序",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "按元数据搜索图片",
+                "parameters": [
+                    {
+                        "description": "筛选条件与分页参数",
+                        "name": "search",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.MetadataSearchReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "分页结果",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/common.PageResp"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "content": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/model.Image"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数校验失败",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/image/search/similar": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "上传一张图片（不会保存），按感知哈希距离返回图库中最相似的图片，可选结合颜色直方图排序（需要登录）",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "以图搜图",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "查询图片",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "返回数量",
+                        "name": "limit",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 16,
+                        "description": "感知哈希的最大汉明距离（0-64）",
+                        "name": "max_distance",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "是否结合颜色直方图排序",
+                        "name": "color",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "按相似度排序的图片",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/response.SimilarImage"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "文件无效/参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "413": {
+                        "description": "文件过大或像素数超出限制",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/image/sign": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "为当前用户可见的图片（或其缩略图、预设处理结果）生成带有效期的签名链接，持有链接即可访问私有图片（需要登录）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "生成图片签名链接",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "签名参数",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.SignImageReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "签名链接",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/response.SignImageResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误/处理参数不在允许的预设中",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权，需要登录",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "图片不存在",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/image/tag/add": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "给现有图片添加一个标签（需要登录）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "添加图片标签",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "添加标签请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.AddTagReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "添加成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Tag"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求格式无效",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权，需要登录",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "无修改权限",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "图片不存在",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/image/tag/list": {
+            "post": {
+                "description": "分页获取包含指定标签的所有图片",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "根据标签获取图片",
+                "parameters": [
+                    {
+                        "minLength": 1,
+                        "type": "string",
+                        "description": "标签名称",
+                        "name": "tag",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "分页参数",
+                        "name": "page",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PageReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "分页结果",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/common.PageResp"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "content": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/model.Image"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "标签参数缺失或格式错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "标签不存在",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/image/tag/remove": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "从图片中移除指定标签（需要登录）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "移除图片标签",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "移除标签请求",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.RemoveTagReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "操作结果",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/response.ImageTagResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权，需要登录",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "无修改权限",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "图片或标签不存在",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/image/upload": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "上传图片文件并添加元数据（需要登录）",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "上传新图片",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "图片文件（支持PNG/JPEG/GIF）",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "none",
+                            "gps",
+                            "sensitive",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "元数据去除程度，只能比站点设置 metadata_strip_policy 更严格，按去除后的内容去重",
+                        "name": "strip_metadata",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "上传成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Image"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "文件无效/参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "无上传权限",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "409": {
+                        "description": "图片已存在或与已有图片相似（near_duplicate_policy 为 reject 时）",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "413": {
+                        "description": "文件过大或像素数超出限制",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "上传失败",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/image/upload/batch": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "一次上传多个图片文件或 zip 压缩包（压缩包中的图片会被展开），按文件返回新建、重复或拒绝的结果。标签与描述应用到本次新建的全部图片（需要登录）",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "批量上传图片",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "图片文件或 zip 压缩包，可重复传递",
+                        "name": "images",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "标签，可重复传递或使用逗号分隔",
+                        "name": "tags",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "描述",
+                        "name": "description",
+                        "in": "formData"
+                    },
+                    {
+                        "enum": [
+                            "none",
+                            "gps",
+                            "sensitive",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "元数据去除程度，只能比站点设置 metadata_strip_policy 更严格，按去除后的内容去重",
+                        "name": "strip_metadata",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "处理结果",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/response.BatchUploadResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "缺少文件/文件数超出上限/参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "无上传权限",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/image/upload/resumable": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "声明文件名与大小，返回上传 ID。之后使用 PATCH 按顺序上传分片，全部上传后调用 finish 完成。24 小时内没有写入的上传会被清理（需要登录）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "创建断点续传上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "文件信息",
+                        "name": "upload",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.CreateUploadReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "上传会话",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/response.UploadSessionResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误/文件扩展名不允许",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "无上传权限",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "413": {
+                        "description": "文件过大",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "429": {
+                        "description": "未完成的上传过多",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/image/upload/resumable/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "返回已接收的字节数，中断后从 offset 处继续上传。HEAD 请求只返回 Upload-Offset 与 Upload-Length 响应头",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "获取上传进度",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "上传ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "上传会话",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/response.UploadSessionResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "上传不存在或已过期",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除上传会话与已接收的数据",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "取消上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "上传ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "取消成功",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "上传不存在或已过期",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "请求体为分片的原始数据，Upload-Offset 必须等于已接收的字节数。连接中断时已接收的部分会被保留",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "上传分片",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "分片在文件中的起始位置",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "上传ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "上传会话",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/response.UploadSessionResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "上传不存在或已过期",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "409": {
+                        "description": "起始位置与已接收的字节数不一致",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "413": {
+                        "description": "分片超出声明的文件大小",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/image/upload/resumable/{id}/finish": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "全部数据上传后创建图片，结果与 /api/image/upload 一致。无论成功与否上传会话都会被删除",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "完成上传",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "上传ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "上传成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Image"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "数据未上传完成",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "无上传权限",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "上传不存在或已过期",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "413": {
+                        "description": "像素数超出限制",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "上传失败",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/image/upload/url": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "服务器下载链接指向的图片并按普通上传处理，结果与 /api/image/upload 一致。默认不允许访问回环、私有、链路本地等地址（需要登录）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "通过链接导入图片",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "图片链接",
+                        "name": "import",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.ImportImageReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "导入成功",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Image"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "链接无效/地址不允许访问/内容不是图片",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "无上传权限或未启用链接导入",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "413": {
+                        "description": "文件过大或像素数超出限制",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "下载或处理失败",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/setting": {
+            "get": {
+                "description": "获取所有公开的系统设置",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "设置"
+                ],
+                "summary": "获取公共设置",
+                "responses": {
+                    "200": {
+                        "description": "公共设置键值对",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "获取设置失败",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/setting/delete": {
+            "delete": {
+                "description": "删除设置",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "设置"
+                ],
+                "summary": "删除设置",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "key",
+                        "name": "key",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {}
+            }
+        },
+        "/api/setting/list": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "按分组列出系统设置项（需要认证）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "设置"
+                ],
+                "summary": "获取系统设置列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "设置分组ID",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "多个设置分组ID，逗号分隔",
+                        "name": "groups",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "设置项列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.SettingItem"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数格式错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权，需要登录",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "需要 manage_settings 权限",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "查询失败",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/setting/save": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "保存一组系统设置项",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "设置"
+                ],
+                "summary": "保存系统设置",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "设置项列表",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.SettingItem"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "保存成功",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "400": {
+                        "description": "请求格式错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权，需要登录",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "需要 manage_settings 权限",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "保存失败",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/setting/setting": {
+            "get": {
+                "description": "获取设置",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "设置"
+                ],
+                "summary": "获取设置",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "key",
+                        "name": "key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "keys",
+                        "name": "keys",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "设置",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/setting/sign_secret": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "重置用于签名链接的密钥，已签发的签名链接全部失效，不影响管理员 Token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "设置"
+                ],
+                "summary": "重置签名密钥",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "重置成功",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权，需要登录",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "需要 manage_settings 权限",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "保存失败",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/setting/token": {
+            "post": {
+                "description": "重置token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "设置"
+                ],
+                "summary": "重置token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tag/delete/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "删除标签并移除与所有图片的关联",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "标签"
+                ],
+                "summary": "删除标签",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Bearer 用户令牌",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "标签ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除结果",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Tag"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "ID格式错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "需要 manage_tags 权限",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "标签不存在",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器内部错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tag/image/{image_id}": {
+            "get": {
+                "description": "获取指定图片关联的所有标签",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "标签"
+                ],
+                "summary": "获取图片关联标签",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "图片ID",
+                        "name": "image_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "标签列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Tag"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "ID格式错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "图片不存在",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器内部错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tag/list": {
+            "post": {
+                "description": "使用分页方式获取所有标签列表",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "标签"
+                ],
+                "summary": "分页获取标签列表",
+                "parameters": [
+                    {
+                        "description": "分页参数",
+                        "name": "page",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/model.PageReq"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "分页结果",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "allOf": [
+                                                {
+                                                    "$ref": "#/definitions/common.PageResp"
+                                                },
+                                                {
+                                                    "type": "object",
+                                                    "properties": {
+                                                        "content": {
+                                                            "type": "array",
+                                                            "items": {
+                                                                "$ref": "#/definitions/model.Tag"
+                                                            }
+                                                        }
+                                                    }
+                                                }
+                                            ]
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "参数绑定错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器内部错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tag/name": {
+            "get": {
+                "description": "通过标签名称查询标签信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "标签"
+                ],
+                "summary": "根据名称查询标签",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "标签名称",
+                        "name": "name",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "标签详情",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Tag"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "名称参数缺失",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "标签不存在",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tag/popular": {
+            "get": {
+                "description": "根据使用次数降序排列获取最常用标签",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "标签"
+                ],
+                "summary": "获取热门标签列表",
+                "parameters": [
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 10,
+                        "description": "返回数量限制",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "标签列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Tag"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "500": {
+                        "description": "服务器内部错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tag/search": {
+            "get": {
+                "description": "通过名称前缀模糊搜索标签",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "标签"
+                ],
+                "summary": "根据前缀搜索标签",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "搜索前缀",
+                        "name": "prefix",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 20,
+                        "description": "最大返回数量",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "匹配的标签列表",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/model.Tag"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "前缀参数缺失",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "500": {
+                        "description": "服务器内部错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/tag/{id}": {
+            "get": {
+                "description": "查询指定ID的标签完整信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "标签"
+                ],
+                "summary": "根据ID获取标签详情",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "标签ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "标签详情",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/common.Resp"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/model.Tag"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "ID格式错误",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "标签不存在",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/images/image/random": {
+            "get": {
+                "description": "随机获取一张图片（15分钟内同一IP最多请求15次）",
+                "produces": [
+                    "image/*"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "获取随机图片",
+                "responses": {
+                    "200": {
+                        "description": "图片文件",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "无可用图片",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "429": {
+                        "description": "请求过于频繁",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/images/image/{name}": {
+            "get": {
+                "description": "根据文件名直接返回图片二进制内容，客户端支持时返回 WebP 格式，携带处理参数时返回按预设实时生成的图片",
+                "produces": [
+                    "image/*"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "获取原始图片文件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"example.jpg\"",
+                        "description": "文件名",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "预设名称",
+                        "name": "preset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "宽度",
+                        "name": "w",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "高度",
+                        "name": "h",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "cover",
+                            "contain"
+                        ],
+                        "type": "string",
+                        "description": "缩放方式",
+                        "name": "fit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "质量",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "webp",
+                            "jpeg",
+                            "png"
+                        ],
+                        "type": "string",
+                        "description": "输出格式",
+                        "name": "fmt",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "衍生文件版本，即图片的 variant_version，重新生成缩略图与 WebP 后更换链接，不必等待缓存过期",
+                        "name": "v",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "签名，用于访问私有图片",
+                        "name": "sign",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "图片文件",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "处理参数不在允许的预设中",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "403": {
+                        "description": "签名无效或已过期",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "图片不存在",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        },
+        "/images/thumbnail/{name}": {
+            "get": {
+                "description": "获取指定文件的缩略图（自动降级返回原图），客户端支持时返回 WebP 格式",
+                "produces": [
+                    "image/*"
+                ],
+                "tags": [
+                    "图片"
+                ],
+                "summary": "获取图片缩略图",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"example_thumb.jpg\"",
+                        "description": "文件名",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "衍生文件版本，即图片的 variant_version，重新生成缩略图与 WebP 后更换链接，不必等待缓存过期",
+                        "name": "v",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "签名，用于访问私有图片",
+                        "name": "sign",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "缩略图文件",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "签名无效或已过期",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    },
+                    "404": {
+                        "description": "文件不存在",
+                        "schema": {
+                            "$ref": "#/definitions/common.Resp"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "common.PageResp": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "description": "分页内容"
+                },
+                "total": {
+                    "description": "总记录数\nExample: 100",
+                    "type": "integer"
+                }
+            }
+        },
+        "common.Resp": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "状态码\nExample: 200",
+                    "type": "integer"
+                },
+                "data": {
+                    "description": "返回数据"
+                },
+                "message": {
+                    "description": "消息描述\nExample: success",
+                    "type": "string"
+                }
+            }
+        },
+        "common.TokenPair": {
+            "type": "object",
+            "properties": {
+                "expire": {
+                    "description": "访问令牌过期时间",
+                    "type": "string",
+                    "example": "2023-10-01T12:00:00Z"
+                },
+                "refresh_expire": {
+                    "description": "刷新令牌过期时间",
+                    "type": "string",
+                    "example": "2023-10-03T12:00:00Z"
+                },
+                "refresh_token": {
+                    "description": "刷新令牌，每次刷新后更换",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "token": {
+                    "description": "访问令牌",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                }
+            }
+        },
+        "handles.ChangePasswordReq": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "old_password"
+            ],
+            "properties": {
+                "new_password": {
+                    "description": "新密码",
+                    "type": "string",
+                    "example": "newpassword123"
+                },
+                "old_password": {
+                    "description": "旧密码",
+                    "type": "string",
+                    "example": "password123"
+                }
+            }
+        },
+        "handles.CreateAPIKeyReq": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_in": {
+                    "description": "有效期（小时），0 表示永不过期",
+                    "type": "integer",
+                    "minimum": 0,
+                    "example": 720
+                },
+                "name": {
+                    "description": "密钥名称",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "ci-uploader"
+                },
+                "scopes": {
+                    "description": "作用域：read、upload、tags",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "upload",
+                        "read"
+                    ]
+                }
+            }
+        },
+        "handles.DisableOtpReq": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "description": "TOTP 验证码或恢复码",
+                    "type": "string",
+                    "example": "123456"
+                },
+                "password": {
+                    "description": "当前密码，没有本地密码的 SSO 用户可不填",
+                    "type": "string"
+                }
+            }
+        },
+        "handles.LoginOtpReq": {
+            "type": "object",
+            "required": [
+                "code",
+                "otp_token"
+            ],
+            "properties": {
+                "code": {
+                    "description": "TOTP 验证码或恢复码",
+                    "type": "string",
+                    "example": "123456"
+                },
+                "otp_token": {
+                    "description": "第一步返回的验证凭据",
+                    "type": "string"
+                }
+            }
+        },
+        "handles.LoginReq": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "description": "密码",
+                    "type": "string",
+                    "example": "admin"
+                },
+                "username": {
+                    "description": "用户名",
+                    "type": "string",
+                    "example": "admin"
+                }
             }
         },
         "handles.LoginResp": {
             "type": "object",
             "properties": {
                 "expire": {
-                    "description": "Token 过期时间",
+                    "description": "访问令牌过期时间",
                     "type": "string",
                     "example": "2023-10-01T12:00:00Z"
                 },
+                "refresh_expire": {
+                    "description": "刷新令牌过期时间",
+                    "type": "string",
+                    "example": "2023-10-03T12:00:00Z"
+                },
+                "refresh_token": {
+                    "description": "刷新令牌，每次刷新后更换",
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
                 "token": {
-                    "description": "JWT Token",
+                    "description": "访问令牌",
                     "type": "string",
                     "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
                 },
@@ -2067,6 +4564,31 @@ const docTemplate = `{
                 }
             }
         },
+        "handles.OtpCodeReq": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "description": "TOTP 验证码或恢复码",
+                    "type": "string",
+                    "example": "123456"
+                }
+            }
+        },
+        "handles.RefreshReq": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "description": "刷新令牌",
+                    "type": "string"
+                }
+            }
+        },
         "handles.RegisterReq": {
             "type": "object",
             "required": [
@@ -2080,9 +4602,9 @@ const docTemplate = `{
                     "example": "password123"
                 },
                 "role": {
-                    "description": "角色：2-管理员，0-普通用户 1-游客（只能存在一个）",
+                    "description": "角色：只能创建普通用户（0），管理员与游客均只存在一个",
                     "type": "integer",
-                    "example": 1
+                    "example": 0
                 },
                 "username": {
                     "description": "用户名",
@@ -2091,23 +4613,30 @@ const docTemplate = `{
                 }
             }
         },
-        "handles.UpdateUserReq": {
+        "handles.SSOURLResp": {
             "type": "object",
             "properties": {
-                "disable": {
-                    "description": "禁用",
-                    "type": "boolean",
-                    "example": true
+                "url": {
+                    "description": "提供方授权链接",
+                    "type": "string",
+                    "example": "https://accounts.example.com/authorize?client_id=..."
+                }
+            }
+        },
+        "model.CameraFacet": {
+            "type": "object",
+            "properties": {
+                "camera_make": {
+                    "type": "string",
+                    "example": "Canon"
                 },
-                "password": {
-                    "description": "新密码",
+                "camera_model": {
                     "type": "string",
-                    "example": "newpassword123"
+                    "example": "Canon EOS R5"
                 },
-                "role": {
-                    "description": "角色",
+                "count": {
                     "type": "integer",
-                    "example": 1
+                    "example": 12
                 }
             }
         },
@@ -2127,6 +4656,10 @@ const docTemplate = `{
                     "description": "下载次数",
                     "type": "integer"
                 },
+                "duplicate_of": {
+                    "description": "上传时关联的相似图片",
+                    "type": "integer"
+                },
                 "file_name": {
                     "type": "string"
                 },
@@ -2142,11 +4675,30 @@ const docTemplate = `{
                 "is_public": {
                     "type": "boolean"
                 },
+                "metadata": {
+                    "description": "EXIF/XMP 元数据，只在上传与详情中返回",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.ImageMetadata"
+                        }
+                    ]
+                },
+                "near_duplicates": {
+                    "description": "NearDuplicates 上传时发现的相似图片，只在上传响应中返回",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
                 "original_name": {
                     "type": "string"
                 },
                 "path": {
-                    "description": "图片路径",
+                    "description": "图片存储 key",
+                    "type": "string"
+                },
+                "phash": {
+                    "description": "感知哈希，十六进制",
                     "type": "string"
                 },
                 "size": {
@@ -2160,7 +4712,7 @@ const docTemplate = `{
                     }
                 },
                 "thumbnail_path": {
-                    "description": "缩略图路径",
+                    "description": "缩略图存储 key",
                     "type": "string"
                 },
                 "updated_at": {
@@ -2169,6 +4721,10 @@ const docTemplate = `{
                 "user_id": {
                     "type": "integer"
                 },
+                "variant_version": {
+                    "description": "衍生文件版本，重新生成缩略图与 WebP 后递增",
+                    "type": "integer"
+                },
                 "view_count": {
                     "description": "浏览次数",
                     "type": "integer"
@@ -2181,143 +4737,553 @@ const docTemplate = `{
                 }
             }
         },
+        "model.ImageMetadata": {
+            "type": "object",
+            "properties": {
+                "altitude": {
+                    "description": "米",
+                    "type": "number"
+                },
+                "camera_make": {
+                    "type": "string"
+                },
+                "camera_model": {
+                    "type": "string"
+                },
+                "creator": {
+                    "type": "string"
+                },
+                "date_taken": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "exposure_time": {
+                    "description": "快门时间，如 1/125",
+                    "type": "string"
+                },
+                "f_number": {
+                    "type": "number"
+                },
+                "focal_length": {
+                    "description": "毫米",
+                    "type": "number"
+                },
+                "iso": {
+                    "type": "integer"
+                },
+                "keywords": {
+                    "description": "逗号分隔",
+                    "type": "string"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "lens_model": {
+                    "type": "string"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "orientation": {
+                    "description": "EXIF 方向 1-8",
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.MonthFacet": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2024-05"
+                }
+            }
+        },
         "model.PageReq": {
             "type": "object",
             "properties": {
-                "page": {
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.Session": {
+            "type": "object",
+            "properties": {
+                "current": {
+                    "description": "是否为当前请求使用的会话",
+                    "type": "boolean"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "issued_at": {
+                    "type": "string"
+                },
+                "last_seen": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.SettingItem": {
+            "type": "object",
+            "required": [
+                "key"
+            ],
+            "properties": {
+                "flag": {
+                    "description": "0 = public, 1 = private, 2 = readonly, 3 = deprecated, etc.",
+                    "type": "integer"
+                },
+                "group": {
+                    "description": "use to group setting in frontend",
+                    "type": "integer"
+                },
+                "help": {
+                    "description": "help message",
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "key": {
+                    "description": "unique key",
+                    "type": "string"
+                },
+                "options": {
+                    "description": "values for select",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "string, number, bool, select",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "value",
+                    "type": "string"
+                }
+            }
+        },
+        "model.Tag": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "使用此标签的图片数量",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "description": "标签名称，唯一且索引",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.User": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "disabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "unique key",
+                    "type": "integer"
+                },
+                "otp_enabled": {
+                    "description": "是否已启用两步验证",
+                    "type": "boolean"
+                },
+                "permission": {
+                    "description": "Determine permissions by bit",
+                    "type": "integer"
+                },
+                "role": {
+                    "description": "user's role",
+                    "type": "integer"
+                },
+                "username": {
+                    "description": "username",
+                    "type": "string"
+                }
+            }
+        },
+        "request.AddTagReq": {
+            "type": "object",
+            "required": [
+                "id",
+                "tag"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "tag": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.CreateUploadReq": {
+            "type": "object",
+            "required": [
+                "filename",
+                "size"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "size": {
+                    "description": "文件总大小（字节）",
+                    "type": "integer"
+                },
+                "strip_metadata": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.ImageDeleteReq": {
+            "type": "object",
+            "required": [
+                "id"
+            ],
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "request.ImportImageReq": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "strip_metadata": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "request.MetadataSearchReq": {
+            "type": "object",
+            "properties": {
+                "camera_make": {
+                    "type": "string"
+                },
+                "camera_model": {
+                    "type": "string"
+                },
+                "lens_model": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "per_page": {
+                    "type": "integer"
+                },
+                "taken_after": {
+                    "description": "拍摄时间不早于，RFC3339 格式",
+                    "type": "string"
+                },
+                "taken_before": {
+                    "description": "拍摄时间早于，RFC3339 格式",
+                    "type": "string"
+                }
+            }
+        },
+        "request.RemoveTagReq": {
+            "type": "object",
+            "required": [
+                "image_id",
+                "tag_id"
+            ],
+            "properties": {
+                "image_id": {
                     "type": "integer"
                 },
-                "per_page": {
+                "tag_id": {
                     "type": "integer"
                 }
             }
         },
-        "model.SettingItem": {
+        "request.SignImageReq": {
             "type": "object",
             "required": [
-                "key"
+                "name"
             ],
             "properties": {
-                "flag": {
-                    "description": "0 = public, 1 = private, 2 = readonly, 3 = deprecated, etc.",
+                "expire": {
+                    "description": "有效期（秒），0 使用默认设置",
                     "type": "integer"
                 },
-                "group": {
-                    "description": "use to group setting in frontend",
-                    "type": "integer"
+                "fit": {
+                    "type": "string"
                 },
-                "help": {
-                    "description": "help message",
+                "fmt": {
                     "type": "string"
                 },
-                "index": {
+                "h": {
                     "type": "integer"
                 },
-                "key": {
-                    "description": "unique key",
+                "name": {
+                    "description": "图片文件名",
                     "type": "string"
                 },
-                "options": {
-                    "description": "values for select",
+                "preset": {
                     "type": "string"
                 },
-                "type": {
-                    "description": "string, number, bool, select",
-                    "type": "string"
+                "q": {
+                    "type": "integer"
                 },
-                "value": {
-                    "description": "value",
-                    "type": "string"
+                "thumbnail": {
+                    "description": "是否为缩略图链接",
+                    "type": "boolean"
+                },
+                "w": {
+                    "type": "integer"
                 }
             }
         },
-        "model.Tag": {
+        "request.UpdateUserReq": {
             "type": "object",
             "properties": {
-                "count": {
-                    "description": "使用此标签的图片数量",
-                    "type": "integer"
+                "disable": {
+                    "description": "禁用",
+                    "type": "boolean",
+                    "example": true
+                },
+                "grant": {
+                    "description": "授予的权限",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "upload",
+                        "delete_own"
+                    ]
+                },
+                "password": {
+                    "description": "新密码",
+                    "type": "string",
+                    "example": "newpassword123"
+                },
+                "revoke": {
+                    "description": "撤销的权限",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "delete_any"
+                    ]
                 },
+                "role": {
+                    "description": "角色",
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "response.APIKeyResponse": {
+            "type": "object",
+            "properties": {
                 "created_at": {
                     "type": "string"
                 },
+                "expires_at": {
+                    "description": "为空时永不过期",
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "last_used": {
+                    "type": "string"
+                },
                 "name": {
-                    "description": "标签名称，唯一且索引",
                     "type": "string"
                 },
-                "updated_at": {
+                "prefix": {
+                    "description": "密钥开头几位，便于辨认",
                     "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "upload"
+                    ]
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "model.User": {
+        "response.BatchUploadResponse": {
             "type": "object",
-            "required": [
-                "username"
-            ],
             "properties": {
-                "disabled": {
-                    "type": "boolean"
-                },
-                "id": {
-                    "description": "unique key",
-                    "type": "integer"
+                "created": {
+                    "type": "integer",
+                    "example": 2
                 },
-                "permission": {
-                    "description": "Determine permissions by bit",
-                    "type": "integer"
+                "duplicates": {
+                    "type": "integer",
+                    "example": 1
                 },
-                "role": {
-                    "description": "user's role",
-                    "type": "integer"
+                "rejected": {
+                    "type": "integer",
+                    "example": 0
                 },
-                "username": {
-                    "description": "username",
-                    "type": "string"
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.BatchUploadResult"
+                    }
                 }
             }
         },
-        "request.AddTagReq": {
+        "response.BatchUploadResult": {
             "type": "object",
-            "required": [
-                "id",
-                "tag"
-            ],
             "properties": {
-                "id": {
-                    "type": "integer"
+                "duplicate_of": {
+                    "description": "已存在图片的ID，对上传者不可见时不返回",
+                    "type": "integer",
+                    "example": 1
                 },
-                "tag": {
-                    "type": "string"
+                "image": {
+                    "description": "新建的图片",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/model.Image"
+                        }
+                    ]
+                },
+                "name": {
+                    "description": "压缩包中的文件为 压缩包名/文件路径",
+                    "type": "string",
+                    "example": "photos.zip/a.jpg"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "invalid file extension: .txt"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "duplicate",
+                        "rejected"
+                    ],
+                    "example": "created"
                 }
             }
         },
-        "request.ImageDeleteReq": {
+        "response.CreateAPIKeyResponse": {
             "type": "object",
-            "required": [
-                "id"
-            ],
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "为空时永不过期",
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
+                },
+                "key": {
+                    "description": "only returned once",
+                    "type": "string",
+                    "example": "ibk_xxxxxxxx"
+                },
+                "last_used": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "密钥开头几位，便于辨认",
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "read",
+                        "upload"
+                    ]
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "request.RemoveTagReq": {
+        "response.DuplicateCluster": {
             "type": "object",
-            "required": [
-                "image_id",
-                "tag_id"
-            ],
             "properties": {
-                "image_id": {
-                    "type": "integer"
+                "images": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.Image"
+                    }
                 },
-                "tag_id": {
-                    "type": "integer"
+                "max_distance": {
+                    "description": "组内感知哈希的最大汉明距离",
+                    "type": "integer",
+                    "example": 4
                 }
             }
         },
@@ -2348,17 +5314,160 @@ const docTemplate = `{
                 }
             }
         },
-        "response.TagDeleteResponse": {
+        "response.MetadataFacets": {
+            "type": "object",
+            "properties": {
+                "cameras": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.CameraFacet"
+                    }
+                },
+                "months": {
+                    "description": "从新到旧排序",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.MonthFacet"
+                    }
+                }
+            }
+        },
+        "response.RecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "abcde-12345"
+                    ]
+                }
+            }
+        },
+        "response.SignImageResponse": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "0 means never expire",
+                    "type": "integer",
+                    "example": 1700000000
+                },
+                "url": {
+                    "type": "string",
+                    "example": "/images/image/abc123.jpg?sign=xxx:1700000000"
+                }
+            }
+        },
+        "response.SimilarImage": {
+            "type": "object",
+            "properties": {
+                "distance": {
+                    "description": "感知哈希的汉明距离",
+                    "type": "integer",
+                    "example": 3
+                },
+                "image": {
+                    "$ref": "#/definitions/model.Image"
+                },
+                "similarity": {
+                    "description": "0 ~ 1，越大越相似",
+                    "type": "number",
+                    "example": 0.95
+                }
+            }
+        },
+        "response.TwoFactorEnrollResponse": {
+            "type": "object",
+            "properties": {
+                "secret": {
+                    "type": "string",
+                    "example": "JBSWY3DPEHPK3PXP"
+                },
+                "uri": {
+                    "type": "string",
+                    "example": "otpauth://totp/image-board:admin?secret=JBSWY3DPEHPK3PXP\u0026issuer=image-board"
+                }
+            }
+        },
+        "response.TwoFactorStatusResponse": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "recovery_codes_remaining": {
+                    "type": "integer",
+                    "example": 10
+                }
+            }
+        },
+        "response.UploadSessionResponse": {
             "type": "object",
             "properties": {
+                "expires_at": {
+                    "type": "string",
+                    "example": "2020-01-02 01:01:01"
+                },
+                "file_name": {
+                    "type": "string",
+                    "example": "panorama.jpg"
+                },
                 "id": {
-                    "type": "integer"
+                    "type": "string",
+                    "example": "Xk2p9..."
                 },
-                "name": {
-                    "type": "string"
+                "offset": {
+                    "description": "已接收的字节数，下一个分片从此处开始",
+                    "type": "integer",
+                    "example": 52428800
                 },
-                "success": {
+                "size": {
+                    "type": "integer",
+                    "example": 104857600
+                }
+            }
+        },
+        "response.UserInfoResponse": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "disabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "description": "unique key",
+                    "type": "integer"
+                },
+                "otp_enabled": {
+                    "description": "是否已启用两步验证",
                     "type": "boolean"
+                },
+                "permission": {
+                    "description": "Determine permissions by bit",
+                    "type": "integer"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "upload",
+                        "delete_own"
+                    ]
+                },
+                "role": {
+                    "description": "user's role",
+                    "type": "integer"
+                },
+                "username": {
+                    "description": "username",
+                    "type": "string"
                 }
             }
         }
@@ -46,32 +46,53 @@ type Scheme struct {
 }
 
 type DataImage struct {
-	Dir string `json:"dir" env:"DIR"`
+	Dir      string `json:"dir" env:"DIR"`
+	CacheDir string `json:"cache_dir" env:"CACHE_DIR"` // 实时处理生成的图片缓存目录
+}
+
+type S3 struct {
+	Endpoint  string `json:"endpoint" env:"ENDPOINT"`
+	Bucket    string `json:"bucket" env:"BUCKET"`
+	Region    string `json:"region" env:"REGION"`
+	AccessKey string `json:"access_key" env:"ACCESS_KEY"`
+	SecretKey string `json:"secret_key" env:"SECRET_KEY"`
+	UseSSL    bool   `json:"use_ssl" env:"USE_SSL"`
+	PathStyle bool   `json:"path_style" env:"PATH_STYLE"`
+	RootPath  string `json:"root_path" env:"ROOT_PATH"`
+}
+
+type Storage struct {
+	Type string `json:"type" env:"TYPE"` // local, s3
+	S3   S3     `json:"s3" envPrefix:"S3_"`
 }
 
 type Config struct {
-	SiteURL        string `json:"site_url" env:"SITE_URL"`
-	JwtSecret      string `json:"jwt_secret" env:"JWT_SECRET"`
-	TokenExpiresIn int    `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`
-	DelayedStart   int    `json:"delayed_start" env:"DELAYED_START"`
-	Cdn            string `json:"cdn" env:"CDN"`
-	DistDir        string `json:"dist_dir"`
+	SiteURL              string `json:"site_url" env:"SITE_URL"`
+	JwtSecret            string `json:"jwt_secret" env:"JWT_SECRET"`
+	TokenExpiresIn       int    `json:"token_expires_in" env:"TOKEN_EXPIRES_IN"`               // 会话（刷新令牌）有效期，单位小时，每次刷新后顺延
+	AccessTokenExpiresIn int    `json:"access_token_expires_in" env:"ACCESS_TOKEN_EXPIRES_IN"` // 访问令牌有效期，单位分钟
+	DelayedStart         int    `json:"delayed_start" env:"DELAYED_START"`
+	Cdn                  string `json:"cdn" env:"CDN"`
+	DistDir              string `json:"dist_dir"`
 
 	Scheme    Scheme    `json:"scheme"`
 	Cors      Cors      `json:"cors" envPrefix:"CORS_"`
 	Log       LogConfig `json:"log"`
 	Database  Database  `json:"database" envPrefix:"DB_"`
 	DataImage DataImage `json:"data_image" envPrefix:"DATA_"`
+	Storage   Storage   `json:"storage" envPrefix:"STORAGE_"`
 }
 
 func DefaultConfig() *Config {
 	logPath := path.Join(flags.DataDir, "log/log.log")
 	dbPath := path.Join(flags.DataDir, "images.db")
 	imagePath := path.Join(flags.DataDir, "images")
+	cachePath := path.Join(flags.DataDir, "cache")
 	// 默认设置
 	return &Config{
-		JwtSecret:      random.String(16),
-		TokenExpiresIn: 48,
+		JwtSecret:            random.String(16),
+		TokenExpiresIn:       48,
+		AccessTokenExpiresIn: 15,
 		Scheme: Scheme{
 			Address:    "0.0.0.0",
 			UnixFile:   "",
@@ -88,7 +109,16 @@ func DefaultConfig() *Config {
 			DBFile:      dbPath,
 		},
 		DataImage: DataImage{
-			Dir: imagePath,
+			Dir:      imagePath,
+			CacheDir: cachePath,
+		},
+		Storage: Storage{
+			Type: "local",
+			S3: S3{
+				Region:    "us-east-1",
+				UseSSL:    true,
+				PathStyle: false,
+			},
 		},
 		Cors: Cors{
 			AllowOrigins: []string{"*"},
@@ -11,8 +11,14 @@ const (
 const (
 
 	// image
-	ImageMaxSize = "image_max_size"
-	ImageTypes   = "image_types"
+	ImageMaxSize           = "image_max_size"
+	ImageMaxPixels         = "image_max_pixels"
+	ImageTypes             = "image_types"
+	ImageTransformPresets  = "image_transform_presets"
+	NearDuplicatePolicy    = "near_duplicate_policy"
+	NearDuplicateThreshold = "near_duplicate_threshold"
+	MetadataStripPolicy    = "metadata_strip_policy"
+	MetadataRetainPrivate  = "metadata_retain_private"
 
 	// Site
 	VERSION          = "version"
@@ -28,12 +34,44 @@ const (
 	Favicon = "favicon"
 
 	// Gloabl
+	PermissionMigrated  = "permission_migrated"
 	PrivacyRegs         = "privacy_regs"
 	FilenameCharMapping = "filename_char_mapping"
 	LinkExpiration      = "link_expiration"
 
+	// s3
+	S3PresignRedirect = "s3_presign_redirect"
+	S3PresignExpire   = "s3_presign_expire"
+
+	// sso
+	SSOLoginEnabled   = "sso_login_enabled"
+	SSOIssuer         = "sso_issuer"
+	SSOClientID       = "sso_client_id"
+	SSOClientSecret   = "sso_client_secret"
+	SSORedirectURI    = "sso_redirect_uri"
+	SSOScopes         = "sso_scopes"
+	SSOAllowedDomains = "sso_allowed_domains"
+	SSOAutoRegister   = "sso_auto_register"
+	SSORoleClaim      = "sso_role_claim"
+	SSORoleMapping    = "sso_role_mapping"
+
+	// ldap
+	LDAPLoginEnabled     = "ldap_login_enabled"
+	LDAPServer           = "ldap_server"
+	LDAPStartTLS         = "ldap_start_tls"
+	LDAPBindDNTemplate   = "ldap_bind_dn_template"
+	LDAPSearchBase       = "ldap_search_base"
+	LDAPAdminGroupFilter = "ldap_admin_group_filter"
+
+	// offline download
+	URLImportEnabled         = "url_import_enabled"
+	URLImportMaxSize         = "url_import_max_size"
+	URLImportTimeout         = "url_import_timeout"
+	URLImportAllowedNetworks = "url_import_allowed_networks"
+
 	// single
-	Token = "token"
+	Token      = "token"
+	SignSecret = "sign_secret"
 )
 
 const (
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"time"
+
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/pkg/errors"
+)
+
+// CreateAPIKey creates a new api key
+func CreateAPIKey(key *model.APIKey) error {
+	return errors.WithStack(db.Create(key).Error)
+}
+
+// GetAPIKeyByHash retrieves an api key by the hash of its secret
+func GetAPIKeyByHash(hash string) (*model.APIKey, error) {
+	var key model.APIKey
+	if err := db.Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &key, nil
+}
+
+// GetAPIKeysByUser retrieves all api keys of a user
+func GetAPIKeysByUser(userID uint) ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+	if err := db.Where("user_id = ?", userID).Order("id ASC").Find(&keys).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return keys, nil
+}
+
+// GetAPIKeys retrieves all api keys
+func GetAPIKeys() ([]*model.APIKey, error) {
+	var keys []*model.APIKey
+	if err := db.Order("id ASC").Find(&keys).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return keys, nil
+}
+
+// UpdateAPIKeyLastUsed updates the last used time of an api key
+func UpdateAPIKeyLastUsed(id uint, t time.Time) error {
+	return errors.WithStack(db.Model(&model.APIKey{}).Where("id = ?", id).Update("last_used", t).Error)
+}
+
+// DeleteAPIKey deletes an api key and returns it, userID 0 matches any user
+func DeleteAPIKey(userID, id uint) (*model.APIKey, error) {
+	var key model.APIKey
+	query := db.Where("id = ?", id)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if err := query.First(&key).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := db.Delete(&key).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &key, nil
+}
+
+// DeleteUserAPIKeys deletes all api keys of a user
+func DeleteUserAPIKeys(userID uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userID).Delete(&model.APIKey{}).Error)
+}
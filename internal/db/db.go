@@ -11,7 +11,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.User), new(model.Image), new(model.SettingItem), new(model.ImageTag), new(model.Tag))
+	err := AutoMigrate(new(model.User), new(model.Image), new(model.SettingItem), new(model.ImageTag), new(model.Tag), new(model.Session), new(model.APIKey), new(model.RecoveryCode), new(model.UploadSession), new(model.ImageMetadata))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
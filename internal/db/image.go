@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/FXAZfung/image-board/internal/errs"
 	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/pkg/imghash"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -42,10 +43,26 @@ func GetImageByHash(hash string) (*model.Image, error) {
 	return &image, nil
 }
 
+// visibleTo 只保留 viewer 可见的图片，viewer 为 nil 表示匿名访问
+func visibleTo(viewer *model.User) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		switch {
+		case viewer == nil:
+			return tx.Where("is_public = ?", true)
+		case viewer.HasPermission(model.PermViewPrivate):
+			return tx
+		case viewer.IsGuest():
+			return tx.Where("is_public = ?", true)
+		default:
+			return tx.Where("is_public = ? OR user_id = ?", true, viewer.ID)
+		}
+	}
+}
+
 // GetImagesByPage retrieves paginated images with their tags
-func GetImagesByPage(page, perPage int) ([]*model.Image, int64, error) {
+func GetImagesByPage(page, perPage int, viewer *model.User) ([]*model.Image, int64, error) {
 	var count int64
-	if err := db.Model(&model.Image{}).Count(&count).Error; err != nil {
+	if err := db.Model(&model.Image{}).Scopes(visibleTo(viewer)).Count(&count).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -53,7 +70,7 @@ func GetImagesByPage(page, perPage int) ([]*model.Image, int64, error) {
 	offset := (page - 1) * perPage
 
 	// Add Preload("Tags") to load the associated tags
-	if err := db.Preload("Tags").Offset(offset).Limit(perPage).Order("id desc").Find(&images).Error; err != nil {
+	if err := db.Preload("Tags").Scopes(visibleTo(viewer)).Offset(offset).Limit(perPage).Order("id desc").Find(&images).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -61,7 +78,7 @@ func GetImagesByPage(page, perPage int) ([]*model.Image, int64, error) {
 }
 
 // GetImagesByTag retrieves images with a specific tag
-func GetImagesByTag(tagName string, page, pageSize int) ([]*model.Image, int64, error) {
+func GetImagesByTag(tagName string, page, pageSize int, viewer *model.User) ([]*model.Image, int64, error) {
 	var images []*model.Image
 	var count int64
 
@@ -79,6 +96,7 @@ func GetImagesByTag(tagName string, page, pageSize int) ([]*model.Image, int64,
 	countErr := db.Model(&model.Image{}).
 		Joins("INNER JOIN im_image_tags ON im_image_tags.image_id = im_images.id").
 		Where("im_image_tags.tag_id = ?", tag.ID).
+		Scopes(visibleTo(viewer)).
 		Count(&count).Error
 
 	if countErr != nil {
@@ -100,6 +118,7 @@ func GetImagesByTag(tagName string, page, pageSize int) ([]*model.Image, int64,
 		Table("im_images").
 		Joins("INNER JOIN im_image_tags ON im_image_tags.image_id = im_images.id").
 		Where("im_image_tags.tag_id = ?", tag.ID).
+		Scopes(visibleTo(viewer)).
 		Offset((page - 1) * pageSize).
 		Limit(pageSize).
 		Find(&images).Error
@@ -144,6 +163,10 @@ func DeleteImage(imageID uint) error {
 			}
 		}
 
+		if err := tx.Where("image_id = ?", imageID).Delete(&model.ImageMetadata{}).Error; err != nil {
+			return err
+		}
+
 		// 删除图片记录
 		return tx.Delete(&image).Error
 	})
@@ -189,19 +212,19 @@ func RemoveTagFromImage(imageID uint, tagID uint) (*model.Tag, error) {
 	return &tag, nil
 }
 
-// GetImageCount 获取图片总数
-func GetImageCount() (int64, error) {
+// GetImageCount 获取 viewer 可见的图片总数
+func GetImageCount(viewer *model.User) (int64, error) {
 	var count int64
-	if err := db.Model(&model.Image{}).Count(&count).Error; err != nil {
+	if err := db.Model(&model.Image{}).Scopes(visibleTo(viewer)).Count(&count).Error; err != nil {
 		return 0, errors.WithStack(errs.ErrImageCount)
 	}
 	return count, nil
 }
 
-// GetRandomImage 随机获取图片
-func GetRandomImage() (*model.Image, error) {
+// GetRandomImage 随机获取一张 viewer 可见的图片
+func GetRandomImage(viewer *model.User) (*model.Image, error) {
 	var image model.Image
-	query := db.Preload("Tags").Order("RANDOM()")
+	query := db.Preload("Tags").Scopes(visibleTo(viewer)).Order("RANDOM()")
 
 	if err := query.First(&image).Error; err != nil {
 		return nil, errors.WithStack(errs.ImageNotFound)
@@ -209,3 +232,105 @@ func GetRandomImage() (*model.Image, error) {
 
 	return &image, nil
 }
+
+// GetImagesByPathPrefix 获取路径以 prefix 开头的图片
+func GetImagesByPathPrefix(prefix string) ([]*model.Image, error) {
+	var images []*model.Image
+	if err := db.Where("path LIKE ?", prefix+"%").Find(&images).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return images, nil
+}
+
+// UpdateImagePaths 更新图片的存储 key
+func UpdateImagePaths(image *model.Image) error {
+	return db.Model(image).Updates(map[string]interface{}{
+		"path":           image.Path,
+		"thumbnail_path": image.ThumbnailPath,
+		"webp_path":      image.WebpPath,
+	}).Error
+}
+
+// GetImagePHashes 获取全部已计算感知哈希的图片
+func GetImagePHashes() ([]model.ImagePHash, error) {
+	var rows []struct {
+		ID    uint
+		PHash string `gorm:"column:phash"`
+	}
+	if err := db.Model(&model.Image{}).Select("id, phash").Where("phash <> ''").Find(&rows).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	hashes := make([]model.ImagePHash, 0, len(rows))
+	for _, row := range rows {
+		h, err := imghash.Parse(row.PHash)
+		if err != nil {
+			log.Warnf("invalid phash of image %d: %s", row.ID, row.PHash)
+			continue
+		}
+		hashes = append(hashes, model.ImagePHash{ID: row.ID, PHash: h})
+	}
+	return hashes, nil
+}
+
+// GetImagesWithoutHashes 按 ID 顺序获取尚未计算感知哈希或颜色直方图的图片
+func GetImagesWithoutHashes(afterID uint, limit int) ([]*model.Image, error) {
+	var images []*model.Image
+	err := db.Where("id > ?", afterID).
+		Where("phash = '' OR phash IS NULL OR color_hist = '' OR color_hist IS NULL").
+		Order("id").Limit(limit).Find(&images).Error
+	return images, errors.WithStack(err)
+}
+
+// GetImagesAfter 按 ID 顺序获取 ID 大于 afterID 的图片
+func GetImagesAfter(afterID uint, limit int) ([]*model.Image, error) {
+	var images []*model.Image
+	err := db.Where("id > ?", afterID).Order("id").Limit(limit).Find(&images).Error
+	return images, errors.WithStack(err)
+}
+
+// GetImagesByIDs 按 ID 获取 viewer 可见的图片
+func GetImagesByIDs(ids []uint, viewer *model.User) ([]*model.Image, error) {
+	var images []*model.Image
+	if err := db.Preload("Tags").Scopes(visibleTo(viewer)).Where("id IN ?", ids).Find(&images).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return images, nil
+}
+
+// UpdateImageHashes 更新图片的感知哈希与颜色直方图，不修改更新时间
+func UpdateImageHashes(image *model.Image) error {
+	return errors.WithStack(db.Model(image).UpdateColumns(map[string]interface{}{
+		"phash":      image.PHash,
+		"color_hist": image.ColorHist,
+	}).Error)
+}
+
+// UpdateImageDerived 更新由图片内容计算的宽高、感知哈希与颜色直方图并递增衍生文件版本，不修改更新时间
+func UpdateImageDerived(image *model.Image) error {
+	if err := db.Model(image).UpdateColumns(map[string]interface{}{
+		"width":           image.Width,
+		"height":          image.Height,
+		"phash":           image.PHash,
+		"color_hist":      image.ColorHist,
+		"variant_version": gorm.Expr("variant_version + ?", 1),
+	}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	image.VariantVersion++
+	return nil
+}
+
+// GetImagesByUserID 获取用户上传的全部图片
+func GetImagesByUserID(userID uint) ([]*model.Image, error) {
+	var images []*model.Image
+	if err := db.Where("user_id = ?", userID).Find(&images).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return images, nil
+}
+
+// ReassignImages 将 from 上传的图片转移给 to
+func ReassignImages(from, to uint) (int64, error) {
+	result := db.Model(&model.Image{}).Where("user_id = ?", from).Update("user_id", to)
+	return result.RowsAffected, errors.WithStack(result.Error)
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"time"
+
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// GetImageMetadata 获取图片的元数据
+func GetImageMetadata(imageID uint) (*model.ImageMetadata, error) {
+	var metadata model.ImageMetadata
+	if err := db.First(&metadata, "image_id = ?", imageID).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &metadata, nil
+}
+
+// SaveImageMetadata 保存图片的元数据，已存在时覆盖
+func SaveImageMetadata(metadata *model.ImageMetadata) error {
+	return errors.WithStack(db.Save(metadata).Error)
+}
+
+// metadataFilter 连接元数据表并按 filter 筛选
+func metadataFilter(filter model.MetadataFilter) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		tx = tx.Joins("INNER JOIN im_image_metadata ON im_image_metadata.image_id = im_images.id")
+		if filter.CameraMake != "" {
+			tx = tx.Where("im_image_metadata.camera_make = ?", filter.CameraMake)
+		}
+		if filter.CameraModel != "" {
+			tx = tx.Where("im_image_metadata.camera_model = ?", filter.CameraModel)
+		}
+		if filter.LensModel != "" {
+			tx = tx.Where("im_image_metadata.lens_model = ?", filter.LensModel)
+		}
+		if filter.TakenAfter != nil {
+			tx = tx.Where("im_image_metadata.date_taken >= ?", *filter.TakenAfter)
+		}
+		if filter.TakenBefore != nil {
+			tx = tx.Where("im_image_metadata.date_taken < ?", *filter.TakenBefore)
+		}
+		return tx
+	}
+}
+
+// SearchImagesByMetadata 按元数据分页筛选 viewer 可见的图片，按拍摄时间从新到旧排序
+func SearchImagesByMetadata(filter model.MetadataFilter, page, perPage int, viewer *model.User) ([]*model.Image, int64, error) {
+	var count int64
+	if err := db.Model(&model.Image{}).Scopes(metadataFilter(filter), visibleTo(viewer)).Count(&count).Error; err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+
+	var images []*model.Image
+	err := db.Preload("Tags").Preload("Metadata").
+		Scopes(metadataFilter(filter), visibleTo(viewer)).
+		Order("im_image_metadata.date_taken desc").Order("im_images.id desc").
+		Offset((page - 1) * perPage).Limit(perPage).
+		Find(&images).Error
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	return images, count, nil
+}
+
+// GetCameraFacets 统计 viewer 可见的图片中各相机型号的图片数
+func GetCameraFacets(viewer *model.User) ([]model.CameraFacet, error) {
+	facets := make([]model.CameraFacet, 0)
+	err := db.Model(&model.Image{}).
+		Scopes(metadataFilter(model.MetadataFilter{}), visibleTo(viewer)).
+		Select("im_image_metadata.camera_make, im_image_metadata.camera_model, COUNT(*) AS count").
+		Where("im_image_metadata.camera_make <> '' OR im_image_metadata.camera_model <> ''").
+		Group("im_image_metadata.camera_make, im_image_metadata.camera_model").
+		Order("count desc").
+		Scan(&facets).Error
+	return facets, errors.WithStack(err)
+}
+
+// GetDatesTaken 获取 viewer 可见的图片的拍摄时间
+func GetDatesTaken(viewer *model.User) ([]time.Time, error) {
+	var dates []time.Time
+	err := db.Model(&model.Image{}).
+		Scopes(metadataFilter(model.MetadataFilter{}), visibleTo(viewer)).
+		Where("im_image_metadata.date_taken IS NOT NULL").
+		Pluck("im_image_metadata.date_taken", &dates).Error
+	return dates, errors.WithStack(err)
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// ReplaceRecoveryCodes replaces all recovery codes of a user
+func ReplaceRecoveryCodes(userID uint, hashes []string) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]model.RecoveryCode, 0, len(hashes))
+		for _, hash := range hashes {
+			codes = append(codes, model.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	}))
+}
+
+// UseRecoveryCode deletes a matching recovery code of a user, returns whether it existed
+func UseRecoveryCode(userID uint, hash string) (bool, error) {
+	result := db.Where("user_id = ? AND code_hash = ?", userID, hash).Delete(&model.RecoveryCode{})
+	return result.RowsAffected > 0, errors.WithStack(result.Error)
+}
+
+// CountRecoveryCodes counts the remaining recovery codes of a user
+func CountRecoveryCodes(userID uint) (int64, error) {
+	var count int64
+	err := db.Model(&model.RecoveryCode{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, errors.WithStack(err)
+}
+
+// DeleteRecoveryCodes deletes all recovery codes of a user
+func DeleteRecoveryCodes(userID uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error)
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"time"
+
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/pkg/errors"
+)
+
+// CreateSession creates a new login session
+func CreateSession(session *model.Session) error {
+	return errors.WithStack(db.Create(session).Error)
+}
+
+// GetSession retrieves an unexpired session by its id
+func GetSession(id string) (*model.Session, error) {
+	var session model.Session
+	if err := db.Where("id = ? AND expires_at > ?", id, time.Now()).First(&session).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &session, nil
+}
+
+// GetSessionsByUser retrieves all unexpired sessions of a user
+func GetSessionsByUser(userID uint) ([]*model.Session, error) {
+	var sessions []*model.Session
+	if err := db.Where("user_id = ? AND expires_at > ?", userID, time.Now()).
+		Order("last_seen DESC").Find(&sessions).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return sessions, nil
+}
+
+// UpdateSessionLastSeen updates the last seen time of a session
+func UpdateSessionLastSeen(id string, t time.Time) error {
+	return errors.WithStack(db.Model(&model.Session{}).Where("id = ?", id).Update("last_seen", t).Error)
+}
+
+// DeleteSession deletes a session of the user
+func DeleteSession(userID uint, id string) (int64, error) {
+	result := db.Where("id = ? AND user_id = ?", id, userID).Delete(&model.Session{})
+	return result.RowsAffected, errors.WithStack(result.Error)
+}
+
+// DeleteUserSessions deletes all sessions of the user except the given one
+func DeleteUserSessions(userID uint, except string) error {
+	return errors.WithStack(db.Where("user_id = ? AND id <> ?", userID, except).Delete(&model.Session{}).Error)
+}
+
+// DeleteExpiredSessions deletes all expired sessions
+func DeleteExpiredSessions() error {
+	return errors.WithStack(db.Where("expires_at <= ?", time.Now()).Delete(&model.Session{}).Error)
+}
+
+// RotateSessionRefresh 刷新令牌代数为 gen 时递增代数并顺延会话，返回是否更新成功
+func RotateSessionRefresh(id string, gen int64, expiresAt, now time.Time) (bool, error) {
+	result := db.Model(&model.Session{}).Where("id = ? AND refresh_gen = ?", id, gen).Updates(map[string]interface{}{
+		"refresh_gen": gen + 1,
+		"expires_at":  expiresAt,
+		"last_seen":   now,
+	})
+	return result.RowsAffected > 0, errors.WithStack(result.Error)
+}
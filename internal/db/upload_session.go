@@ -0,0 +1,72 @@
+package db
+
+import (
+	"time"
+
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/pkg/errors"
+)
+
+// CreateUploadSession creates a new resumable upload session
+func CreateUploadSession(session *model.UploadSession) error {
+	return errors.WithStack(db.Create(session).Error)
+}
+
+// GetUploadSession retrieves an unexpired upload session of the user
+func GetUploadSession(userID uint, id string) (*model.UploadSession, error) {
+	var session model.UploadSession
+	if err := db.Where("id = ? AND user_id = ? AND expires_at > ?", id, userID, time.Now()).First(&session).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &session, nil
+}
+
+// CountUserUploadSessions counts the unexpired upload sessions of the user
+func CountUserUploadSessions(userID uint) (int64, error) {
+	var count int64
+	err := db.Model(&model.UploadSession{}).Where("user_id = ? AND expires_at > ?", userID, time.Now()).Count(&count).Error
+	return count, errors.WithStack(err)
+}
+
+// UpdateUploadSessionProgress updates the received offset and hash state of an upload session
+func UpdateUploadSessionProgress(session *model.UploadSession) error {
+	return errors.WithStack(db.Model(session).Select("offset", "hash_state", "expires_at").Updates(session).Error)
+}
+
+// DeleteUploadSession deletes an upload session
+func DeleteUploadSession(id string) error {
+	return errors.WithStack(db.Where("id = ?", id).Delete(&model.UploadSession{}).Error)
+}
+
+// GetExpiredUploadSessions retrieves all expired upload sessions
+func GetExpiredUploadSessions() ([]*model.UploadSession, error) {
+	var sessions []*model.UploadSession
+	if err := db.Where("expires_at <= ?", time.Now()).Find(&sessions).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return sessions, nil
+}
+
+// DeleteExpiredUploadSession deletes an upload session only if it has expired, reporting whether it was deleted
+func DeleteExpiredUploadSession(id string) (bool, error) {
+	result := db.Where("id = ? AND expires_at <= ?", id, time.Now()).Delete(&model.UploadSession{})
+	return result.RowsAffected > 0, errors.WithStack(result.Error)
+}
+
+// GetUploadSessionIDs retrieves the ids of all upload sessions
+func GetUploadSessionIDs() ([]string, error) {
+	var ids []string
+	if err := db.Model(&model.UploadSession{}).Pluck("id", &ids).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return ids, nil
+}
+
+// DeleteUserUploadSessions deletes all upload sessions of the user
+func DeleteUserUploadSessions(userID uint) ([]*model.UploadSession, error) {
+	var sessions []*model.UploadSession
+	if err := db.Where("user_id = ?", userID).Find(&sessions).Error; err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return sessions, errors.WithStack(db.Where("user_id = ?", userID).Delete(&model.UploadSession{}).Error)
+}
@@ -82,6 +82,17 @@ func UpdateUser(user *model.User) error {
 	return db.Save(user).Error
 }
 
+// UpdateUserFields updates only the given columns of a user
+func UpdateUserFields(user *model.User, fields ...string) error {
+	return errors.WithStack(db.Model(user).Select(fields).Updates(user).Error)
+}
+
+// UseOtpCounter records a used TOTP time step only if it is newer than the last one, reporting whether it was recorded
+func UseOtpCounter(id uint, counter int64) (bool, error) {
+	result := db.Model(&model.User{}).Where("id = ? AND otp_last_counter < ?", id, counter).Update("otp_last_counter", counter)
+	return result.RowsAffected > 0, errors.WithStack(result.Error)
+}
+
 // DeleteUser deletes a user by their ID
 func DeleteUser(id uint) error {
 	return db.Delete(&model.User{}, id).Error
@@ -95,3 +106,29 @@ func GetUserCount() (int64, error) {
 	}
 	return count, nil
 }
+
+// InitGeneralUserPermission 权限位启用前的普通用户均没有任何权限位，
+// 仅在所有普通用户都没有权限时授予 perm，只在首次迁移时调用
+func InitGeneralUserPermission(perm int32) (int64, error) {
+	var count int64
+	if err := db.Model(&model.User{}).Where("role = ? AND permission <> 0", model.GENERAL).Count(&count).Error; err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if count > 0 {
+		return 0, nil
+	}
+	result := db.Model(&model.User{}).Where("role = ? AND permission = 0", model.GENERAL).Update("permission", perm)
+	return result.RowsAffected, errors.WithStack(result.Error)
+}
+
+// GetUserBySsoID retrieves a user by the bound sso account
+func GetUserBySsoID(ssoID string) (*model.User, error) {
+	var user model.User
+	if err := db.Where("sso_id = ?", ssoID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.WithStack(errs.ErrUserNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	return &user, nil
+}
@@ -36,8 +36,20 @@ var (
 	ErrInvalidFileType   = errors.New("invalid file type, only images are accepted")
 	ErrInvalidFileExt    = errors.New("invalid file extension")
 	ErrFileTooLarge      = errors.New("file size exceeds maximum limit")
+	ErrTooManyPixels     = errors.New("image dimensions exceed maximum pixel count")
 	ErrCorruptedFile     = errors.New("corrupted or invalid image file")
 	ErrFileNameCollision = errors.New("file name collision detected")
+	ErrInvalidStripLevel = errors.New("invalid strip_metadata, expected none, gps, sensitive or all")
+)
+
+// Resumable upload errors
+var (
+	ErrUploadNotFound    = errors.New("upload not found or expired")
+	ErrUploadOffset      = errors.New("upload offset does not match the received data")
+	ErrUploadIncomplete  = errors.New("upload is not complete")
+	ErrUploadTooMany     = errors.New("too many unfinished uploads")
+	ErrUploadOverflow    = errors.New("chunk exceeds the declared upload length")
+	ErrURLImportDisabled = errors.New("import by url is disabled")
 )
 
 // Storage errors
@@ -47,6 +59,7 @@ var (
 	ErrFileWrite         = errors.New("failed to write file data")
 	ErrStorageQuota      = errors.New("storage quota exceeded")
 	ErrThumbnailGenerate = errors.New("failed to generate thumbnail")
+	ErrTransformDenied   = errors.New("image transform parameters are not in the allowed presets")
 )
 
 // Permission errors
@@ -54,6 +67,8 @@ var (
 	ErrImageAccess       = errors.New("insufficient permissions to access image")
 	ErrImageNotOwned     = errors.New("user does not own this image")
 	ErrImageModifyDenied = errors.New("not authorized to modify this image")
+	ErrGuestWriteDenied  = errors.New("guest user is not allowed to modify images, login please")
+	ErrPermissionDenied  = errors.New("permission denied")
 )
 
 // Tag related errors
@@ -76,6 +91,7 @@ var (
 var (
 	ErrDuplicateImage = errors.New("image already exists in the system")
 	ErrDuplicateHash  = errors.New("image with identical content already exists")
+	ErrNearDuplicate  = errors.New("a visually similar image already exists")
 )
 
 // User errors
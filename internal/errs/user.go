@@ -10,4 +10,24 @@ var (
 	ErrUserAuth           = errors.New("auth error")
 	ErrUserRegister       = errors.New("register error")
 	ErrUserToken          = errors.New("token error")
+	ErrBuiltinUserDelete  = errors.New("built-in users can't be deleted")
+	ErrInvalidRole        = errors.New("invalid role, built-in roles can't be assigned or changed")
+	ErrUnknownPermission  = errors.New("unknown permission")
+	ErrSessionInvalid     = errors.New("session is invalid or expired, login please")
+	ErrRefreshTokenReused = errors.New("refresh token has been reused, session revoked")
+	ErrAPIKeyInvalid      = errors.New("api key is invalid or expired")
+	ErrAPIKeyScope        = errors.New("api key is not allowed to access this resource")
+	ErrAPIKeyNotFound     = errors.New("api key not found")
+	ErrOtpAlreadyEnabled  = errors.New("two-factor authentication is already enabled")
+	ErrOtpNotEnrolled     = errors.New("two-factor authentication is not enrolled")
+	ErrOtpNotEnabled      = errors.New("two-factor authentication is not enabled")
+	ErrOtpCode            = errors.New("invalid two-factor code")
+	ErrOtpChallenge       = errors.New("two-factor challenge is invalid or expired, login please")
+	ErrLDAPLocalUser      = errors.New("the username belongs to a local user, it can't login through ldap")
+	ErrSSODisabled        = errors.New("sso login is disabled")
+	ErrSSOState           = errors.New("sso state is invalid or expired, login please")
+	ErrSSORedirectURL     = errors.New("sso_redirect_uri or site_url must be configured")
+	ErrSSODomain          = errors.New("email domain is not allowed to login")
+	ErrSSONotBound        = errors.New("no user is bound to this sso account")
+	ErrSSOAlreadyBound    = errors.New("this sso account is already bound to another user")
 )
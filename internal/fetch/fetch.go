@@ -0,0 +1,206 @@
+// Package fetch 下载远程图片，限制大小与超时，并拒绝访问内网地址
+package fetch
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrBlockedAddress = errors.New("access to this address is not allowed")
+	ErrTooLarge       = errors.New("remote file exceeds the size limit")
+	ErrNotImage       = errors.New("remote file is not an image")
+	ErrInvalidURL     = errors.New("only http and https urls are supported")
+)
+
+const maxRedirects = 5
+
+// Config 下载配置
+type Config struct {
+	MaxSize int64
+	Timeout time.Duration
+	// AllowedNetworks 允许访问的内网网段，默认拒绝回环、私有、链路本地等地址
+	AllowedNetworks []netip.Prefix
+}
+
+// Response 下载的响应
+// MaxSize 只用于检查 Content-Length，未声明长度时调用方需自行限制读取的字节数
+type Response struct {
+	Body        io.ReadCloser
+	FileName    string
+	ContentType string // 根据内容判断的类型
+}
+
+// ParseNetworks 解析逗号分隔的网段，单个地址视为 /32 或 /128
+func ParseNetworks(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			addr, err := netip.ParseAddr(part)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid network %s", part)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(part)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid network %s", part)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// 不属于 netip 分类但同样不应访问的网段
+var reservedNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // 运营商级 NAT
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64 可映射到内网 IPv4
+}
+
+// Allowed 判断是否允许连接该地址
+func (c Config) Allowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, prefix := range c.AllowedNetworks {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsMulticast() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() {
+		return false
+	}
+	for _, prefix := range reservedNetworks {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// client 在建立连接时检查实际连接的地址，重定向与 DNS 重绑定同样受限
+func (c Config) client() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: c.Timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil || !c.Allowed(addr) {
+				return errors.WithStack(ErrBlockedAddress)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: c.Timeout,
+		Transport: &http.Transport{
+			Proxy:                 nil, // 不使用环境变量中的代理，否则无法检查目标地址
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   c.Timeout,
+			ResponseHeaderTimeout: c.Timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			return checkURL(req.URL)
+		},
+	}
+}
+
+func checkURL(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.WithStack(ErrInvalidURL)
+	}
+	return nil
+}
+
+// Get 下载 rawURL，根据文件头确认内容为图片后返回，调用方负责关闭 Body
+func Get(ctx context.Context, config Config, rawURL string) (*Response, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.WithStack(ErrInvalidURL)
+	}
+	if err := checkURL(u); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "image/*")
+	resp, err := config.client().Do(req)
+	if err != nil {
+		if errors.Is(err, ErrBlockedAddress) {
+			return nil, errors.WithStack(ErrBlockedAddress)
+		}
+		return nil, errors.Wrap(err, "failed to fetch url")
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, errors.Errorf("failed to fetch url: %s", resp.Status)
+	}
+	if config.MaxSize > 0 && resp.ContentLength > config.MaxSize {
+		resp.Body.Close()
+		return nil, errors.WithStack(ErrTooLarge)
+	}
+
+	br := bufio.NewReader(resp.Body)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		resp.Body.Close()
+		return nil, errors.Wrap(err, "failed to read response")
+	}
+	contentType := http.DetectContentType(head)
+	if !strings.HasPrefix(contentType, "image/") {
+		resp.Body.Close()
+		return nil, errors.WithStack(ErrNotImage)
+	}
+	return &Response{
+		Body:        readCloser{br, resp.Body},
+		FileName:    fileName(resp),
+		ContentType: contentType,
+	}, nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// fileName 优先使用 Content-Disposition 中的文件名，其次为最终地址的路径
+func fileName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := path.Base(strings.ReplaceAll(params["filename"], "\\", "/")); name != "." && name != "/" {
+			return name
+		}
+	}
+	name := path.Base(resp.Request.URL.Path)
+	if name == "." || name == "/" {
+		return "image"
+	}
+	return name
+}
@@ -0,0 +1,128 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestAllowed(t *testing.T) {
+	config := Config{}
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1::1", true},
+		{"127.0.0.1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false}, // 云服务元数据地址
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::1", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+	}
+	for _, tt := range tests {
+		if got := config.Allowed(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("Allowed(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+
+	networks, err := ParseNetworks("10.0.0.0/8, 192.168.1.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.AllowedNetworks = networks
+	for addr, want := range map[string]bool{"10.1.2.3": true, "192.168.1.5": true, "192.168.1.6": false} {
+		if got := config.Allowed(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("Allowed(%s) with allowed networks = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func pngData(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGet(t *testing.T) {
+	data := pngData(t)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/a.png", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/download", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", `attachment; filename="../../b.png"`)
+		_, _ = w.Write(data)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><body>hello</body></html>"))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data/", http.StatusFound)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	loopback, _ := ParseNetworks("127.0.0.0/8")
+	config := Config{Timeout: 5 * time.Second, AllowedNetworks: loopback}
+	ctx := context.Background()
+
+	// 测试服务器位于回环地址，默认配置下应被拒绝
+	if _, err := Get(ctx, Config{Timeout: 5 * time.Second}, server.URL+"/a.png"); !errors.Is(err, ErrBlockedAddress) {
+		t.Errorf("Get loopback error = %v, want ErrBlockedAddress", err)
+	}
+
+	resp, err := Get(ctx, config, server.URL+"/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !bytes.Equal(got, data) || resp.FileName != "a.png" || resp.ContentType != "image/png" {
+		t.Errorf("Get = %q %q %d bytes", resp.FileName, resp.ContentType, len(got))
+	}
+
+	resp, err = Get(ctx, config, server.URL+"/download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.FileName != "b.png" {
+		t.Errorf("FileName = %q, want b.png", resp.FileName)
+	}
+
+	tests := []struct {
+		url     string
+		config  Config
+		wantErr error
+	}{
+		{server.URL + "/page", config, ErrNotImage},
+		{server.URL + "/a.png", Config{Timeout: 5 * time.Second, MaxSize: 10, AllowedNetworks: loopback}, ErrTooLarge},
+		{server.URL + "/redirect", config, ErrBlockedAddress},
+		{"file:///etc/passwd", config, ErrInvalidURL},
+		{"gopher://127.0.0.1/", config, ErrInvalidURL},
+	}
+	for _, tt := range tests {
+		if _, err := Get(ctx, tt.config, tt.url); !errors.Is(err, tt.wantErr) {
+			t.Errorf("Get(%s) error = %v, want %v", tt.url, err, tt.wantErr)
+		}
+	}
+}
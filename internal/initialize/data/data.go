@@ -3,4 +3,5 @@ package data
 func InitData() {
 	initUser()
 	initSettings()
+	initImagePaths()
 }
@@ -0,0 +1,42 @@
+package data
+
+import (
+	"path/filepath"
+	"strings"
+
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/db"
+	"github.com/FXAZfung/image-board/pkg/utils"
+)
+
+// initImagePaths 将旧版本保存的磁盘路径转换为存储 key
+func initImagePaths() {
+	if conf.Conf.DataImage.Dir == "" {
+		return
+	}
+	prefix := filepath.Clean(conf.Conf.DataImage.Dir) + string(filepath.Separator)
+	images, err := db.GetImagesByPathPrefix(prefix)
+	if err != nil {
+		utils.Log.Fatalf("[init image] Failed to get images: %v", err)
+	}
+	toKey := func(p string) string {
+		if !strings.HasPrefix(p, prefix) {
+			return p
+		}
+		return filepath.ToSlash(strings.TrimPrefix(p, prefix))
+	}
+	for _, image := range images {
+		if !strings.HasPrefix(image.Path, prefix) {
+			continue
+		}
+		image.Path = toKey(image.Path)
+		image.ThumbnailPath = toKey(image.ThumbnailPath)
+		image.WebpPath = toKey(image.WebpPath)
+		if err := db.UpdateImagePaths(image); err != nil {
+			utils.Log.Fatalf("[init image] Failed to update image paths: %v", err)
+		}
+	}
+	if len(images) > 0 {
+		utils.Log.Infof("[init image] Converted %d image paths to storage keys", len(images))
+	}
+}
@@ -92,7 +92,18 @@ func InitialSettings() []model.SettingItem {
 	initialSettingItems = []model.SettingItem{
 		// image settings
 		{Key: conf.ImageMaxSize, Value: "20", Type: conf.TypeNumber, Group: model.IMAGE},
+		{Key: conf.ImageMaxPixels, Value: "100", Type: conf.TypeNumber, Group: model.IMAGE, Help: "maximum width x height of an uploaded image in megapixels, checked from the file header before decoding"},
 		{Key: conf.ImageTypes, Value: "jpg,tiff,jpeg,png,gif,bmp,svg,ico,swf,webp", Type: conf.TypeText, Group: model.IMAGE},
+		{Key: conf.ImageTransformPresets, Value: `{
+  "thumb": {"w": 300, "fit": "contain", "q": 85, "fmt": "webp"},
+  "small": {"w": 640, "fit": "contain", "q": 85, "fmt": "webp"},
+  "medium": {"w": 1280, "fit": "contain", "q": 85, "fmt": "webp"},
+  "square": {"w": 300, "h": 300, "fit": "cover", "q": 85, "fmt": "webp"}
+}`, Type: conf.TypeText, Group: model.IMAGE, Help: "allowed parameter presets for /images/image/:name?w=&h=&fit=&q=&fmt="},
+		{Key: conf.NearDuplicatePolicy, Value: "warn", Type: conf.TypeSelect, Options: "off,warn,link,reject", Group: model.IMAGE, Help: "what to do when an upload looks like an existing image: warn lists the similar images in the response, link also records the closest one as duplicate_of, reject refuses the upload"},
+		{Key: conf.NearDuplicateThreshold, Value: "6", Type: conf.TypeNumber, Group: model.IMAGE, Help: "maximum hamming distance (0-64) between perceptual hashes for two images to count as near duplicates"},
+		{Key: conf.MetadataStripPolicy, Value: "gps", Type: conf.TypeSelect, Options: "none,gps,sensitive,all", Group: model.IMAGE, Help: "metadata removed from stored originals: gps removes the location, sensitive also removes serial numbers, camera owner and maker notes, all removes every EXIF/XMP/IPTC field and rotates the pixels upright. Uploads may ask for a stricter level with strip_metadata"},
+		{Key: conf.MetadataRetainPrivate, Value: "true", Type: conf.TypeBool, Group: model.IMAGE, Help: "keep the metadata read before stripping in the database only, the stored file never contains it; the location is only shown to the uploader and users who can view private images"},
 		// site settings
 		{Key: conf.VERSION, Value: "0.0.1", Type: conf.TypeString, Group: model.SITE, Flag: model.READONLY},
 		//{Key: conf.ApiUrl, Value: "", Type: conf.TypeString, Group: model.SITE},
@@ -122,10 +133,43 @@ func InitialSettings() []model.SettingItem {
 		([[:xdigit:]]{1,4}(?::[[:xdigit:]]{1,4}){7}|::|:(?::[[:xdigit:]]{1,4}){1,6}|[[:xdigit:]]{1,4}:(?::[[:xdigit:]]{1,4}){1,5}|(?:[[:xdigit:]]{1,4}:){2}(?::[[:xdigit:]]{1,4}){1,4}|(?:[[:xdigit:]]{1,4}:){3}(?::[[:xdigit:]]{1,4}){1,3}|(?:[[:xdigit:]]{1,4}:){4}(?::[[:xdigit:]]{1,4}){1,2}|(?:[[:xdigit:]]{1,4}:){5}:[[:xdigit:]]{1,4}|(?:[[:xdigit:]]{1,4}:){1,6}:)
 		//(?U)access_token=(.*)&`,
 			Type: conf.TypeText, Group: model.GLOBAL, Flag: model.PRIVATE},
+		{Key: conf.LinkExpiration, Value: "24", Type: conf.TypeNumber, Group: model.GLOBAL, Help: "default expiration of signed links in hours, 0 means never expire"},
+		*permissionMigratedItem("false"),
 		//		{Key: conf.FilenameCharMapping, Value: `{"/": "|"}`, Type: conf.TypeText, Group: model.GLOBAL},
 
+		// s3 settings
+		{Key: conf.S3PresignRedirect, Value: "false", Type: conf.TypeBool, Group: model.S3, Help: "redirect image requests to presigned urls when using s3 storage"},
+		{Key: conf.S3PresignExpire, Value: "60", Type: conf.TypeNumber, Group: model.S3, Help: "presigned url expiration in minutes"},
+
+		// sso settings
+		{Key: conf.SSOLoginEnabled, Value: "false", Type: conf.TypeBool, Group: model.SSO, Flag: model.PUBLIC},
+		{Key: conf.SSOIssuer, Value: "", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE, Help: "OpenID Connect issuer url, used for discovery"},
+		{Key: conf.SSOClientID, Value: "", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
+		{Key: conf.SSOClientSecret, Value: "", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
+		{Key: conf.SSORedirectURI, Value: "", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE, Help: "callback url registered at the provider, defaults to <site_url in the config file>/api/auth/sso/callback"},
+		{Key: conf.SSOScopes, Value: "openid profile email", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE},
+		{Key: conf.SSOAllowedDomains, Value: "", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE, Help: "comma separated email domains allowed to login, empty means any"},
+		{Key: conf.SSOAutoRegister, Value: "true", Type: conf.TypeBool, Group: model.SSO, Flag: model.PRIVATE, Help: "create a user on first login, otherwise only bound users can login"},
+		{Key: conf.SSORoleClaim, Value: "groups", Type: conf.TypeString, Group: model.SSO, Flag: model.PRIVATE, Help: "claim holding the user's roles, nested claims are separated by dots"},
+		{Key: conf.SSORoleMapping, Value: "{}", Type: conf.TypeText, Group: model.SSO, Flag: model.PRIVATE, Help: `role to permissions mapping, e.g. {"editors": ["upload", "manage_tags"]}; when set, permissions of sso users are synced on every login`},
+
+		// ldap settings
+		{Key: conf.LDAPLoginEnabled, Value: "false", Type: conf.TypeBool, Group: model.LDAP, Flag: model.PUBLIC, Help: "authenticate users other than the admin against ldap, users are created on first login"},
+		{Key: conf.LDAPServer, Value: "", Type: conf.TypeString, Group: model.LDAP, Flag: model.PRIVATE, Help: "e.g. ldap://ldap.example.com:389 or ldaps://ldap.example.com:636"},
+		{Key: conf.LDAPStartTLS, Value: "false", Type: conf.TypeBool, Group: model.LDAP, Flag: model.PRIVATE},
+		{Key: conf.LDAPBindDNTemplate, Value: "", Type: conf.TypeString, Group: model.LDAP, Flag: model.PRIVATE, Help: "e.g. uid={username},ou=people,dc=example,dc=com or {username}@example.com"},
+		{Key: conf.LDAPSearchBase, Value: "", Type: conf.TypeString, Group: model.LDAP, Flag: model.PRIVATE, Help: "base dn to search the admin group in"},
+		{Key: conf.LDAPAdminGroupFilter, Value: "", Type: conf.TypeString, Group: model.LDAP, Flag: model.PRIVATE, Help: "e.g. (&(cn=admins)(member={dn})), matched users are granted all permissions"},
+
+		// offline download settings
+		{Key: conf.URLImportEnabled, Value: "true", Type: conf.TypeBool, Group: model.OFFLINE_DOWNLOAD, Flag: model.PUBLIC, Help: "allow users with the upload permission to import images by url"},
+		{Key: conf.URLImportMaxSize, Value: "20", Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE, Help: "maximum size of an imported image in MB"},
+		{Key: conf.URLImportTimeout, Value: "30", Type: conf.TypeNumber, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE, Help: "timeout of the whole download in seconds"},
+		{Key: conf.URLImportAllowedNetworks, Value: "", Type: conf.TypeText, Group: model.OFFLINE_DOWNLOAD, Flag: model.PRIVATE, Help: "comma separated networks that may be fetched even though they are loopback, private or link-local, e.g. 10.0.0.0/8,192.168.1.5"},
+
 		// single settings
 		{Key: conf.Token, Value: token, Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
+		{Key: conf.SignSecret, Value: random.SecretKey(), Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE},
 	}
 	if flags.Dev {
 		initialSettingItems = append(initialSettingItems, []model.SettingItem{
@@ -136,3 +180,9 @@ func InitialSettings() []model.SettingItem {
 	}
 	return initialSettingItems
 }
+
+// permissionMigratedItem 记录普通用户的默认权限是否已经迁移
+func permissionMigratedItem(value string) *model.SettingItem {
+	return &model.SettingItem{Key: conf.PermissionMigrated, Value: value, Type: conf.TypeBool, Group: model.GLOBAL, Flag: model.READONLY,
+		Help: "whether general users created before permission bits existed have been granted the default permission"}
+}
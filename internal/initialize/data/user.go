@@ -2,6 +2,7 @@ package data
 
 import (
 	"github.com/FXAZfung/image-board/cmd/flags"
+	conf "github.com/FXAZfung/image-board/internal/config"
 	"github.com/FXAZfung/image-board/internal/db"
 	"github.com/FXAZfung/image-board/internal/model"
 	"github.com/FXAZfung/image-board/internal/op"
@@ -62,4 +63,26 @@ func initUser() {
 			utils.Log.Fatalf("[init user] Failed to get guest user: %v", err)
 		}
 	}
+	migrateUserPermission()
+}
+
+// migrateUserPermission 权限位启用前创建的普通用户只迁移一次，之后由管理员维护，即使全部被设为无权限也不再授予
+func migrateUserPermission() {
+	marker, err := db.GetSettingItemByKey(conf.PermissionMigrated)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		utils.Log.Fatalf("[init user] Failed to get permission migration marker: %v", err)
+	}
+	if marker != nil && marker.Value == "true" {
+		return
+	}
+	n, err := db.InitGeneralUserPermission(model.DefaultPermission)
+	if err != nil {
+		utils.Log.Fatalf("[init user] Failed to init user permission: %v", err)
+	}
+	if n > 0 {
+		utils.Log.Infof("[init user] Granted default permission to %d users", n)
+	}
+	if err := db.SaveSettingItem(permissionMigratedItem("true")); err != nil {
+		utils.Log.Fatalf("[init user] Failed to save permission migration marker: %v", err)
+	}
 }
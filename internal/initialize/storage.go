@@ -0,0 +1,40 @@
+package initialize
+
+import (
+	"github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/storage"
+	log "github.com/sirupsen/logrus"
+)
+
+func InitStorage() {
+	var s storage.Storage
+	switch config.Conf.Storage.Type {
+	case "", "local":
+		if config.Conf.DataImage.Dir == "" {
+			log.Fatalf("image storage directory not configured")
+		}
+		s = storage.NewLocal(config.Conf.DataImage.Dir)
+	case "s3":
+		s3Conf := config.Conf.Storage.S3
+		if s3Conf.Endpoint == "" || s3Conf.Bucket == "" {
+			log.Fatalf("s3 endpoint and bucket must be configured")
+		}
+		var err error
+		s, err = storage.NewS3(storage.S3Options{
+			Endpoint:  s3Conf.Endpoint,
+			Bucket:    s3Conf.Bucket,
+			Region:    s3Conf.Region,
+			AccessKey: s3Conf.AccessKey,
+			SecretKey: s3Conf.SecretKey,
+			UseSSL:    s3Conf.UseSSL,
+			PathStyle: s3Conf.PathStyle,
+			RootPath:  s3Conf.RootPath,
+		})
+		if err != nil {
+			log.Fatalf("failed to init s3 storage: %+v", err)
+		}
+	default:
+		log.Fatalf("not supported storage type: %s", config.Conf.Storage.Type)
+	}
+	storage.Init(s)
+}
@@ -0,0 +1,94 @@
+// Package ldap 通过绑定 LDAP 目录校验用户名与密码
+package ldap
+
+import (
+	"crypto/tls"
+	"net"
+	"strings"
+	"time"
+
+	ldapv3 "github.com/go-ldap/ldap/v3"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidCredentials 用户名或密码错误
+var ErrInvalidCredentials = errors.New("invalid ldap credentials")
+
+const timeout = 10 * time.Second
+
+// Config LDAP 认证配置
+type Config struct {
+	Server   string // ldap://host:389 或 ldaps://host:636
+	StartTLS bool
+	// BindDNTemplate 用户绑定 DN 模板，{username} 会被替换为转义后的用户名
+	// 例如 uid={username},ou=people,dc=example,dc=com 或 {username}@example.com
+	BindDNTemplate string
+	// SearchBase 与 AdminGroupFilter 均不为空时，以用户身份搜索，有结果即视为管理员
+	// 过滤器中的 {dn} 与 {username} 会被替换为转义后的值
+	SearchBase       string
+	AdminGroupFilter string
+}
+
+// Result 认证结果
+type Result struct {
+	DN    string
+	Admin bool
+}
+
+// Authenticate 以用户身份绑定 LDAP，成功后检查是否属于管理员组
+func Authenticate(config Config, username, password string) (*Result, error) {
+	// 空密码会被服务器当作匿名绑定并返回成功
+	if username == "" || password == "" {
+		return nil, errors.WithStack(ErrInvalidCredentials)
+	}
+	conn, err := ldapv3.DialURL(config.Server,
+		ldapv3.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldapv3.DialWithTLSConfig(&tls.Config{ServerName: hostname(config.Server)}))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect ldap server")
+	}
+	defer conn.Close()
+	conn.SetTimeout(timeout)
+	if config.StartTLS {
+		if err := conn.StartTLS(&tls.Config{ServerName: hostname(config.Server)}); err != nil {
+			return nil, errors.Wrap(err, "failed to start tls")
+		}
+	}
+
+	dn := strings.ReplaceAll(config.BindDNTemplate, "{username}", ldapv3.EscapeDN(username))
+	if err := conn.Bind(dn, password); err != nil {
+		if ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultInvalidCredentials) {
+			return nil, errors.WithStack(ErrInvalidCredentials)
+		}
+		return nil, errors.Wrap(err, "failed to bind ldap")
+	}
+
+	result := &Result{DN: dn}
+	if config.SearchBase == "" || config.AdminGroupFilter == "" {
+		return result, nil
+	}
+	filter := strings.NewReplacer(
+		"{dn}", ldapv3.EscapeFilter(dn),
+		"{username}", ldapv3.EscapeFilter(username),
+	).Replace(config.AdminGroupFilter)
+	resp, err := conn.Search(ldapv3.NewSearchRequest(
+		config.SearchBase, ldapv3.ScopeWholeSubtree, ldapv3.NeverDerefAliases,
+		1, int(timeout.Seconds()), false, filter, []string{"dn"}, nil,
+	))
+	if err != nil && !ldapv3.IsErrorWithCode(err, ldapv3.LDAPResultSizeLimitExceeded) {
+		return nil, errors.Wrap(err, "failed to search admin group")
+	}
+	result.Admin = resp != nil && len(resp.Entries) > 0
+	return result, nil
+}
+
+func hostname(server string) string {
+	host := server
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return strings.TrimSuffix(host, "/")
+}
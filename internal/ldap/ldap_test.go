@@ -0,0 +1,150 @@
+package ldap
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	ldapv3 "github.com/go-ldap/ldap/v3"
+)
+
+// standIn 只实现简单绑定与搜索的 LDAP 服务，搜索时过滤器包含 adminDNs 中的 DN 即返回一条结果
+type standIn struct {
+	listener  net.Listener
+	passwords map[string]string
+	adminDNs  []string
+}
+
+func newStandIn(t *testing.T) *standIn {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &standIn{
+		listener: l,
+		passwords: map[string]string{
+			"uid=alice,ou=people,dc=example,dc=com": "alice-pass",
+			"uid=bob,ou=people,dc=example,dc=com":   "bob-pass",
+		},
+		adminDNs: []string{"uid=alice,ou=people,dc=example,dc=com"},
+	}
+	go s.serve()
+	t.Cleanup(func() { l.Close() })
+	return s
+}
+
+func (s *standIn) config() Config {
+	return Config{
+		Server:           "ldap://" + s.listener.Addr().String(),
+		BindDNTemplate:   "uid={username},ou=people,dc=example,dc=com",
+		SearchBase:       "ou=groups,dc=example,dc=com",
+		AdminGroupFilter: "(&(cn=admins)(member={dn}))",
+	}
+}
+
+func (s *standIn) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *standIn) handle(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		id := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case ldapv3.ApplicationBindRequest:
+			dn := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			code := int64(ldapv3.LDAPResultInvalidCredentials)
+			if expected, ok := s.passwords[dn]; ok && expected == password {
+				code = ldapv3.LDAPResultSuccess
+			}
+			s.write(conn, id, result(ldapv3.ApplicationBindResponse, code))
+		case ldapv3.ApplicationSearchRequest:
+			filter, _ := ldapv3.DecompileFilter(op.Children[6])
+			for _, dn := range s.adminDNs {
+				if strings.Contains(filter, "member="+dn) {
+					entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldapv3.ApplicationSearchResultEntry, nil, "Search Result Entry")
+					entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "cn=admins,ou=groups,dc=example,dc=com", "Object Name"))
+					entry.AppendChild(ber.NewSequence("Attributes"))
+					s.write(conn, id, entry)
+				}
+			}
+			s.write(conn, id, result(ldapv3.ApplicationSearchResultDone, ldapv3.LDAPResultSuccess))
+		default:
+			return
+		}
+	}
+}
+
+func result(tag ber.Tag, code int64) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Diagnostic Message"))
+	return p
+}
+
+func (s *standIn) write(conn net.Conn, id int64, op *ber.Packet) {
+	envelope := ber.NewSequence("LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, "Message ID"))
+	envelope.AppendChild(op)
+	_, _ = conn.Write(envelope.Bytes())
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := newStandIn(t)
+	tests := []struct {
+		username, password string
+		wantErr            bool
+		wantAdmin          bool
+	}{
+		{"alice", "alice-pass", false, true},
+		{"bob", "bob-pass", false, false},
+		{"bob", "wrong", true, false},
+		{"bob", "", true, false},
+		{"nobody", "bob-pass", true, false},
+		// 用户名中的特殊字符被转义，不能拼接出其他 DN
+		{"bob,ou=people,dc=example,dc=com", "bob-pass", true, false},
+	}
+	for _, tt := range tests {
+		res, err := Authenticate(s.config(), tt.username, tt.password)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Authenticate(%q) succeeded, want error", tt.username)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Authenticate(%q) error: %v", tt.username, err)
+			continue
+		}
+		if res.Admin != tt.wantAdmin {
+			t.Errorf("Authenticate(%q).Admin = %v, want %v", tt.username, res.Admin, tt.wantAdmin)
+		}
+	}
+}
+
+func TestAuthenticateWithoutAdminFilter(t *testing.T) {
+	s := newStandIn(t)
+	config := s.config()
+	config.AdminGroupFilter = ""
+	res, err := Authenticate(config, "alice", "alice-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Admin {
+		t.Error("Admin = true without an admin group filter")
+	}
+}
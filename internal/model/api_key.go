@@ -0,0 +1,89 @@
+package model
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// APIKeyPrefix API 密钥前缀，用于与 JWT 及管理员令牌区分
+const APIKeyPrefix = "ibk_"
+
+// API 密钥作用域
+const (
+	ScopeRead   int32 = 1 << iota // 只读，可查看私有图片及签发链接
+	ScopeUpload                   // 上传图片
+	ScopeTags                     // 管理标签
+)
+
+// ScopeNames 作用域名称与作用域位的对应关系
+var ScopeNames = map[string]int32{
+	"read":   ScopeRead,
+	"upload": ScopeUpload,
+	"tags":   ScopeTags,
+}
+
+// scopePermissions 作用域允许使用的用户权限
+var scopePermissions = map[int32]int32{
+	ScopeRead:   PermViewPrivate,
+	ScopeUpload: PermUpload,
+	ScopeTags:   PermManageTags,
+}
+
+// ParseScopes 将作用域名称转换为作用域位
+func ParseScopes(names []string) (int32, error) {
+	var scope int32
+	for _, name := range names {
+		s, ok := ScopeNames[name]
+		if !ok {
+			return 0, errors.Errorf("unknown scope: %s", name)
+		}
+		scope |= s
+	}
+	return scope, nil
+}
+
+// APIKey 用户的 API 密钥，仅保存哈希值
+type APIKey struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"index"`
+	Name      string     `json:"name" gorm:"size:64"`
+	Prefix    string     `json:"prefix" gorm:"size:16"` // 密钥开头几位，便于辨认
+	KeyHash   string     `json:"-" gorm:"uniqueIndex;size:64"`
+	Scope     int32      `json:"-"`
+	ExpiresAt *time.Time `json:"expires_at"` // 为空时永不过期
+	LastUsed  *time.Time `json:"last_used"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func (k *APIKey) HasScope(scope int32) bool {
+	return k.Scope&scope == scope
+}
+
+func (k *APIKey) Expired() bool {
+	return k.ExpiresAt != nil && !k.ExpiresAt.After(time.Now())
+}
+
+// Scopes 密钥拥有的作用域名称
+func (k *APIKey) Scopes() []string {
+	names := make([]string, 0, len(ScopeNames))
+	for name, s := range ScopeNames {
+		if k.HasScope(s) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Permission 密钥作用域允许使用的权限位
+func (k *APIKey) Permission() int32 {
+	var perm int32
+	for s, p := range scopePermissions {
+		if k.HasScope(s) {
+			perm |= p
+		}
+	}
+	return perm
+}
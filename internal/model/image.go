@@ -1,30 +1,44 @@
 package model
 
 import (
+	"strconv"
 	"time"
 )
 
 // Image 图片模型
 type Image struct {
-	ID            uint      `json:"id" gorm:"primaryKey"`
-	FileName      string    `json:"file_name" gorm:"unique;not null"`
-	OriginalName  string    `json:"original_name"`
-	Hash          string    `json:"hash" gorm:"unique;not null"`
-	Path          string    `json:"path"`           // 图片路径
-	ThumbnailPath string    `json:"thumbnail_path"` // 缩略图路径
-	WebpPath      string    `json:"webp_path"`
-	ContentType   string    `json:"content_type"`
-	Size          int64     `json:"size"`
-	Width         int       `json:"width"`
-	Height        int       `json:"height"`
-	Description   string    `json:"description"`
-	IsPublic      bool      `json:"is_public" gorm:"default:true"`
-	ViewCount     int       `json:"view_count" gorm:"default:0"`     // 浏览次数
-	DownloadCount int       `json:"download_count" gorm:"default:0"` // 下载次数
-	UserID        uint      `json:"user_id"`
-	Tags          []Tag     `json:"tags" gorm:"many2many:image_tags;"` // 标签，多对多关系
-	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID             uint           `json:"id" gorm:"primaryKey"`
+	FileName       string         `json:"file_name" gorm:"unique;not null"`
+	OriginalName   string         `json:"original_name"`
+	Hash           string         `json:"hash" gorm:"unique;not null"`
+	Path           string         `json:"path"`           // 图片存储 key
+	ThumbnailPath  string         `json:"thumbnail_path"` // 缩略图存储 key
+	WebpPath       string         `json:"webp_path"`
+	ContentType    string         `json:"content_type"`
+	Size           int64          `json:"size"`
+	Width          int            `json:"width"`
+	Height         int            `json:"height"`
+	Description    string         `json:"description"`
+	IsPublic       bool           `json:"is_public" gorm:"default:true"`
+	ViewCount      int            `json:"view_count" gorm:"default:0"`     // 浏览次数
+	DownloadCount  int            `json:"download_count" gorm:"default:0"` // 下载次数
+	UserID         uint           `json:"user_id"`
+	PHash          string         `json:"phash,omitempty" gorm:"column:phash;size:16;index"` // 感知哈希，十六进制
+	DuplicateOf    uint           `json:"duplicate_of,omitempty" gorm:"index"`               // 上传时关联的相似图片
+	ColorHist      string         `json:"-" gorm:"column:color_hist;size:128"`               // 颜色直方图，十六进制
+	VariantVersion int            `json:"variant_version" gorm:"default:0"`                  // 衍生文件版本，重新生成缩略图与 WebP 后递增
+	Tags           []Tag          `json:"tags" gorm:"many2many:image_tags;"`                 // 标签，多对多关系
+	Metadata       *ImageMetadata `json:"metadata,omitempty" gorm:"foreignKey:ImageID"`      // EXIF/XMP 元数据，只在上传与详情中返回
+	CreatedAt      time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	// NearDuplicates 上传时发现的相似图片，只在上传响应中返回
+	NearDuplicates []uint `json:"near_duplicates,omitempty" gorm:"-"`
+}
+
+// ImagePHash 图片的感知哈希，用于相似图片查找
+type ImagePHash struct {
+	ID    uint
+	PHash uint64
 }
 
 // ImageTag 图片与标签的关联表
@@ -33,3 +47,28 @@ type ImageTag struct {
 	TagID     uint      `gorm:"primaryKey"`
 	CreatedAt time.Time `gorm:"autoCreateTime"`
 }
+
+// VariantTag 衍生文件版本标记，附加在缩略图、WebP 与处理结果的 ETag 中，重新生成后客户端不会继续使用旧的缓存
+func (i *Image) VariantTag() string {
+	if i.VariantVersion == 0 {
+		return ""
+	}
+	return "-v" + strconv.Itoa(i.VariantVersion)
+}
+
+// CanView 判断用户是否可以查看图片，user 为 nil 表示匿名访问
+// 私有图片仅对上传者与拥有 PermViewPrivate 权限的用户可见
+func (i *Image) CanView(user *User) bool {
+	if i.IsPublic {
+		return true
+	}
+	if user == nil {
+		return false
+	}
+	return user.HasPermission(PermViewPrivate) || i.IsOwnedBy(user)
+}
+
+// IsOwnedBy 判断图片是否由 user 上传，游客共享同一账号，不视为任何图片的所有者
+func (i *Image) IsOwnedBy(user *User) bool {
+	return user != nil && !user.IsGuest() && user.ID == i.UserID
+}
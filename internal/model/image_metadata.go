@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+// ImageMetadata 图片的 EXIF/XMP 元数据，没有元数据的图片没有记录
+type ImageMetadata struct {
+	ImageID      uint       `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	CameraMake   string     `json:"camera_make,omitempty" gorm:"size:64;index:idx_metadata_camera"`
+	CameraModel  string     `json:"camera_model,omitempty" gorm:"size:128;index:idx_metadata_camera"`
+	LensModel    string     `json:"lens_model,omitempty" gorm:"size:128"`
+	ExposureTime string     `json:"exposure_time,omitempty" gorm:"size:16"` // 快门时间，如 1/125
+	FNumber      float64    `json:"f_number,omitempty"`
+	ISO          int        `json:"iso,omitempty"`
+	FocalLength  float64    `json:"focal_length,omitempty"` // 毫米
+	DateTaken    *time.Time `json:"date_taken,omitempty" gorm:"index"`
+	Orientation  int        `json:"orientation,omitempty"` // EXIF 方向 1-8
+	Latitude     *float64   `json:"latitude,omitempty"`
+	Longitude    *float64   `json:"longitude,omitempty"`
+	Altitude     *float64   `json:"altitude,omitempty"` // 米
+	Title        string     `json:"title,omitempty" gorm:"size:255"`
+	Description  string     `json:"description,omitempty" gorm:"type:text"`
+	Creator      string     `json:"creator,omitempty" gorm:"size:255"`
+	Keywords     string     `json:"keywords,omitempty" gorm:"type:text"` // 逗号分隔
+	Rating       int        `json:"rating,omitempty"`
+}
+
+// WithoutLocation 返回去掉拍摄位置的副本
+func (m *ImageMetadata) WithoutLocation() *ImageMetadata {
+	c := *m
+	c.Latitude, c.Longitude, c.Altitude = nil, nil, nil
+	return &c
+}
+
+// MetadataFilter 按元数据筛选图片，空字段不参与筛选
+type MetadataFilter struct {
+	CameraMake  string
+	CameraModel string
+	LensModel   string
+	TakenAfter  *time.Time
+	TakenBefore *time.Time
+}
+
+// MonthFacet 拍摄月份及其图片数
+type MonthFacet struct {
+	Month string `json:"month" example:"2024-05"`
+	Count int64  `json:"count" example:"30"`
+}
+
+// CameraFacet 相机型号及其图片数
+type CameraFacet struct {
+	CameraMake  string `json:"camera_make" example:"Canon"`
+	CameraModel string `json:"camera_model" example:"Canon EOS R5"`
+	Count       int64  `json:"count" example:"12"`
+}
@@ -0,0 +1,8 @@
+package model
+
+// RecoveryCode 两步验证恢复码，仅保存哈希值，使用后删除
+type RecoveryCode struct {
+	ID       uint   `gorm:"primaryKey"`
+	UserID   uint   `gorm:"index"`
+	CodeHash string `gorm:"size:64"`
+}
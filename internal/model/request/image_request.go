@@ -2,11 +2,60 @@ package request
 
 import (
 	"mime/multipart"
+	"time"
+
+	"github.com/FXAZfung/image-board/internal/model"
 )
 
 // UploadImageReq 上传图片请求
 type UploadImageReq struct {
-	Image *multipart.FileHeader `json:"-" form:"image" binding:"required"`
+	Image         *multipart.FileHeader `json:"-" form:"image" binding:"required"`
+	StripMetadata string                `form:"strip_metadata"` // 元数据去除程度，只能比站点设置更严格
+}
+
+// BatchUploadReq 批量上传请求，标签与描述应用到本次新建的全部图片
+type BatchUploadReq struct {
+	Tags          []string `form:"tags"` // 可重复传递或使用逗号分隔
+	Description   string   `form:"description"`
+	StripMetadata string   `form:"strip_metadata"`
+}
+
+// CreateUploadReq 创建断点续传上传请求
+type CreateUploadReq struct {
+	FileName      string `json:"filename" binding:"required"`
+	Size          int64  `json:"size" binding:"required"` // 文件总大小（字节）
+	Description   string `json:"description"`
+	StripMetadata string `json:"strip_metadata"`
+}
+
+// ImportImageReq 通过链接导入图片请求
+type ImportImageReq struct {
+	URL           string `json:"url" binding:"required"`
+	Description   string `json:"description"`
+	StripMetadata string `json:"strip_metadata"`
+}
+
+// DuplicateClustersReq 相似图片分组请求
+type DuplicateClustersReq struct {
+	model.PageReq
+	Threshold *int `form:"threshold"` // 汉明距离阈值，为空时使用设置中的值
+}
+
+// SimilarImageReq 以图搜图请求，查询图片通过表单字段 image 上传
+type SimilarImageReq struct {
+	Limit       int  `form:"limit"`        // 返回数量，默认 20，最多 100
+	MaxDistance *int `form:"max_distance"` // 感知哈希的最大汉明距离，默认 16
+	Color       bool `form:"color"`        // 是否结合颜色直方图排序
+}
+
+// MetadataSearchReq 按元数据搜索图片请求，空字段不参与筛选
+type MetadataSearchReq struct {
+	model.PageReq
+	CameraMake  string     `json:"camera_make"`
+	CameraModel string     `json:"camera_model"`
+	LensModel   string     `json:"lens_model"`
+	TakenAfter  *time.Time `json:"taken_after"`  // 拍摄时间不早于，RFC3339 格式
+	TakenBefore *time.Time `json:"taken_before"` // 拍摄时间早于，RFC3339 格式
 }
 
 // UpdateImageReq 更新图片请求
@@ -15,6 +64,29 @@ type UpdateImageReq struct {
 	IsPublic    *bool  `json:"is_public" form:"is_public"`
 }
 
+// ImageTransformReq 图片实时处理参数，只能使用设置中允许的预设
+type ImageTransformReq struct {
+	Preset  string `json:"preset" form:"preset"`
+	Width   int    `json:"w" form:"w"`
+	Height  int    `json:"h" form:"h"`
+	Fit     string `json:"fit" form:"fit"`
+	Quality int    `json:"q" form:"q"`
+	Format  string `json:"fmt" form:"fmt"`
+}
+
+// IsEmpty 未携带任何处理参数
+func (r *ImageTransformReq) IsEmpty() bool {
+	return *r == ImageTransformReq{}
+}
+
+// SignImageReq 生成签名链接请求
+type SignImageReq struct {
+	Name      string `json:"name" binding:"required"` // 图片文件名
+	Thumbnail bool   `json:"thumbnail"`               // 是否为缩略图链接
+	Expire    int64  `json:"expire"`                  // 有效期（秒），0 使用默认设置
+	ImageTransformReq
+}
+
 // ImageSearchReq 图片搜索请求
 type ImageSearchReq struct {
 	Tags      []string `json:"tags"`
@@ -0,0 +1,10 @@
+package request
+
+// UpdateUserReq 修改用户请求，空字段不修改
+type UpdateUserReq struct {
+	Password string   `json:"password" example:"newpassword123"` // 新密码
+	Role     *int     `json:"role" example:"1"`                  // 角色
+	Disable  *bool    `json:"disable" example:"true"`            // 禁用
+	Grant    []string `json:"grant" example:"upload,delete_own"` // 授予的权限
+	Revoke   []string `json:"revoke" example:"delete_any"`       // 撤销的权限
+}
@@ -0,0 +1,15 @@
+package response
+
+import "github.com/FXAZfung/image-board/internal/model"
+
+// APIKeyResponse defines the api key response format
+type APIKeyResponse struct {
+	*model.APIKey
+	Scopes []string `json:"scopes" example:"read,upload"`
+}
+
+// CreateAPIKeyResponse defines the created api key response format
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key" example:"ibk_xxxxxxxx"` // only returned once
+}
@@ -46,3 +46,61 @@ type ImageDeleteResponse struct {
 type ImageCountResponse struct {
 	Count int64 `json:"count" example:"42"`
 }
+
+// SignImageResponse defines the signed link response format
+type SignImageResponse struct {
+	URL       string `json:"url" example:"/images/image/abc123.jpg?sign=xxx:1700000000"`
+	ExpiresAt int64  `json:"expires_at" example:"1700000000"` // 0 means never expire
+}
+
+// 批量上传中单个文件的处理结果
+const (
+	BatchUploadCreated   = "created"
+	BatchUploadDuplicate = "duplicate"
+	BatchUploadRejected  = "rejected"
+)
+
+// BatchUploadResult defines the result of one file in a batch upload
+type BatchUploadResult struct {
+	Name        string       `json:"name" example:"photos.zip/a.jpg"` // 压缩包中的文件为 压缩包名/文件路径
+	Status      string       `json:"status" example:"created" enums:"created,duplicate,rejected"`
+	Image       *model.Image `json:"image,omitempty"`                    // 新建的图片
+	DuplicateOf uint         `json:"duplicate_of,omitempty" example:"1"` // 已存在图片的ID，对上传者不可见时不返回
+	Reason      string       `json:"reason,omitempty" example:"invalid file extension: .txt"`
+}
+
+// BatchUploadResponse defines the batch upload response format
+type BatchUploadResponse struct {
+	Results    []BatchUploadResult `json:"results"`
+	Created    int                 `json:"created" example:"2"`
+	Duplicates int                 `json:"duplicates" example:"1"`
+	Rejected   int                 `json:"rejected" example:"0"`
+}
+
+// DuplicateCluster defines a group of visually similar images
+type DuplicateCluster struct {
+	Images      []*model.Image `json:"images"`
+	MaxDistance int            `json:"max_distance" example:"4"` // 组内感知哈希的最大汉明距离
+}
+
+// SimilarImage defines one result of a reverse image search
+type SimilarImage struct {
+	Image      *model.Image `json:"image"`
+	Distance   int          `json:"distance" example:"3"`      // 感知哈希的汉明距离
+	Similarity float64      `json:"similarity" example:"0.95"` // 0 ~ 1，越大越相似
+}
+
+// MetadataFacets defines the camera and date-taken facets of visible images
+type MetadataFacets struct {
+	Cameras []model.CameraFacet `json:"cameras"`
+	Months  []model.MonthFacet  `json:"months"` // 从新到旧排序
+}
+
+// UploadSessionResponse defines the resumable upload status format
+type UploadSessionResponse struct {
+	ID        string    `json:"id" example:"Xk2p9..."`
+	FileName  string    `json:"file_name" example:"panorama.jpg"`
+	Size      int64     `json:"size" example:"104857600"`
+	Offset    int64     `json:"offset" example:"52428800"` // 已接收的字节数，下一个分片从此处开始
+	ExpiresAt time.Time `json:"expires_at" example:"2020-01-02 01:01:01"`
+}
@@ -0,0 +1,26 @@
+package response
+
+import "github.com/FXAZfung/image-board/internal/model"
+
+// UserInfoResponse defines the current user response format
+type UserInfoResponse struct {
+	*model.User
+	Permissions []string `json:"permissions" example:"upload,delete_own"`
+}
+
+// TwoFactorStatusResponse defines the two-factor status response format
+type TwoFactorStatusResponse struct {
+	Enabled                bool  `json:"enabled" example:"true"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining" example:"10"`
+}
+
+// TwoFactorEnrollResponse defines the two-factor enrollment response format
+type TwoFactorEnrollResponse struct {
+	Secret string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	URI    string `json:"uri" example:"otpauth://totp/image-board:admin?secret=JBSWY3DPEHPK3PXP&issuer=image-board"`
+}
+
+// RecoveryCodesResponse defines the recovery codes response format, codes are only shown once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"abcde-12345"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// Session 登录会话，ID 即 JWT 的 jti
+type Session struct {
+	ID         string    `json:"id" gorm:"primaryKey;size:64"`
+	UserID     uint      `json:"user_id" gorm:"index"`
+	IssuedAt   time.Time `json:"issued_at"`
+	ExpiresAt  time.Time `json:"expires_at" gorm:"index"`
+	LastSeen   time.Time `json:"last_seen"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	RefreshGen int64     `json:"-"`                // 当前有效的刷新令牌代数，每次刷新递增
+	Current    bool      `json:"current" gorm:"-"` // 是否为当前请求使用的会话
+}
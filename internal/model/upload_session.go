@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// UploadSession 断点续传的上传会话，未完成的数据保存在本地临时文件中
+type UploadSession struct {
+	ID            string    `json:"id" gorm:"primaryKey;size:64"`
+	UserID        uint      `json:"user_id" gorm:"index"`
+	FileName      string    `json:"file_name"`
+	Description   string    `json:"description"`
+	StripMetadata string    `json:"strip_metadata"`
+	Size          int64     `json:"size"`   // 文件总大小
+	Offset        int64     `json:"offset"` // 已接收的字节数
+	HashState     []byte    `json:"-"`      // 已接收数据的 sha256 中间状态
+	ExpiresAt     time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Completed 是否已接收全部数据
+func (s *UploadSession) Completed() bool {
+	return s.Offset >= s.Size
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/FXAZfung/image-board/internal/errs"
 	"github.com/FXAZfung/image-board/pkg/utils"
 	"github.com/pkg/errors"
+	"sort"
 )
 
 const (
@@ -12,6 +13,47 @@ const (
 	ADMIN   // only one exists
 )
 
+// 权限位，管理员默认拥有全部权限
+const (
+	PermUpload         int32 = 1 << iota // 上传图片
+	PermDeleteOwn                        // 删除自己的图片
+	PermDeleteAny                        // 删除任意图片
+	PermManageTags                       // 管理标签，可修改任意图片的标签
+	PermManageUsers                      // 管理用户
+	PermManageSettings                   // 管理设置
+	PermViewPrivate                      // 查看所有私有图片
+)
+
+// DefaultPermission 新建普通用户的默认权限
+const DefaultPermission = PermUpload | PermDeleteOwn
+
+// PermAll 全部权限
+const PermAll = PermViewPrivate<<1 - 1
+
+// PermissionNames 权限名称与权限位的对应关系
+var PermissionNames = map[string]int32{
+	"upload":          PermUpload,
+	"delete_own":      PermDeleteOwn,
+	"delete_any":      PermDeleteAny,
+	"manage_tags":     PermManageTags,
+	"manage_users":    PermManageUsers,
+	"manage_settings": PermManageSettings,
+	"view_private":    PermViewPrivate,
+}
+
+// ParsePermissions 将权限名称转换为权限位
+func ParsePermissions(names []string) (int32, error) {
+	var perm int32
+	for _, name := range names {
+		p, ok := PermissionNames[name]
+		if !ok {
+			return 0, errors.Wrapf(errs.ErrUnknownPermission, "%s", name)
+		}
+		perm |= p
+	}
+	return perm, nil
+}
+
 type User struct {
 	ID       uint   `json:"id" gorm:"primaryKey"`                      // unique key
 	Username string `json:"username" gorm:"unique" binding:"required"` // username
@@ -19,7 +61,17 @@ type User struct {
 	Role     int    `json:"role"` // user's role
 	Disabled bool   `json:"disabled"`
 	// Determine permissions by bit
-	Permission int32 `json:"permission"` // password hash
+	Permission int32 `json:"permission"`
+	// 两步验证
+	OtpSecret      string `json:"-"`           // 启用前为待验证的密钥
+	OtpEnabled     bool   `json:"otp_enabled"` // 是否已启用两步验证
+	OtpLastCounter int64  `json:"-"`           // 最近一次使用的时间步，防止验证码重放
+	// 绑定的 SSO 账户（OIDC sub）
+	SsoID string `json:"-" gorm:"size:255;index"`
+	// LDAP 账户（DN），为空表示不是由 LDAP 创建的用户
+	LdapDN string `json:"-" gorm:"size:255"`
+	// 由 LDAP 管理员组授予的权限，移出管理员组时只撤销这些权限
+	LdapPermission int32 `json:"-"`
 }
 
 // ValidatePwdStaticHash 验证密码是否正确
@@ -43,3 +95,47 @@ func (u *User) IsGuest() bool {
 func (u *User) IsAdmin() bool {
 	return u.Role == ADMIN
 }
+
+// HasPermission 判断用户是否拥有 perm 中的全部权限
+func (u *User) HasPermission(perm int32) bool {
+	return u.IsAdmin() || u.Permission&perm == perm
+}
+
+// Restrict 返回权限被限制在 perm 以内的用户副本，管理员降为普通用户
+func (u *User) Restrict(perm int32) *User {
+	restricted := *u
+	if u.IsAdmin() {
+		restricted.Role = GENERAL
+		restricted.Permission = PermAll
+	}
+	restricted.Permission &= perm
+	return &restricted
+}
+
+// Grant 手动授予的权限不再视为 LDAP 授予
+func (u *User) Grant(perm int32) {
+	u.Permission |= perm
+	u.LdapPermission &^= perm
+}
+
+func (u *User) Revoke(perm int32) {
+	u.Permission &^= perm
+	u.LdapPermission &^= perm
+}
+
+// IsLdap 是否为 LDAP 用户
+func (u *User) IsLdap() bool {
+	return u.LdapDN != ""
+}
+
+// Permissions 用户拥有的权限名称
+func (u *User) Permissions() []string {
+	names := make([]string, 0, len(PermissionNames))
+	for name, p := range PermissionNames {
+		if u.HasPermission(p) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
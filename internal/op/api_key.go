@@ -0,0 +1,119 @@
+package op
+
+import (
+	"time"
+
+	"github.com/FXAZfung/go-cache"
+	"github.com/FXAZfung/image-board/internal/db"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/pkg/random"
+	"github.com/FXAZfung/image-board/pkg/singleflight"
+	"github.com/FXAZfung/image-board/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// 以密钥哈希为键缓存，多实例部署时撤销最多延迟一个缓存周期生效
+var apiKeyCache = cache.NewMemCache(cache.WithShards[*model.APIKey](4))
+var apiKeyG singleflight.Group[*model.APIKey]
+
+const (
+	apiKeyCacheDuration = time.Minute
+	apiKeyTouchInterval = time.Minute
+	apiKeyPrefixLen     = len(model.APIKeyPrefix) + 6
+)
+
+func hashAPIKey(key string) string {
+	return utils.HashData(utils.SHA256, []byte(key))
+}
+
+// CreateAPIKey 为用户生成 API 密钥，明文密钥只在此时返回
+func CreateAPIKey(userID uint, name string, scope int32, expiresAt *time.Time) (string, *model.APIKey, error) {
+	secret := model.APIKeyPrefix + random.String(40)
+	key := &model.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    secret[:apiKeyPrefixLen],
+		KeyHash:   hashAPIKey(secret),
+		Scope:     scope,
+		ExpiresAt: expiresAt,
+	}
+	if err := db.CreateAPIKey(key); err != nil {
+		return "", nil, err
+	}
+	return secret, key, nil
+}
+
+// GetAPIKey 根据明文密钥获取未过期的 API 密钥
+func GetAPIKey(secret string) (*model.APIKey, error) {
+	hash := hashAPIKey(secret)
+	key, ok := apiKeyCache.Get(hash)
+	if !ok {
+		var err error
+		key, err, _ = apiKeyG.Do(hash, func() (*model.APIKey, error) {
+			key, err := db.GetAPIKeyByHash(hash)
+			if err != nil {
+				return nil, err
+			}
+			apiKeyCache.Set(hash, key, cache.WithEx[*model.APIKey](apiKeyCacheDuration))
+			return key, nil
+		})
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, errors.WithStack(errs.ErrAPIKeyInvalid)
+			}
+			return nil, err
+		}
+	}
+	if key.Expired() {
+		return nil, errors.WithStack(errs.ErrAPIKeyInvalid)
+	}
+	return key, nil
+}
+
+// TouchAPIKey 更新密钥最后使用时间，间隔较短时不写库
+func TouchAPIKey(key *model.APIKey) {
+	now := time.Now()
+	if key.LastUsed != nil && now.Sub(*key.LastUsed) < apiKeyTouchInterval {
+		return
+	}
+	if err := db.UpdateAPIKeyLastUsed(key.ID, now); err != nil {
+		log.Warnf("failed to update api key last used: %+v", err)
+		return
+	}
+	apiKeyCache.Del(key.KeyHash)
+}
+
+// GetAPIKeysByUser 获取用户的全部 API 密钥
+func GetAPIKeysByUser(userID uint) ([]*model.APIKey, error) {
+	return db.GetAPIKeysByUser(userID)
+}
+
+// GetAPIKeys 获取全部 API 密钥
+func GetAPIKeys() ([]*model.APIKey, error) {
+	return db.GetAPIKeys()
+}
+
+// DeleteAPIKey 删除用户的 API 密钥，userID 为 0 时不限制所属用户
+func DeleteAPIKey(userID, id uint) error {
+	key, err := db.DeleteAPIKey(userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.WithStack(errs.ErrAPIKeyNotFound)
+		}
+		return err
+	}
+	apiKeyCache.Del(key.KeyHash)
+	return nil
+}
+
+// DeleteUserAPIKeys 删除用户的全部 API 密钥
+func DeleteUserAPIKeys(userID uint) error {
+	if err := db.DeleteUserAPIKeys(userID); err != nil {
+		return err
+	}
+	apiKeyCache.Clear()
+	return nil
+}
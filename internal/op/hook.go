@@ -2,6 +2,7 @@ package op
 
 import (
 	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/fetch"
 	"github.com/FXAZfung/image-board/internal/model"
 	"github.com/FXAZfung/image-board/pkg/utils"
 	"github.com/pkg/errors"
@@ -59,6 +60,10 @@ var settingItemHooks = map[string]SettingItemHook{
 		log.Debugf("filename char mapping: %+v", conf.FilenameCharMap)
 		return nil
 	},
+	conf.URLImportAllowedNetworks: func(item *model.SettingItem) error {
+		_, err := fetch.ParseNetworks(item.Value)
+		return err
+	},
 	//conf.IgnoreDirectLinkParams: func(item *model.SettingItem) error {
 	//	conf.SlicesMap[conf.IgnoreDirectLinkParams] = strings.Split(item.Value, ",")
 	//	return nil
@@ -19,6 +19,7 @@ var imageListG singleflight.Group[interface{}]
 func ImageCacheUpdate() {
 	imageCache.Clear()
 	imageListCache.Clear()
+	metadataCache.Clear()
 }
 
 var imageCacheF = func(image *model.Image) {
@@ -79,16 +80,30 @@ func GetImageByHash(hash string) (*model.Image, error) {
 	return image, err
 }
 
-// GetImagesByPage 分页获取图片
-func GetImagesByPage(page, pageSize int) ([]*model.Image, int64, error) {
-	cacheKey := fmt.Sprintf("images_page_%d_%d", page, pageSize)
+// viewerScope 列表缓存按访问者可见范围区分
+func viewerScope(viewer *model.User) string {
+	switch {
+	case viewer == nil:
+		return "public"
+	case viewer.HasPermission(model.PermViewPrivate):
+		return "all"
+	case viewer.IsGuest():
+		return "public"
+	default:
+		return "user_" + strconv.Itoa(int(viewer.ID))
+	}
+}
+
+// GetImagesByPage 分页获取 viewer 可见的图片
+func GetImagesByPage(page, pageSize int, viewer *model.User) ([]*model.Image, int64, error) {
+	cacheKey := fmt.Sprintf("images_page_%s_%d_%d", viewerScope(viewer), page, pageSize)
 	if cached, ok := imageListCache.Get(cacheKey); ok {
 		data := cached.(map[string]interface{})
 		return data["images"].([]*model.Image), data["count"].(int64), nil
 	}
 
 	result, err, _ := imageListG.Do(cacheKey, func() (interface{}, error) {
-		images, count, err := db.GetImagesByPage(page, pageSize)
+		images, count, err := db.GetImagesByPage(page, pageSize, viewer)
 		if err != nil {
 			return nil, err
 		}
@@ -114,16 +129,16 @@ func GetImagesByPage(page, pageSize int) ([]*model.Image, int64, error) {
 	return nil, 0, err
 }
 
-// GetImagesByTag 获取拥有特定标签的所有图片
-func GetImagesByTag(tagName string, page, pageSize int) ([]*model.Image, int64, error) {
-	cacheKey := fmt.Sprintf("images_tag_%s_%d_%d", tagName, page, pageSize)
+// GetImagesByTag 获取拥有特定标签且 viewer 可见的所有图片
+func GetImagesByTag(tagName string, page, pageSize int, viewer *model.User) ([]*model.Image, int64, error) {
+	cacheKey := fmt.Sprintf("images_tag_%s_%s_%d_%d", viewerScope(viewer), tagName, page, pageSize)
 	if cached, ok := imageListCache.Get(cacheKey); ok {
 		data := cached.(map[string]interface{})
 		return data["images"].([]*model.Image), data["count"].(int64), nil
 	}
 
 	result, err, _ := imageListG.Do(cacheKey, func() (interface{}, error) {
-		images, count, err := db.GetImagesByTag(tagName, page, pageSize)
+		images, count, err := db.GetImagesByTag(tagName, page, pageSize, viewer)
 		if err != nil {
 			return nil, err
 		}
@@ -155,6 +170,7 @@ func CreateImage(image *model.Image) error {
 		return err
 	}
 	imageCacheF(image)
+	metadataCache.Del(strconv.Itoa(int(image.ID)))
 	// 清除可能受影响的列表缓存
 	imageListCache.Clear()
 	return nil
@@ -165,8 +181,9 @@ func UpdateImage(image *model.Image) error {
 	if err := db.UpdateImage(image); err != nil {
 		return err
 	}
-	// 更新缓存
+	// 更新缓存，可见性可能变化，列表缓存也需要清除
 	imageCacheF(image)
+	imageListCache.Clear()
 	return nil
 }
 
@@ -183,15 +200,77 @@ func DeleteImage(imageID uint) error {
 	if err := db.DeleteImage(imageID); err != nil {
 		return err
 	}
+	metadataCache.Del(strconv.Itoa(int(imageID)))
 
 	// 清除可能受影响的列表缓存
 	imageListCache.Clear()
 	return nil
 }
 
-// GetRandomImage 获取随机图片
-func GetRandomImage() (*model.Image, error) {
-	image, err := db.GetRandomImage()
+// GetImagePHashes 获取全部图片的感知哈希，用于构建内存索引，不经过缓存
+func GetImagePHashes() ([]model.ImagePHash, error) {
+	return db.GetImagePHashes()
+}
+
+// GetImagesWithoutHashes 获取尚未计算感知哈希或颜色直方图的图片，不经过缓存
+func GetImagesWithoutHashes(afterID uint, limit int) ([]*model.Image, error) {
+	return db.GetImagesWithoutHashes(afterID, limit)
+}
+
+// GetImagesAfter 按 ID 顺序分批获取全部图片，不经过缓存
+func GetImagesAfter(afterID uint, limit int) ([]*model.Image, error) {
+	return db.GetImagesAfter(afterID, limit)
+}
+
+// GetImagesByIDs 按 ID 获取 viewer 可见的图片，不经过缓存
+func GetImagesByIDs(ids []uint, viewer *model.User) ([]*model.Image, error) {
+	return db.GetImagesByIDs(ids, viewer)
+}
+
+// UpdateImageHashes 更新图片的感知哈希与颜色直方图
+func UpdateImageHashes(image *model.Image) error {
+	if err := db.UpdateImageHashes(image); err != nil {
+		return err
+	}
+	invalidateImage(image)
+	return nil
+}
+
+// UpdateImageDerived 更新图片的宽高、感知哈希与颜色直方图，并递增衍生文件版本
+func UpdateImageDerived(image *model.Image) error {
+	if err := db.UpdateImageDerived(image); err != nil {
+		return err
+	}
+	invalidateImage(image)
+	return nil
+}
+
+// invalidateImage 传入的图片可能未加载标签，删除缓存而不是覆盖
+func invalidateImage(image *model.Image) {
+	imageCache.Del(image.FileName)
+	imageCache.Del(image.Hash)
+	imageCache.Del(strconv.Itoa(int(image.ID)))
+	imageListCache.Clear()
+}
+
+// GetImagesByUserID 获取用户上传的全部图片，不经过缓存
+func GetImagesByUserID(userID uint) ([]*model.Image, error) {
+	return db.GetImagesByUserID(userID)
+}
+
+// ReassignImages 将 from 上传的图片转移给 to
+func ReassignImages(from, to uint) (int64, error) {
+	n, err := db.ReassignImages(from, to)
+	if err != nil {
+		return 0, err
+	}
+	ImageCacheUpdate()
+	return n, nil
+}
+
+// GetRandomImage 获取一张 viewer 可见的随机图片
+func GetRandomImage(viewer *model.User) (*model.Image, error) {
+	image, err := db.GetRandomImage(viewer)
 	if err != nil {
 		return nil, err
 	}
@@ -200,15 +279,15 @@ func GetRandomImage() (*model.Image, error) {
 	return image, nil
 }
 
-// GetImageCount 获取总的图片数量
-func GetImageCount() (int64, error) {
-	cacheKey := "image_count"
+// GetImageCount 获取 viewer 可见的图片数量
+func GetImageCount(viewer *model.User) (int64, error) {
+	cacheKey := "image_count_" + viewerScope(viewer)
 	if cached, ok := imageListCache.Get(cacheKey); ok {
 		return cached.(int64), nil
 	}
 
 	result, err, _ := imageListG.Do(cacheKey, func() (interface{}, error) {
-		count, err := db.GetImageCount()
+		count, err := db.GetImageCount(viewer)
 		if err != nil {
 			return int64(0), err
 		}
@@ -0,0 +1,105 @@
+package op
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/FXAZfung/go-cache"
+	"github.com/FXAZfung/image-board/internal/db"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/pkg/singleflight"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+var metadataCache = cache.NewMemCache(cache.WithShards[*model.ImageMetadata](2))
+var metadataG singleflight.Group[*model.ImageMetadata]
+
+// GetImageMetadata 获取图片的元数据，没有元数据时返回 nil
+func GetImageMetadata(imageID uint) (*model.ImageMetadata, error) {
+	key := strconv.Itoa(int(imageID))
+	if metadata, ok := metadataCache.Get(key); ok {
+		return metadata, nil
+	}
+
+	metadata, err, _ := metadataG.Do(key, func() (*model.ImageMetadata, error) {
+		_metadata, err := db.GetImageMetadata(imageID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			_metadata, err = nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		metadataCache.Set(key, _metadata, cache.WithEx[*model.ImageMetadata](time.Minute*10))
+		return _metadata, nil
+	})
+	return metadata, err
+}
+
+// SaveImageMetadata 保存图片的元数据
+func SaveImageMetadata(metadata *model.ImageMetadata) error {
+	if err := db.SaveImageMetadata(metadata); err != nil {
+		return err
+	}
+	metadataCache.Del(strconv.Itoa(int(metadata.ImageID)))
+	imageListCache.Clear()
+	return nil
+}
+
+// SearchImagesByMetadata 按元数据分页筛选 viewer 可见的图片，不经过缓存
+func SearchImagesByMetadata(filter model.MetadataFilter, page, perPage int, viewer *model.User) ([]*model.Image, int64, error) {
+	return db.SearchImagesByMetadata(filter, page, perPage, viewer)
+}
+
+// GetCameraFacets 统计 viewer 可见的图片中各相机型号的图片数
+func GetCameraFacets(viewer *model.User) ([]model.CameraFacet, error) {
+	cacheKey := "camera_facets_" + viewerScope(viewer)
+	if cached, ok := imageListCache.Get(cacheKey); ok {
+		return cached.([]model.CameraFacet), nil
+	}
+
+	result, err, _ := imageListG.Do(cacheKey, func() (interface{}, error) {
+		facets, err := db.GetCameraFacets(viewer)
+		if err != nil {
+			return nil, err
+		}
+		imageListCache.Set(cacheKey, facets, cache.WithEx[interface{}](time.Minute*5))
+		return facets, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]model.CameraFacet), nil
+}
+
+// GetMonthFacets 按拍摄月份统计 viewer 可见的图片数，从新到旧排序
+func GetMonthFacets(viewer *model.User) ([]model.MonthFacet, error) {
+	cacheKey := "month_facets_" + viewerScope(viewer)
+	if cached, ok := imageListCache.Get(cacheKey); ok {
+		return cached.([]model.MonthFacet), nil
+	}
+
+	result, err, _ := imageListG.Do(cacheKey, func() (interface{}, error) {
+		// 不同数据库的日期函数不同，取出拍摄时间后按月份统计
+		dates, err := db.GetDatesTaken(viewer)
+		if err != nil {
+			return nil, err
+		}
+		counts := make(map[string]int64)
+		for _, date := range dates {
+			counts[date.Format("2006-01")]++
+		}
+		facets := make([]model.MonthFacet, 0, len(counts))
+		for month, count := range counts {
+			facets = append(facets, model.MonthFacet{Month: month, Count: count})
+		}
+		sort.Slice(facets, func(i, j int) bool { return facets[i].Month > facets[j].Month })
+		imageListCache.Set(cacheKey, facets, cache.WithEx[interface{}](time.Minute*5))
+		return facets, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.([]model.MonthFacet), nil
+}
@@ -0,0 +1,42 @@
+package op
+
+import (
+	"strings"
+
+	"github.com/FXAZfung/image-board/internal/db"
+	"github.com/FXAZfung/image-board/pkg/utils"
+)
+
+// normalizeRecoveryCode 忽略大小写、空格与连字符
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func hashRecoveryCode(code string) string {
+	return utils.HashData(utils.SHA256, []byte(normalizeRecoveryCode(code)))
+}
+
+// SetRecoveryCodes 替换用户的全部恢复码
+func SetRecoveryCodes(userID uint, codes []string) error {
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return db.ReplaceRecoveryCodes(userID, hashes)
+}
+
+// UseRecoveryCode 使用一个恢复码，成功后该恢复码失效
+func UseRecoveryCode(userID uint, code string) (bool, error) {
+	return db.UseRecoveryCode(userID, hashRecoveryCode(code))
+}
+
+// CountRecoveryCodes 用户剩余的恢复码数量
+func CountRecoveryCodes(userID uint) (int64, error) {
+	return db.CountRecoveryCodes(userID)
+}
+
+// DeleteRecoveryCodes 删除用户的全部恢复码
+func DeleteRecoveryCodes(userID uint) error {
+	return db.DeleteRecoveryCodes(userID)
+}
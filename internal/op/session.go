@@ -0,0 +1,115 @@
+package op
+
+import (
+	"time"
+
+	"github.com/FXAZfung/go-cache"
+	"github.com/FXAZfung/image-board/internal/db"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/pkg/singleflight"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// 会话缓存时间较短，多实例部署时其他实例的撤销操作最多延迟一个缓存周期生效
+var sessionCache = cache.NewMemCache(cache.WithShards[*model.Session](4))
+var sessionG singleflight.Group[*model.Session]
+
+const (
+	sessionCacheDuration = time.Minute
+	sessionTouchInterval = time.Minute
+)
+
+// CreateSession 创建会话，同时清理已过期的会话
+func CreateSession(session *model.Session) error {
+	if err := db.DeleteExpiredSessions(); err != nil {
+		log.Warnf("failed to delete expired sessions: %+v", err)
+	}
+	if err := db.CreateSession(session); err != nil {
+		return err
+	}
+	sessionCache.Set(session.ID, session, cache.WithEx[*model.Session](sessionCacheDuration))
+	return nil
+}
+
+// GetSession 获取未过期的会话
+func GetSession(id string) (*model.Session, error) {
+	if session, ok := sessionCache.Get(id); ok {
+		return session, nil
+	}
+
+	session, err, _ := sessionG.Do(id, func() (*model.Session, error) {
+		session, err := db.GetSession(id)
+		if err != nil {
+			return nil, err
+		}
+		sessionCache.Set(id, session, cache.WithEx[*model.Session](sessionCacheDuration))
+		return session, nil
+	})
+	return session, err
+}
+
+// TouchSession 更新会话最后活跃时间，间隔较短时不写库
+func TouchSession(session *model.Session) {
+	now := time.Now()
+	if now.Sub(session.LastSeen) < sessionTouchInterval {
+		return
+	}
+	if err := db.UpdateSessionLastSeen(session.ID, now); err != nil {
+		log.Warnf("failed to update session last seen: %+v", err)
+		return
+	}
+	sessionCache.Del(session.ID)
+}
+
+// RotateSession 轮换会话的刷新令牌并顺延有效期
+// gen 与当前代数不一致说明旧的刷新令牌被重用，撤销整个会话
+func RotateSession(id string, gen int64, expiresAt time.Time) (*model.Session, error) {
+	session, err := db.GetSession(id)
+	if err != nil {
+		return nil, errors.WithStack(errs.ErrSessionInvalid)
+	}
+	if session.RefreshGen == gen {
+		ok, err := db.RotateSessionRefresh(id, gen, expiresAt, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			sessionCache.Del(id)
+			session.RefreshGen = gen + 1
+			session.ExpiresAt = expiresAt
+			return session, nil
+		}
+	}
+
+	log.Warnf("refresh token reuse detected, revoking session %s of user %d", id, session.UserID)
+	if _, err := RevokeSession(session.UserID, id); err != nil {
+		return nil, err
+	}
+	return nil, errors.WithStack(errs.ErrRefreshTokenReused)
+}
+
+// GetSessionsByUser 获取用户的全部有效会话
+func GetSessionsByUser(userID uint) ([]*model.Session, error) {
+	return db.GetSessionsByUser(userID)
+}
+
+// RevokeSession 撤销用户的一个会话，返回是否存在该会话
+func RevokeSession(userID uint, id string) (bool, error) {
+	n, err := db.DeleteSession(userID, id)
+	if err != nil {
+		return false, err
+	}
+	sessionCache.Del(id)
+	return n > 0, nil
+}
+
+// RevokeUserSessions 撤销用户除 except 外的全部会话
+func RevokeUserSessions(userID uint, except string) error {
+	if err := db.DeleteUserSessions(userID, except); err != nil {
+		return err
+	}
+	sessionCache.Clear()
+	return nil
+}
@@ -0,0 +1,60 @@
+package op
+
+import (
+	"github.com/FXAZfung/image-board/internal/db"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// 上传会话每个分片都会更新，不做缓存
+
+// CreateUploadSession 创建上传会话
+func CreateUploadSession(session *model.UploadSession) error {
+	return db.CreateUploadSession(session)
+}
+
+// GetUploadSession 获取用户未过期的上传会话
+func GetUploadSession(userID uint, id string) (*model.UploadSession, error) {
+	session, err := db.GetUploadSession(userID, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.WithStack(errs.ErrUploadNotFound)
+	}
+	return session, err
+}
+
+// CountUserUploadSessions 统计用户未过期的上传会话数
+func CountUserUploadSessions(userID uint) (int64, error) {
+	return db.CountUserUploadSessions(userID)
+}
+
+// UpdateUploadSessionProgress 保存已接收的字节数与哈希状态
+func UpdateUploadSessionProgress(session *model.UploadSession) error {
+	return db.UpdateUploadSessionProgress(session)
+}
+
+// DeleteUploadSession 删除上传会话
+func DeleteUploadSession(id string) error {
+	return db.DeleteUploadSession(id)
+}
+
+// GetExpiredUploadSessions 获取全部已过期的上传会话
+func GetExpiredUploadSessions() ([]*model.UploadSession, error) {
+	return db.GetExpiredUploadSessions()
+}
+
+// DeleteExpiredUploadSession 会话仍已过期时删除，返回是否删除
+func DeleteExpiredUploadSession(id string) (bool, error) {
+	return db.DeleteExpiredUploadSession(id)
+}
+
+// GetUploadSessionIDs 获取全部上传会话的 ID
+func GetUploadSessionIDs() ([]string, error) {
+	return db.GetUploadSessionIDs()
+}
+
+// DeleteUserUploadSessions 删除用户的全部上传会话，返回被删除的会话
+func DeleteUserUploadSessions(userID uint) ([]*model.UploadSession, error) {
+	return db.DeleteUserUploadSessions(userID)
+}
@@ -23,11 +23,19 @@ func UserCacheUpdate() {
 	userListCache.Clear()
 }
 
+// roleKey 按角色缓存的 key，需与按 ID 缓存的 key 区分
+func roleKey(role int) string {
+	return "role_" + strconv.Itoa(role)
+}
+
 // cacheUser helper to store user in cache
 var cacheUser = func(user *model.User) {
 	userCache.Set(user.Username, user, cache.WithEx[*model.User](time.Minute*10))
 	userCache.Set(strconv.Itoa(int(user.ID)), user, cache.WithEx[*model.User](time.Minute*10))
-	userCache.Set(strconv.Itoa(int(user.Role)), user, cache.WithEx[*model.User](time.Minute*10))
+	// 只有管理员与游客是唯一的
+	if user.IsAdmin() || user.IsGuest() {
+		userCache.Set(roleKey(user.Role), user, cache.WithEx[*model.User](time.Minute*10))
+	}
 }
 
 func GetAdmin() (*model.User, error) {
@@ -87,14 +95,28 @@ func GetUserByName(username string) (*model.User, error) {
 	return user, err
 }
 
+// GetUserBySsoID retrieves a user by the bound sso account
+func GetUserBySsoID(ssoID string) (*model.User, error) {
+	user, err := db.GetUserBySsoID(ssoID)
+	if err != nil {
+		return nil, err
+	}
+	// 优先返回缓存中的实例，避免同一用户存在多个副本
+	if cached, ok := userCache.Get(user.Username); ok {
+		return cached, nil
+	}
+	cacheUser(user)
+	return user, nil
+}
+
 // GetUserByRole retrieves a user by their role with caching
 func GetUserByRole(role int) (*model.User, error) {
-	key := strconv.Itoa(role)
+	key := roleKey(role)
 	if user, ok := userCache.Get(key); ok {
 		return user, nil
 	}
 
-	user, err, _ := userG.Do("role_"+key, func() (*model.User, error) {
+	user, err, _ := userG.Do(key, func() (*model.User, error) {
 		user, err := db.GetUserByRole(role)
 		if err != nil {
 			return nil, err
@@ -164,14 +186,38 @@ func UpdateUser(user *model.User) error {
 	return nil
 }
 
+// UpdateUserFields 只更新指定字段，不会覆盖其他请求同时做出的修改，缓存在更新后失效
+func UpdateUserFields(user *model.User, fields ...string) error {
+	if err := db.UpdateUserFields(user, fields...); err != nil {
+		return err
+	}
+	uncacheUser(user)
+	return nil
+}
+
+// UseOtpCounter 记录已使用的 TOTP 时间步，不晚于上次使用的时间步时返回 false，并发请求中只有一个能成功
+func UseOtpCounter(user *model.User, counter int64) (bool, error) {
+	ok, err := db.UseOtpCounter(user.ID, counter)
+	if err != nil || !ok {
+		return false, err
+	}
+	uncacheUser(user)
+	return true, nil
+}
+
+// uncacheUser 使用户的缓存失效
+func uncacheUser(user *model.User) {
+	userCache.Del(user.Username)
+	userCache.Del(strconv.Itoa(int(user.ID)))
+	userCache.Del(roleKey(user.Role))
+}
+
 // DeleteUser deletes a user and removes from cache
 func DeleteUser(id uint) error {
 	// Get user to invalidate cache
 	user, err := GetUserById(id)
 	if err == nil {
-		userCache.Del(user.Username)
-		userCache.Del(strconv.Itoa(int(user.ID)))
-		userCache.Del(strconv.Itoa(int(user.Role)))
+		uncacheUser(user)
 	}
 
 	if err := db.DeleteUser(id); err != nil {
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/pkg/errors"
+)
+
+// CheckPermission 校验用户是否拥有 perm 权限，游客默认没有任何写权限
+func CheckPermission(user *model.User, perm int32) error {
+	if user != nil && user.HasPermission(perm) {
+		return nil
+	}
+	return deny(user, errs.ErrPermissionDenied)
+}
+
+// CheckImageDelete 校验用户是否可以删除图片
+func CheckImageDelete(user *model.User, image *model.Image) error {
+	if user != nil && user.HasPermission(model.PermDeleteAny) {
+		return nil
+	}
+	if image.IsOwnedBy(user) && user.HasPermission(model.PermDeleteOwn) {
+		return nil
+	}
+	return deny(user, errs.ErrImageModifyDenied)
+}
+
+// CheckImageTagWrite 校验用户是否可以修改图片标签，上传者可修改自己图片的标签
+func CheckImageTagWrite(user *model.User, image *model.Image) error {
+	if image.IsOwnedBy(user) || (user != nil && user.HasPermission(model.PermManageTags)) {
+		return nil
+	}
+	return deny(user, errs.ErrImageModifyDenied)
+}
+
+// deny 游客返回统一的提示，引导登录
+func deny(user *model.User, err error) error {
+	if user == nil || user.IsGuest() {
+		return errors.WithStack(errs.ErrGuestWriteDenied)
+	}
+	return errors.WithStack(err)
+}
+
+// getModifiableImage 获取图片并使用 check 校验修改权限
+// 对无权查看的图片返回 errs.ImageNotFound，避免泄露私有图片是否存在
+func getModifiableImage(imageID uint, user *model.User, check func(*model.User, *model.Image) error) (*model.Image, error) {
+	image, err := op.GetImageByID(imageID)
+	if err != nil || !image.CanView(user) {
+		return nil, errors.WithStack(errs.ImageNotFound)
+	}
+	if err := check(user, image); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"archive/zip"
+	"io"
+	"mime/multipart"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/request"
+	"github.com/FXAZfung/image-board/internal/model/response"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	maxBatchFiles = 100 // 单次批量上传的文件数上限，压缩包按其中的文件计算
+	maxBatchTags  = 20
+)
+
+// batchItem 展开后的待处理文件，reason 不为空时直接记为拒绝
+type batchItem struct {
+	source uploadSource
+	reason string
+}
+
+// BatchUploadImages 批量上传图片，压缩包会被展开，按文件返回处理结果
+func BatchUploadImages(files []*multipart.FileHeader, req request.BatchUploadReq, user *model.User) (*response.BatchUploadResponse, error) {
+	if err := CheckPermission(user, model.PermUpload); err != nil {
+		return nil, err
+	}
+	if len(req.Tags) > maxBatchTags {
+		return nil, errors.WithStack(errs.ErrTooManyTags)
+	}
+	if err := validateStripLevel(req.StripMetadata); err != nil {
+		return nil, err
+	}
+
+	var items []batchItem
+	for _, file := range files {
+		if strings.ToLower(path.Ext(file.Filename)) != ".zip" {
+			items = append(items, batchItem{source: formSource(file)})
+			continue
+		}
+		entries, closer, err := expandZip(file)
+		if err != nil {
+			items = append(items, batchItem{source: uploadSource{name: file.Filename}, reason: err.Error()})
+			continue
+		}
+		defer closer.Close()
+		items = append(items, entries...)
+	}
+	if len(items) > maxBatchFiles {
+		return nil, errors.Wrapf(errs.ErrImageBatchLimit, "at most %d files", maxBatchFiles)
+	}
+
+	startTime := time.Now()
+	resp := &response.BatchUploadResponse{Results: make([]response.BatchUploadResult, len(items))}
+	service := NewImageService()
+	// 同时处理的文件数与图片处理并发数一致，解码与转码仍由 processingSem 限制
+	workers := make(chan struct{}, cap(processingSem))
+	var wg sync.WaitGroup
+	for i, item := range items {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(i int, item batchItem) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			resp.Results[i] = uploadBatchItem(service, item, req, user)
+		}(i, item)
+	}
+	wg.Wait()
+
+	for _, result := range resp.Results {
+		switch result.Status {
+		case response.BatchUploadCreated:
+			resp.Created++
+		case response.BatchUploadDuplicate:
+			resp.Duplicates++
+		default:
+			resp.Rejected++
+		}
+	}
+	log.WithFields(log.Fields{
+		"user_id":    user.ID,
+		"operation":  "batch_upload",
+		"files":      len(items),
+		"created":    resp.Created,
+		"duplicates": resp.Duplicates,
+		"rejected":   resp.Rejected,
+		"duration":   time.Since(startTime),
+	}).Info("Batch upload completed")
+	return resp, nil
+}
+
+func uploadBatchItem(service *ImageService, item batchItem, req request.BatchUploadReq, user *model.User) response.BatchUploadResult {
+	result := response.BatchUploadResult{Name: item.source.name}
+	if item.reason != "" {
+		result.Status = response.BatchUploadRejected
+		result.Reason = item.reason
+		return result
+	}
+
+	logFields := log.Fields{
+		"user_id":   user.ID,
+		"file_name": item.source.name,
+		"operation": "batch_upload",
+	}
+	image, err := service.processUpload(item.source, user, uploadOptions{description: req.Description, stripMetadata: req.StripMetadata}, logFields)
+	switch {
+	case errors.Is(err, errs.ErrDuplicateImage), errors.Is(err, errs.ErrNearDuplicate):
+		// 已有图片对上传者不可见时 image 为空，不返回其 ID
+		result.Status = response.BatchUploadDuplicate
+		if image != nil {
+			result.DuplicateOf = image.ID
+		}
+		if errors.Is(err, errs.ErrNearDuplicate) {
+			result.Reason = uploadFailureReason(err)
+		}
+		return result
+	case err != nil:
+		result.Status = response.BatchUploadRejected
+		result.Reason = uploadFailureReason(err)
+		return result
+	}
+
+	for _, tag := range req.Tags {
+		t, err := op.AddTagToImage(image.ID, tag)
+		if err != nil {
+			log.WithFields(logFields).Warnf("failed to add tag %s: %v", tag, err)
+			continue
+		}
+		image.Tags = append(image.Tags, *t)
+	}
+	result.Status = response.BatchUploadCreated
+	result.Image = image
+	return result
+}
+
+// uploadFailureReason 去掉 processUpload 添加的前缀，只保留失败的步骤原因
+func uploadFailureReason(err error) string {
+	if cause := errors.Unwrap(err); cause != nil {
+		return cause.Error()
+	}
+	return err.Error()
+}
+
+// expandZip 展开压缩包中的文件，跳过目录与隐藏文件，返回的 closer 需在处理完成后关闭
+func expandZip(file *multipart.FileHeader) ([]batchItem, io.Closer, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "file open failed")
+	}
+	zr, err := zip.NewReader(f, file.Size)
+	if err != nil {
+		f.Close()
+		return nil, nil, errors.New("invalid zip archive")
+	}
+
+	limit := int64(setting.GetInt(conf.ImageMaxSize, 20)) << 20
+	var items []batchItem
+	for _, entry := range zr.File {
+		if entry.FileInfo().IsDir() || isHiddenEntry(entry.Name) {
+			continue
+		}
+		if len(items) >= maxBatchFiles {
+			// 超出上限即可判断整批失败，不再继续展开
+			items = append(items, batchItem{})
+			break
+		}
+		item := batchItem{source: uploadSource{name: file.Filename + "/" + entry.Name, limit: limit, open: entry.Open}}
+		if int64(entry.UncompressedSize64) > limit {
+			item.reason = errs.ErrFileTooLarge.Error()
+		}
+		items = append(items, item)
+	}
+	return items, f, nil
+}
+
+// isHiddenEntry macOS 压缩时附带的 __MACOSX 目录与以点开头的文件
+func isHiddenEntry(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
@@ -17,16 +17,21 @@ import (
 	"mime/multipart"
 	"net/http"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/FXAZfung/image-board/internal/config"
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
 	"github.com/FXAZfung/image-board/internal/model"
 	"github.com/FXAZfung/image-board/internal/model/request"
 	"github.com/FXAZfung/image-board/internal/model/response"
 	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/setting"
+	"github.com/FXAZfung/image-board/internal/storage"
+	"github.com/FXAZfung/image-board/pkg/imghash"
+	"github.com/FXAZfung/image-board/pkg/imgmeta"
 	"github.com/FXAZfung/image-board/pkg/utils"
 	"github.com/disintegration/imaging"
 	"golang.org/x/sync/errgroup"
@@ -41,19 +46,15 @@ var (
 )
 
 type ImageService struct {
-	baseDir        string
+	storage        storage.Storage
 	thumbnailWidth int
 	quality        int
 	allowedExts    []string
 }
 
 func NewImageService() *ImageService {
-	if config.Conf.DataImage.Dir == "" {
-		log.Fatal("Image storage directory not configured")
-	}
-
 	return &ImageService{
-		baseDir:        config.Conf.DataImage.Dir,
+		storage:        storage.GetStorage(),
 		thumbnailWidth: 300,
 		quality:        90,
 		allowedExts:    []string{".jpg", ".jpeg", ".png", ".gif", ".webp"},
@@ -61,7 +62,14 @@ func NewImageService() *ImageService {
 }
 
 // UploadImage 入口函数
-func UploadImage(file *multipart.FileHeader, user *model.User) (*model.Image, error) {
+func UploadImage(file *multipart.FileHeader, req request.UploadImageReq, user *model.User) (*model.Image, error) {
+	if err := CheckPermission(user, model.PermUpload); err != nil {
+		return nil, err
+	}
+	if err := validateStripLevel(req.StripMetadata); err != nil {
+		return nil, err
+	}
+
 	startTime := time.Now()
 	logFields := log.Fields{
 		"user_id":   user.ID,
@@ -77,7 +85,7 @@ func UploadImage(file *multipart.FileHeader, user *model.User) (*model.Image, er
 	}()
 
 	service := NewImageService()
-	image, err := service.processUpload(file, user, logFields)
+	image, err := service.processUpload(formSource(file), user, uploadOptions{stripMetadata: req.StripMetadata}, logFields)
 	if err != nil {
 		log.WithFields(logFields).Errorf("Upload failed: %v", err)
 		return nil, err
@@ -86,33 +94,90 @@ func UploadImage(file *multipart.FileHeader, user *model.User) (*model.Image, er
 	return image, nil
 }
 
+// uploadSource 待上传的文件，来自表单或压缩包
+type uploadSource struct {
+	name   string
+	header *multipart.FileHeader // 来自压缩包时为空
+	limit  int64                 // 读取的最大字节数，0 为不限制
+	hash   string                // 已计算的内容哈希，为空时读取时计算
+	path   string                // 已在本地磁盘上的文件，设置后直接读取，不再复制到临时文件
+	open   func() (io.ReadCloser, error)
+}
+
+func formSource(file *multipart.FileHeader) uploadSource {
+	return uploadSource{
+		name:   file.Filename,
+		header: file,
+		open: func() (io.ReadCloser, error) {
+			return file.Open()
+		},
+	}
+}
+
+// uploadOptions 随上传提交的参数
+type uploadOptions struct {
+	description   string
+	stripMetadata string // 元数据去除程度，为空时使用站点设置
+}
+
 type uploadContext struct {
-	file          *multipart.FileHeader
+	source        uploadSource
 	user          *model.User
-	fileData      []byte
+	options       uploadOptions
+	localPath     string // 完整内容所在的本地文件
+	tempFile      bool   // localPath 是否为需要删除的临时文件
+	size          int64
+	contentType   string
+	width         int
+	height        int
 	hash          string
+	thumbnail     *bytes.Buffer // 解码时一并编码的缩略图与 WebP，解码后的图片不在步骤间保留
+	webp          *bytes.Buffer
+	upright       *bytes.Buffer // 需要以正向重新编码原图时的编码结果
+	phash         uint64
+	colorHist     imghash.Histogram
+	metadata      *model.ImageMetadata
+	duplicateOf   uint
+	nearIDs       []uint // 相似图片，按距离排序
+	unlock        func()
 	fileExt       string
-	filePath      string
+	storage       storage.Storage
+	filePath      string // 以下均为存储 key
 	thumbnailPath string
 	webpPath      string
 	modImage      *model.Image
 	logFields     log.Fields
 }
 
-func (s *ImageService) processUpload(file *multipart.FileHeader, user *model.User, logFields log.Fields) (*model.Image, error) {
+// processUpload 处理单个文件，重复图片返回 ErrDuplicateImage，已存在的图片对上传者可见时一并返回
+func (s *ImageService) processUpload(source uploadSource, user *model.User, options uploadOptions, logFields log.Fields) (*model.Image, error) {
 	ctx := &uploadContext{
-		file:      file,
+		source:    source,
 		user:      user,
+		options:   options,
+		storage:   s.storage,
 		logFields: logFields,
 	}
+	defer func() {
+		if ctx.unlock != nil {
+			ctx.unlock()
+		}
+		if ctx.tempFile {
+			_ = os.Remove(ctx.localPath)
+		}
+	}()
 
 	steps := []func() error{
 		ctx.validateInput,
-		ctx.readAndHashFile,
-		ctx.checkDuplicate,
+		ctx.spoolFile,
 		ctx.validateExtension,
+		ctx.readImageConfig,
+		ctx.readMetadata,
+		ctx.decodeAndEncode,
+		ctx.stripMetadata,
+		ctx.checkDuplicate, // 按去除元数据后的内容去重
+		ctx.checkNearDuplicate,
 		ctx.generateFilePaths,
-		ctx.createStorageDirs,
 		ctx.processImageData,
 		ctx.createImageModel,
 		ctx.saveToDatabase,
@@ -120,7 +185,7 @@ func (s *ImageService) processUpload(file *multipart.FileHeader, user *model.Use
 
 	for _, step := range steps {
 		if err := step(); err != nil {
-			return nil, s.wrapError("upload processing failed", err)
+			return ctx.modImage, s.wrapError("upload processing failed", err)
 		}
 	}
 
@@ -128,50 +193,136 @@ func (s *ImageService) processUpload(file *multipart.FileHeader, user *model.Use
 }
 
 func (ctx *uploadContext) validateInput() error {
-	if ctx.file == nil {
+	if ctx.source.open == nil && ctx.source.path == "" {
 		return errors.New("no file provided")
 	}
-	if !utils.IsImage(ctx.file) {
+	if ctx.source.header != nil && !utils.IsImage(ctx.source.header) {
 		return errors.New("invalid file type")
 	}
 	return nil
 }
 
-func (ctx *uploadContext) readAndHashFile() error {
-	hash := sha256.New()
-	f, err := ctx.file.Open()
+// spoolFile 将上传内容写入临时文件并计算哈希，不在内存中保留整个文件
+func (ctx *uploadContext) spoolFile() error {
+	if ctx.source.path != "" {
+		info, err := os.Stat(ctx.source.path)
+		if err != nil {
+			return fmt.Errorf("file stat failed: %w", err)
+		}
+		ctx.localPath, ctx.size, ctx.hash = ctx.source.path, info.Size(), ctx.source.hash
+		return ctx.sniffContentType()
+	}
+
+	f, err := ctx.source.open()
 	if err != nil {
 		return fmt.Errorf("file open failed: %w", err)
 	}
 	defer f.Close()
+	tmp, err := os.CreateTemp("", "image-board-upload-*")
+	if err != nil {
+		return fmt.Errorf("temp file create failed: %w", err)
+	}
+	defer tmp.Close()
+	ctx.localPath, ctx.tempFile = tmp.Name(), true
 
-	// 流式读取同时计算哈希
-	tee := io.TeeReader(f, hash)
-	data, err := io.ReadAll(tee)
+	var r io.Reader = f
+	if ctx.source.limit > 0 {
+		r = io.LimitReader(f, ctx.source.limit+1)
+	}
+	// 流式写入同时计算哈希
+	hash := sha256.New()
+	w := io.Writer(tmp)
+	if ctx.source.hash == "" {
+		w = io.MultiWriter(tmp, hash)
+	}
+	n, err := io.Copy(w, r)
 	if err != nil {
 		return fmt.Errorf("file read failed: %w", err)
 	}
+	if ctx.source.limit > 0 && n > ctx.source.limit {
+		return errors.WithStack(errs.ErrFileTooLarge)
+	}
+
+	ctx.size = n
+	ctx.hash = ctx.source.hash
+	if ctx.hash == "" {
+		ctx.hash = hex.EncodeToString(hash.Sum(nil))
+	}
+	return ctx.sniffContentType()
+}
 
-	ctx.fileData = data
-	ctx.hash = hex.EncodeToString(hash.Sum(nil))
+// sniffContentType 根据文件头判断类型，压缩包等来源没有 Content-Type，要求内容为图片
+func (ctx *uploadContext) sniffContentType() error {
+	f, err := os.Open(ctx.localPath)
+	if err != nil {
+		return fmt.Errorf("file open failed: %w", err)
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return fmt.Errorf("file read failed: %w", err)
+	}
+	ctx.contentType = http.DetectContentType(head[:n])
+	if ctx.source.header == nil && !strings.HasPrefix(ctx.contentType, "image/") {
+		return errors.WithStack(errs.ErrInvalidFileType)
+	}
 	return nil
 }
 
 func (ctx *uploadContext) checkDuplicate() error {
+	// 同一内容的上传串行执行，避免并发写入相同的存储 key
+	ctx.unlock = lockKey("hash:" + ctx.hash)
 	if existing, err := op.GetImageByHash(ctx.hash); err == nil {
 		log.WithFields(ctx.logFields).Info("Duplicate image found")
-		ctx.modImage = existing
-		return errors.New("duplicate image") // 特殊错误类型触发提前返回
+		// 已有图片对上传者不可见时不返回，避免泄露私有图片
+		if existing.CanView(ctx.user) {
+			ctx.modImage = existing
+		}
+		return errors.WithStack(errs.ErrDuplicateImage) // 特殊错误类型触发提前返回
 	}
 	return nil
 }
 
+// keyLock 按 key 加锁的互斥锁，引用计数归零后从表中移除
+type keyLock struct {
+	sync.Mutex
+	refs int
+}
+
+var (
+	keyLocksMu sync.Mutex
+	keyLocks   = map[string]*keyLock{}
+)
+
+// lockKey 锁定 key 并返回解锁函数
+func lockKey(key string) func() {
+	keyLocksMu.Lock()
+	l, ok := keyLocks[key]
+	if !ok {
+		l = &keyLock{}
+		keyLocks[key] = l
+	}
+	l.refs++
+	keyLocksMu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		keyLocksMu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(keyLocks, key)
+		}
+		keyLocksMu.Unlock()
+	}
+}
+
 func (s *ImageService) wrapError(msg string, err error) error {
 	return fmt.Errorf("%s: %w", msg, errors.Cause(err))
 }
 
 func (ctx *uploadContext) validateExtension() error {
-	ctx.fileExt = strings.ToLower(filepath.Ext(ctx.file.Filename))
+	ctx.fileExt = strings.ToLower(path.Ext(ctx.source.name))
 	for _, ext := range NewImageService().allowedExts {
 		if ctx.fileExt == ext {
 			return nil
@@ -180,29 +331,49 @@ func (ctx *uploadContext) validateExtension() error {
 	return fmt.Errorf("invalid file extension: %s", ctx.fileExt)
 }
 
-func (ctx *uploadContext) generateFilePaths() error {
-	now := time.Now()
-	datePath := fmt.Sprintf("%d/%02d", now.Year(), now.Month())
-	baseDir := filepath.Join(NewImageService().baseDir, datePath)
-
-	ctx.filePath = filepath.Join(baseDir, ctx.hash+ctx.fileExt)
-	ctx.thumbnailPath = filepath.Join(baseDir, "thumbnails", ctx.hash+ctx.fileExt)
-	ctx.webpPath = GetWebPPath(filepath.Join(baseDir, "webp", ctx.hash+ctx.fileExt))
+// readImageConfig 只解析文件头获取尺寸，无法识别或像素数过多的文件在解码前被拒绝
+func (ctx *uploadContext) readImageConfig() error {
+	f, err := os.Open(ctx.localPath)
+	if err != nil {
+		return fmt.Errorf("file open failed: %w", err)
+	}
+	defer f.Close()
+	config, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return fmt.Errorf("image decode failed: %w", err)
+	}
+	if err := checkPixels(config); err != nil {
+		return err
+	}
+	ctx.width, ctx.height = config.Width, config.Height
 	return nil
 }
 
-func (ctx *uploadContext) createStorageDirs() error {
-	dirs := []string{
-		filepath.Dir(ctx.filePath),
-		filepath.Dir(ctx.thumbnailPath),
-		filepath.Dir(ctx.webpPath),
+// checkPixels 体积很小的文件也可能解码出巨大的位图，按像素数限制
+func checkPixels(config image.Config) error {
+	limit := int64(setting.GetInt(conf.ImageMaxPixels, 100)) * 1000000
+	if int64(config.Width)*int64(config.Height) > limit {
+		return errors.Wrapf(errs.ErrTooManyPixels, "%dx%d", config.Width, config.Height)
 	}
+	return nil
+}
 
-	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("directory creation failed: %w", err)
-		}
+// checkImagePixels 读取文件头检查像素数，调用方需自行重置读取位置
+func checkImagePixels(r io.Reader) error {
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return errors.WithStack(errs.ErrCorruptedFile)
 	}
+	return checkPixels(config)
+}
+
+func (ctx *uploadContext) generateFilePaths() error {
+	now := time.Now()
+	datePath := fmt.Sprintf("%d/%02d", now.Year(), now.Month())
+
+	ctx.filePath = path.Join(datePath, ctx.hash+ctx.fileExt)
+	ctx.thumbnailPath = GetThumbnailPath(ctx.filePath)
+	ctx.webpPath = GetWebPPath(path.Join(datePath, "webp", ctx.hash+ctx.fileExt))
 	return nil
 }
 
@@ -211,21 +382,26 @@ func (ctx *uploadContext) processImageData() error {
 
 	// 保存原始文件
 	g.Go(func() error {
-		return safeWriteFile(ctx.filePath, ctx.fileData)
+		f, err := os.Open(ctx.localPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return ctx.storage.Put(context.Background(), ctx.filePath, f, ctx.size, ctx.contentType)
 	})
 
-	// 生成缩略图
+	// 缩略图与 WebP 已在 decodeAndEncode 中编码
 	g.Go(func() error {
-		processingSem <- struct{}{}
-		defer func() { <-processingSem }()
-		return NewImageService().createThumbnail(ctx.fileData, ctx.thumbnailPath)
+		if err := ctx.storage.Put(context.Background(), ctx.thumbnailPath, ctx.thumbnail, int64(ctx.thumbnail.Len()), ""); err != nil {
+			return fmt.Errorf("thumbnail save failed: %w", err)
+		}
+		return nil
 	})
-
-	// 生成WebP
 	g.Go(func() error {
-		processingSem <- struct{}{}
-		defer func() { <-processingSem }()
-		return NewImageService().convertToWebP(ctx.fileData, ctx.webpPath)
+		if err := ctx.storage.Put(context.Background(), ctx.webpPath, ctx.webp, int64(ctx.webp.Len()), "image/webp"); err != nil {
+			return fmt.Errorf("webp save failed: %w", err)
+		}
+		return nil
 	})
 
 	if err := g.Wait(); err != nil {
@@ -235,33 +411,76 @@ func (ctx *uploadContext) processImageData() error {
 	return nil
 }
 
-func safeWriteFile(path string, data []byte) error {
-	tmpPath := path + ".tmp"
-	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+// decodeAndEncode 解码图片并按 EXIF 方向转为正向后计算感知哈希，同时编码缩略图与 WebP
+// 图片只解码这一次，解码后的位图只在占用 processingSem 期间存在，processingSem 因此能限制内存中的位图数量
+// 缩略图、WebP 与保存的宽高均以正向图片为准
+func (ctx *uploadContext) decodeAndEncode() error {
+	processingSem <- struct{}{}
+	defer func() { <-processingSem }()
+	src, err := ctx.decodeImage()
+	if err != nil {
+		return err
+	}
+	if ctx.metadata != nil {
+		src = imgmeta.Orient(src, ctx.metadata.Orientation)
+	}
+	ctx.width, ctx.height = src.Bounds().Dx(), src.Bounds().Dy()
+	ctx.phash = imghash.DHash(src)
+	ctx.colorHist = imghash.ColorHistogram(src)
+
+	s := NewImageService()
+	format, err := imaging.FormatFromExtension(ctx.fileExt)
+	if err != nil {
+		return fmt.Errorf("thumbnail format failed: %w", err)
+	}
+	if ctx.thumbnail, err = s.encodeThumbnail(src, format); err != nil {
 		return err
 	}
-	return os.Rename(tmpPath, path)
+	if ctx.webp, err = s.encodeWebP(src); err != nil {
+		return err
+	}
+	if ctx.needsUpright() {
+		if ctx.upright, err = ctx.encodeUpright(src); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (ctx *uploadContext) createImageModel() error {
-	img, _, err := image.Decode(bytes.NewReader(ctx.fileData))
+func (ctx *uploadContext) decodeImage() (image.Image, error) {
+	f, err := os.Open(ctx.localPath)
 	if err != nil {
-		return fmt.Errorf("image decode failed: %w", err)
+		return nil, err
 	}
+	defer f.Close()
+	src, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("image decode failed: %w", err)
+	}
+	return src, nil
+}
 
+func (ctx *uploadContext) createImageModel() error {
 	ctx.modImage = &model.Image{
 		FileName:      ctx.hash + ctx.fileExt,
-		OriginalName:  ctx.file.Filename,
+		OriginalName:  path.Base(ctx.source.name),
 		Hash:          ctx.hash,
-		ContentType:   http.DetectContentType(ctx.fileData),
-		Size:          ctx.file.Size,
+		ContentType:   ctx.contentType,
+		Size:          ctx.size,
 		Path:          ctx.filePath,
 		ThumbnailPath: ctx.thumbnailPath,
 		WebpPath:      ctx.webpPath,
-		Width:         img.Bounds().Dx(),
-		Height:        img.Bounds().Dy(),
+		Width:         ctx.width,
+		Height:        ctx.height,
+		Description:   ctx.options.description,
 		UserID:        ctx.user.ID,
 		IsPublic:      true,
+		PHash:         imghash.Format(ctx.phash),
+		DuplicateOf:   ctx.duplicateOf,
+		ColorHist:     imghash.FormatHistogram(ctx.colorHist),
+		Metadata:      ctx.metadata,
+		// 只在本次上传的响应中返回
+		NearDuplicates: ctx.nearIDs,
 	}
 	return nil
 }
@@ -271,57 +490,70 @@ func (ctx *uploadContext) saveToDatabase() error {
 		ctx.cleanupFiles()
 		return fmt.Errorf("database save failed: %w", err)
 	}
+	phashIndex.Add(ctx.modImage.ID, ctx.phash)
 	return nil
 }
 
 func (ctx *uploadContext) cleanupFiles() {
-	files := []string{ctx.filePath, ctx.thumbnailPath, ctx.webpPath}
+	keys := []string{ctx.filePath, ctx.thumbnailPath, ctx.webpPath}
 	var wg sync.WaitGroup
 
-	for _, path := range files {
+	for _, key := range keys {
 		wg.Add(1)
-		go func(p string) {
+		go func(k string) {
 			defer wg.Done()
-			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
-				log.WithFields(ctx.logFields).Warnf("Cleanup failed for %s: %v", p, err)
+			if err := ctx.storage.Delete(context.Background(), k); err != nil {
+				log.WithFields(ctx.logFields).Warnf("Cleanup failed for %s: %v", k, err)
 			}
-		}(path)
+		}(key)
 	}
 	wg.Wait()
 }
 
 // 其他方法保持类似结构，以下是修改后的关键函数：
 
-func (s *ImageService) createThumbnail(imgData []byte, path string) error {
-	src, err := imaging.Decode(bytes.NewReader(imgData))
+func (s *ImageService) createThumbnail(src image.Image, key string) error {
+	format, err := imaging.FormatFromFilename(key)
 	if err != nil {
-		return fmt.Errorf("thumbnail decode failed: %w", err)
+		return fmt.Errorf("thumbnail format failed: %w", err)
 	}
-
-	thumbnail := imaging.Resize(src, s.thumbnailWidth, 0, imaging.Lanczos)
-	if err := imaging.Save(thumbnail, path, imaging.JPEGQuality(s.quality)); err != nil {
+	buf, err := s.encodeThumbnail(src, format)
+	if err != nil {
+		return err
+	}
+	if err := s.storage.Put(context.Background(), key, buf, int64(buf.Len()), ""); err != nil {
 		return fmt.Errorf("thumbnail save failed: %w", err)
 	}
 	return nil
 }
 
-func (s *ImageService) convertToWebP(imgData []byte, path string) error {
-	src, err := imaging.Decode(bytes.NewReader(imgData))
-	if err != nil {
-		return fmt.Errorf("webp decode failed: %w", err)
+func (s *ImageService) encodeThumbnail(src image.Image, format imaging.Format) (*bytes.Buffer, error) {
+	thumbnail := imaging.Resize(src, s.thumbnailWidth, 0, imaging.Lanczos)
+	var buf bytes.Buffer
+	if err := imaging.Encode(&buf, thumbnail, format, imaging.JPEGQuality(s.quality)); err != nil {
+		return nil, fmt.Errorf("thumbnail encode failed: %w", err)
 	}
+	return &buf, nil
+}
 
-	f, err := os.Create(path)
+func (s *ImageService) convertToWebP(src image.Image, key string) error {
+	buf, err := s.encodeWebP(src)
 	if err != nil {
-		return fmt.Errorf("webp create failed: %w", err)
+		return err
 	}
-	defer f.Close()
+	if err := s.storage.Put(context.Background(), key, buf, int64(buf.Len()), "image/webp"); err != nil {
+		return fmt.Errorf("webp save failed: %w", err)
+	}
+	return nil
+}
 
+func (s *ImageService) encodeWebP(src image.Image) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
 	options := &webp.Options{Quality: float32(s.quality)}
-	if err := webp.Encode(f, src, options); err != nil {
-		return fmt.Errorf("webp encode failed: %w", err)
+	if err := webp.Encode(&buf, src, options); err != nil {
+		return nil, fmt.Errorf("webp encode failed: %w", err)
 	}
-	return nil
+	return &buf, nil
 }
 
 // UpdateImage updates image metadata and properties
@@ -357,30 +589,21 @@ func UpdateImage(imageID uint, req request.UpdateImageReq) (*model.Image, error)
 }
 
 // DeleteImage removes an image and its files
-func DeleteImage(imageID uint) (*response.ImageDeleteResponse, error) {
+func DeleteImage(imageID uint, user *model.User) (*response.ImageDeleteResponse, error) {
 	// Get image data
-	image, err := op.GetImageByID(imageID)
+	image, err := getModifiableImage(imageID, user, CheckImageDelete)
 	if err != nil {
-		return nil, fmt.Errorf("image not found: %w", err)
+		return nil, err
 	}
 
 	// Delete from database
 	if err := op.DeleteImage(imageID); err != nil {
 		return nil, fmt.Errorf("failed to delete image from database: %w", err)
 	}
+	phashIndex.Remove(imageID)
 
 	// Delete files asynchronously
-	go func() {
-		if err := utils.RemoveFile(image.Path); err != nil {
-			log.Printf("Warning: failed to delete image file: %v", err)
-		}
-
-		if image.ThumbnailPath != "" {
-			if err := utils.RemoveFile(image.ThumbnailPath); err != nil {
-				log.Printf("Warning: failed to delete thumbnail: %v", err)
-			}
-		}
-	}()
+	go removeImageFiles(image)
 
 	// Return success response
 	return &response.ImageDeleteResponse{
@@ -389,8 +612,48 @@ func DeleteImage(imageID uint) (*response.ImageDeleteResponse, error) {
 	}, nil
 }
 
+// removeImageFiles 删除图片在存储后端的全部文件与本地处理缓存
+func removeImageFiles(image *model.Image) {
+	s := storage.GetStorage()
+	if err := s.Delete(context.Background(), image.Path); err != nil {
+		log.Printf("Warning: failed to delete image file: %v", err)
+	}
+
+	if image.ThumbnailPath != "" {
+		if err := s.Delete(context.Background(), image.ThumbnailPath); err != nil {
+			log.Printf("Warning: failed to delete thumbnail: %v", err)
+		}
+	}
+
+	if image.WebpPath != "" {
+		if err := s.Delete(context.Background(), image.WebpPath); err != nil {
+			log.Printf("Warning: failed to delete webp: %v", err)
+		}
+	}
+
+	RemoveImageVariants(image.Hash)
+}
+
+// AddTagToImage adds a tag to an image
+func AddTagToImage(imageID uint, tagName string, user *model.User) (*model.Tag, error) {
+	if _, err := getModifiableImage(imageID, user, CheckImageTagWrite); err != nil {
+		return nil, err
+	}
+
+	tag, err := op.AddTagToImage(imageID, tagName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add tag: %w", err)
+	}
+
+	return tag, nil
+}
+
 // RemoveTagFromImage removes a tag from an image
-func RemoveTagFromImage(imageID, tagID uint) (*model.Tag, error) {
+func RemoveTagFromImage(imageID, tagID uint, user *model.User) (*model.Tag, error) {
+	if _, err := getModifiableImage(imageID, user, CheckImageTagWrite); err != nil {
+		return nil, err
+	}
+
 	// Remove the tag
 	tag, err := op.RemoveTagFromImage(imageID, tagID)
 	if err != nil {
@@ -420,16 +683,15 @@ func (s *ImageService) getImageInfo(r io.Reader) (image.Image, string, error) {
 	return img, format, nil
 }
 
-// GetWebPPath 根据原图路径生成WebP格式图片的路径
+// GetWebPPath 根据原图 key 生成WebP格式图片的 key
 func GetWebPPath(imagePath string) string {
-	ext := filepath.Ext(imagePath)
+	ext := path.Ext(imagePath)
 	return strings.TrimSuffix(imagePath, ext) + ".webp"
 }
 
-// GetThumbnailPath returns the thumbnail path for an original path
+// GetThumbnailPath returns the thumbnail key for an original key
 func GetThumbnailPath(originalPath string) string {
-	dir := filepath.Dir(originalPath)
-	filename := filepath.Base(originalPath)
-	thumbnailDir := filepath.Join(dir, "thumbnails")
-	return filepath.Join(thumbnailDir, filename)
+	dir := path.Dir(originalPath)
+	filename := path.Base(originalPath)
+	return path.Join(dir, "thumbnails", filename)
 }
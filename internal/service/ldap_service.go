@@ -0,0 +1,125 @@
+package service
+
+import (
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/ldap"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// Authenticate 校验用户名与密码
+// 启用 LDAP 后除管理员外的用户均通过 LDAP 认证，管理员始终使用本地密码，以免目录服务不可用时无法登录
+func Authenticate(username, password string) (*model.User, error) {
+	if setting.GetBool(conf.LDAPLoginEnabled) {
+		admin, err := op.GetAdmin()
+		if err != nil {
+			return nil, err
+		}
+		if username != admin.Username {
+			return authenticateLDAP(username, password)
+		}
+	}
+	user, err := op.GetUserByName(username)
+	if err != nil {
+		return nil, err
+	}
+	if err := user.ValidatePwdStaticHash(password); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func ldapConfig() ldap.Config {
+	return ldap.Config{
+		Server:           setting.GetStr(conf.LDAPServer),
+		StartTLS:         setting.GetBool(conf.LDAPStartTLS),
+		BindDNTemplate:   setting.GetStr(conf.LDAPBindDNTemplate),
+		SearchBase:       setting.GetStr(conf.LDAPSearchBase),
+		AdminGroupFilter: setting.GetStr(conf.LDAPAdminGroupFilter),
+	}
+}
+
+// authenticateLDAP 通过 LDAP 认证，首次登录时创建同名本地用户，同名的本地用户不能通过 LDAP 登录
+func authenticateLDAP(username, password string) (*model.User, error) {
+	config := ldapConfig()
+	result, err := ldap.Authenticate(config, username, password)
+	if err != nil {
+		if errors.Is(err, ldap.ErrInvalidCredentials) {
+			return nil, errors.WithStack(errs.ErrUsernameOrPassword)
+		}
+		return nil, err
+	}
+
+	user, err := op.GetUserByName(username)
+	if errors.Is(err, errs.ErrUserNotFound) {
+		user = &model.User{Username: username, Role: model.GENERAL, Permission: model.DefaultPermission, LdapDN: result.DN}
+		if result.Admin {
+			user.Permission = model.PermAll
+			user.LdapPermission = model.PermAll &^ model.DefaultPermission
+		}
+		if err := op.CreateUser(user); err != nil {
+			return nil, err
+		}
+		log.Infof("created user %s for ldap account %s", user.Username, result.DN)
+		return user, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.IsGuest() {
+		return nil, errors.WithStack(errs.ErrUsernameOrPassword)
+	}
+	// 缓存中的用户为共享对象，在副本上修改，只更新 LDAP 相关的字段
+	u := *user
+	user = &u
+	if !user.IsLdap() {
+		// 同名的本地用户不能通过 LDAP 登录，否则目录中的同名账户可以接管该用户
+		// 没有本地密码也没有绑定 SSO 的用户只能是早期版本由 LDAP 创建的，记录其 DN
+		if user.PwdHash != "" || user.SsoID != "" {
+			log.Warnf("refused ldap login of %s to local user %s", result.DN, user.Username)
+			return nil, errors.WithStack(errs.ErrLDAPLocalUser)
+		}
+		user.LdapDN = result.DN
+		if err := op.UpdateUserFields(user, "ldap_dn"); err != nil {
+			return nil, err
+		}
+	}
+	if config.AdminGroupFilter != "" {
+		if err := syncLDAPPermission(user, result.Admin); err != nil {
+			return nil, err
+		}
+	}
+	return user, nil
+}
+
+// verifyLDAPPassword 通过 LDAP 重新认证用户，用于敏感操作前确认身份
+func verifyLDAPPassword(user *model.User, password string) error {
+	if _, err := ldap.Authenticate(ldapConfig(), user.Username, password); err != nil {
+		if errors.Is(err, ldap.ErrInvalidCredentials) {
+			return errors.WithStack(errs.ErrUsernameOrPassword)
+		}
+		return err
+	}
+	return nil
+}
+
+// syncLDAPPermission 管理员组成员授予全部权限，移出管理员组后只撤销由 LDAP 授予的权限
+func syncLDAPPermission(user *model.User, admin bool) error {
+	perm, granted := user.Permission, user.LdapPermission
+	if admin {
+		granted |= model.PermAll &^ perm
+		perm = model.PermAll
+	} else {
+		perm &^= granted
+		granted = 0
+	}
+	if perm == user.Permission && granted == user.LdapPermission {
+		return nil
+	}
+	user.Permission, user.LdapPermission = perm, granted
+	return op.UpdateUserFields(user, "permission", "ldap_permission")
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/request"
+	"github.com/FXAZfung/image-board/internal/model/response"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/pkg/imgmeta"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// readMetadata 读取 EXIF/XMP，元数据损坏时只记录日志，不影响上传
+func (ctx *uploadContext) readMetadata() error {
+	f, err := os.Open(ctx.localPath)
+	if err != nil {
+		return errors.Wrap(err, "file open failed")
+	}
+	defer f.Close()
+	m, err := imgmeta.Parse(f)
+	if err != nil {
+		log.WithFields(ctx.logFields).Debugf("Malformed image metadata: %v", err)
+	}
+	ctx.metadata = metadataModel(m)
+	return nil
+}
+
+// metadataModel 转换为数据库记录，没有元数据时返回 nil
+func metadataModel(m *imgmeta.Metadata) *model.ImageMetadata {
+	if m == nil || m.IsEmpty() {
+		return nil
+	}
+	metadata := &model.ImageMetadata{
+		CameraMake:   truncate(m.Make, 64),
+		CameraModel:  truncate(m.Model, 128),
+		LensModel:    truncate(m.LensModel, 128),
+		ExposureTime: truncate(m.ExposureTime, 16),
+		FNumber:      m.FNumber,
+		ISO:          m.ISO,
+		FocalLength:  m.FocalLength,
+		Orientation:  m.Orientation,
+		Title:        truncate(m.Title, 255),
+		Description:  m.Description,
+		Creator:      truncate(m.Creator, 255),
+		Keywords:     strings.Join(m.Keywords, ","),
+		Rating:       m.Rating,
+	}
+	if !m.DateTaken.IsZero() {
+		t := m.DateTaken.UTC()
+		metadata.DateTaken = &t
+	}
+	if m.GPS != nil {
+		metadata.Latitude, metadata.Longitude, metadata.Altitude = &m.GPS.Latitude, &m.GPS.Longitude, m.GPS.Altitude
+	}
+	return metadata
+}
+
+// truncate 按字符截断到 n 个字节以内，避免超出列长度
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// canViewLocation 拍摄位置只对上传者与可以查看私有图片的用户可见
+func canViewLocation(image *model.Image, viewer *model.User) bool {
+	return image.IsOwnedBy(viewer) || (viewer != nil && viewer.HasPermission(model.PermViewPrivate))
+}
+
+// GetImageDetail 获取 viewer 可见的图片及其元数据
+func GetImageDetail(id uint, viewer *model.User) (*model.Image, error) {
+	image, err := op.GetImageByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !image.CanView(viewer) {
+		return nil, errors.WithStack(errs.ImageNotFound)
+	}
+	metadata, err := op.GetImageMetadata(id)
+	if err != nil {
+		return nil, err
+	}
+	// 缓存中的图片为共享对象，复制后再附加元数据
+	detail := *image
+	if metadata != nil && !canViewLocation(image, viewer) {
+		metadata = metadata.WithoutLocation()
+	}
+	detail.Metadata = metadata
+	return &detail, nil
+}
+
+// SearchImagesByMetadata 按相机、镜头与拍摄时间筛选图片
+func SearchImagesByMetadata(req request.MetadataSearchReq, viewer *model.User) ([]*model.Image, int64, error) {
+	filter := model.MetadataFilter{
+		CameraMake:  req.CameraMake,
+		CameraModel: req.CameraModel,
+		LensModel:   req.LensModel,
+		TakenAfter:  req.TakenAfter,
+		TakenBefore: req.TakenBefore,
+	}
+	images, total, err := op.SearchImagesByMetadata(filter, req.Page, req.PerPage, viewer)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, image := range images {
+		if image.Metadata != nil && !canViewLocation(image, viewer) {
+			image.Metadata = image.Metadata.WithoutLocation()
+		}
+	}
+	return images, total, nil
+}
+
+// GetMetadataFacets 统计 viewer 可见的图片中的相机型号与拍摄月份
+func GetMetadataFacets(viewer *model.User) (*response.MetadataFacets, error) {
+	cameras, err := op.GetCameraFacets(viewer)
+	if err != nil {
+		return nil, err
+	}
+	months, err := op.GetMonthFacets(viewer)
+	if err != nil {
+		return nil, err
+	}
+	return &response.MetadataFacets{Cameras: cameras, Months: months}, nil
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/setting"
+	"github.com/FXAZfung/image-board/pkg/imgmeta"
+	"github.com/chai2010/webp"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// 按方向旋转后重新编码原图使用的质量
+const orientedQuality = 95
+
+// validateStripLevel 检查上传参数中的元数据去除程度
+func validateStripLevel(value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := imgmeta.ParseStripLevel(value); err != nil {
+		return errors.WithStack(errs.ErrInvalidStripLevel)
+	}
+	return nil
+}
+
+// stripLevel 站点设置是最低要求，上传参数只能要求更严格的去除程度
+func stripLevel(value string) imgmeta.StripLevel {
+	site, err := imgmeta.ParseStripLevel(setting.GetStr(conf.MetadataStripPolicy, "gps"))
+	if err != nil {
+		site = imgmeta.StripGPS
+	}
+	level, err := imgmeta.ParseStripLevel(value)
+	if err != nil {
+		return site
+	}
+	return max(site, level)
+}
+
+// stripMetadata 去除原图中的元数据，处理后的文件替换 localPath，哈希改为处理后内容的哈希
+// 去重按保存的内容进行，以更严格的程度重新上传时不会得到之前保留了更多元数据的副本
+// 保留私有副本时，去除前读取的元数据只保存在数据库中，保存的文件中不再包含
+func (ctx *uploadContext) stripMetadata() error {
+	level := stripLevel(ctx.options.stripMetadata)
+	if level == imgmeta.StripNone {
+		return nil
+	}
+	tmp, err := os.CreateTemp("", "image-board-strip-*")
+	if err != nil {
+		return errors.Wrap(err, "temp file create failed")
+	}
+	hash := sha256.New()
+	w := io.MultiWriter(tmp, hash)
+	if ctx.upright != nil {
+		// 方向标签也会被去除，使用解码时编码的正向图片，保证仍然正向显示
+		if _, err = ctx.upright.WriteTo(w); err == nil {
+			// 保存的原图已经正向，不再需要按方向显示
+			ctx.metadata.Orientation = 1
+		}
+	} else {
+		err = stripFile(w, ctx.localPath, level)
+	}
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		_ = tmp.Close()
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return errors.Wrap(err, "metadata strip failed")
+	}
+	info, err := os.Stat(tmp.Name())
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return errors.WithStack(err)
+	}
+
+	if ctx.tempFile {
+		_ = os.Remove(ctx.localPath)
+	}
+	ctx.localPath, ctx.tempFile, ctx.size = tmp.Name(), true, info.Size()
+	ctx.hash = hex.EncodeToString(hash.Sum(nil))
+	log.WithFields(ctx.logFields).Debugf("Metadata stripped: level=%s, size=%d", level, ctx.size)
+
+	if !setting.GetBool(conf.MetadataRetainPrivate) {
+		ctx.metadata = withoutStripped(ctx.metadata, level)
+	}
+	return nil
+}
+
+func stripFile(w io.Writer, name string, level imgmeta.StripLevel) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer f.Close()
+	return imgmeta.Strip(w, f, level)
+}
+
+// needsUpright 去除全部元数据时方向标签也会被去除，带方向的图片需要以正向重新编码
+func (ctx *uploadContext) needsUpright() bool {
+	return ctx.metadata != nil && ctx.metadata.Orientation > 1 &&
+		stripLevel(ctx.options.stripMetadata) == imgmeta.StripAll
+}
+
+// encodeUpright 以原格式编码已转为正向的图片，在解码图片时调用
+func (ctx *uploadContext) encodeUpright(img image.Image) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	var err error
+	switch ctx.contentType {
+	case "image/png":
+		err = png.Encode(&buf, img)
+	case "image/webp":
+		err = webp.Encode(&buf, img, &webp.Options{Quality: orientedQuality})
+	default:
+		// 只有 JPEG、PNG 与 WebP 会读取到方向
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: orientedQuality})
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &buf, nil
+}
+
+// withoutStripped 不保留私有副本时，从元数据记录中一并去除已从原图中去除的字段
+func withoutStripped(m *model.ImageMetadata, level imgmeta.StripLevel) *model.ImageMetadata {
+	switch {
+	case m == nil, level >= imgmeta.StripAll:
+		return nil
+	case level >= imgmeta.StripGPS:
+		m.Latitude, m.Longitude, m.Altitude = nil, nil, nil
+	}
+	return m
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/response"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/setting"
+	"github.com/FXAZfung/image-board/internal/storage"
+	"github.com/FXAZfung/image-board/pkg/imghash"
+	"github.com/FXAZfung/image-board/pkg/imgmeta"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// 相似图片处理策略
+const (
+	NearDuplicateOff    = "off"
+	NearDuplicateWarn   = "warn"   // 上传成功，响应中列出相似图片
+	NearDuplicateLink   = "link"   // 同 warn，并将最相似的图片记录为 duplicate_of
+	NearDuplicateReject = "reject" // 拒绝上传
+)
+
+const (
+	maxNearDuplicates  = 10  // 上传响应中最多列出的相似图片数
+	phashBackfillBatch = 100 // 补算感知哈希时每次读取的图片数
+)
+
+// phashIndex 全部图片感知哈希的内存索引，启动时从数据库构建，随上传与删除更新
+var phashIndex = imghash.NewIndex()
+
+// LoadPHashIndex 从数据库构建感知哈希索引
+func LoadPHashIndex() error {
+	hashes, err := op.GetImagePHashes()
+	if err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		phashIndex.Add(h.ID, h.PHash)
+	}
+	log.Infof("loaded phash index with %d images", phashIndex.Len())
+	return nil
+}
+
+// NearDuplicateThreshold 设置中的相似距离阈值，限制在 0-64
+func NearDuplicateThreshold() int {
+	return min(max(setting.GetInt(conf.NearDuplicateThreshold, 6), 0), 64)
+}
+
+// checkNearDuplicate 按设置的策略处理与已有图片相似的上传
+func (ctx *uploadContext) checkNearDuplicate() error {
+	policy := setting.GetStr(conf.NearDuplicatePolicy, NearDuplicateWarn)
+	if policy == NearDuplicateOff {
+		return nil
+	}
+	matches := phashIndex.Search(ctx.phash, NearDuplicateThreshold())
+	if len(matches) == 0 {
+		return nil
+	}
+
+	ctx.logFields["near_duplicate_of"] = matches[0].ID
+	// 只向上传者透露其可见的图片，其他相似图片不返回 ID
+	visible := ctx.visibleMatches(matches)
+	switch policy {
+	case NearDuplicateReject:
+		log.WithFields(ctx.logFields).Info("Near duplicate image found")
+		if len(visible) == 0 {
+			return errors.WithStack(errs.ErrNearDuplicate)
+		}
+		ctx.modImage = visible[0].image
+		return errs.NewErr(errs.ErrNearDuplicate, "similar to image %d (distance %d)", visible[0].image.ID, visible[0].Distance)
+	case NearDuplicateLink:
+		if len(visible) > 0 {
+			ctx.duplicateOf = visible[0].image.ID
+		}
+	}
+	for _, m := range visible {
+		ctx.nearIDs = append(ctx.nearIDs, m.image.ID)
+	}
+	return nil
+}
+
+type visibleMatch struct {
+	imghash.Match
+	image *model.Image
+}
+
+// visibleMatches 按距离顺序返回上传者可见的相似图片，最多 maxNearDuplicates 张
+func (ctx *uploadContext) visibleMatches(matches []imghash.Match) []visibleMatch {
+	var visible []visibleMatch
+	for _, m := range matches {
+		image, err := op.GetImageByID(m.ID)
+		if err != nil || !image.CanView(ctx.user) {
+			continue
+		}
+		visible = append(visible, visibleMatch{Match: m, image: image})
+		if len(visible) == maxNearDuplicates {
+			break
+		}
+	}
+	return visible
+}
+
+// StartPHashBackfill 在后台为尚未计算感知哈希或颜色直方图的已有图片补算
+func StartPHashBackfill() {
+	go func() {
+		var afterID uint
+		var count int
+		for {
+			images, err := op.GetImagesWithoutHashes(afterID, phashBackfillBatch)
+			if err != nil {
+				log.Warnf("failed to get images without phash: %+v", err)
+				return
+			}
+			if len(images) == 0 {
+				break
+			}
+			for _, img := range images {
+				afterID = img.ID
+				if err := backfillPHash(img); err != nil {
+					log.Warnf("failed to compute phash of image %d: %v", img.ID, err)
+					continue
+				}
+				count++
+			}
+		}
+		if count > 0 {
+			log.Infof("computed phash for %d images", count)
+		}
+	}()
+}
+
+func backfillPHash(img *model.Image) error {
+	reader, _, err := storage.GetStorage().Get(context.Background(), img.Path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	processingSem <- struct{}{}
+	src, _, err := imgmeta.Decode(reader)
+	<-processingSem
+	if err != nil {
+		return errors.Wrap(err, "image decode failed")
+	}
+	hash := imghash.DHash(src)
+	img.PHash = imghash.Format(hash)
+	img.ColorHist = imghash.FormatHistogram(imghash.ColorHistogram(src))
+	if err := op.UpdateImageHashes(img); err != nil {
+		return err
+	}
+	phashIndex.Add(img.ID, hash)
+	return nil
+}
+
+// ListDuplicateClusters 将距离不超过 threshold 的图片归为一组，按组内图片数从多到少分页返回
+func ListDuplicateClusters(threshold, page, perPage int, viewer *model.User) ([]response.DuplicateCluster, int64, error) {
+	// 并查集合并所有距离不超过阈值的图片
+	parent := make(map[uint]uint)
+	var find func(uint) uint
+	find = func(id uint) uint {
+		p, ok := parent[id]
+		if !ok || p == id {
+			return id
+		}
+		parent[id] = find(p)
+		return parent[id]
+	}
+	hashes := make(map[uint]uint64)
+	for _, id := range phashIndex.IDs() {
+		hash, ok := phashIndex.Get(id)
+		if !ok {
+			continue
+		}
+		hashes[id] = hash
+		for _, m := range phashIndex.Search(hash, threshold) {
+			if a, b := find(id), find(m.ID); a != b {
+				parent[max(a, b)] = min(a, b)
+			}
+		}
+	}
+
+	groups := make(map[uint][]uint)
+	for id := range hashes {
+		root := find(id)
+		groups[root] = append(groups[root], id)
+	}
+	var clusters [][]uint
+	for _, group := range groups {
+		if len(group) > 1 {
+			sort.Slice(group, func(i, j int) bool { return group[i] < group[j] })
+			clusters = append(clusters, group)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if len(clusters[i]) != len(clusters[j]) {
+			return len(clusters[i]) > len(clusters[j])
+		}
+		return clusters[i][0] < clusters[j][0]
+	})
+
+	total := int64(len(clusters))
+	start := min((page-1)*perPage, len(clusters))
+	clusters = clusters[start:min(start+perPage, len(clusters))]
+
+	var ids []uint
+	for _, cluster := range clusters {
+		ids = append(ids, cluster...)
+	}
+	images := make(map[uint]*model.Image, len(ids))
+	if len(ids) > 0 {
+		list, err := op.GetImagesByIDs(ids, viewer)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, img := range list {
+			images[img.ID] = img
+		}
+	}
+
+	result := make([]response.DuplicateCluster, 0, len(clusters))
+	for _, cluster := range clusters {
+		c := response.DuplicateCluster{Images: make([]*model.Image, 0, len(cluster))}
+		for i, id := range cluster {
+			if img, ok := images[id]; ok {
+				c.Images = append(c.Images, img)
+			}
+			for _, other := range cluster[i+1:] {
+				c.MaxDistance = max(c.MaxDistance, imghash.Distance(hashes[id], hashes[other]))
+			}
+		}
+		result = append(result, c)
+	}
+	return result, total, nil
+}
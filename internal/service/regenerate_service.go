@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/storage"
+	"github.com/FXAZfung/image-board/pkg/imghash"
+	"github.com/FXAZfung/image-board/pkg/imgmeta"
+	"github.com/pkg/errors"
+)
+
+const regenerateBatchSize = 100
+
+// RegenerateOptions 重新生成衍生文件的选项
+type RegenerateOptions struct {
+	All    bool // 处理全部图片，否则只处理原图带有非默认 EXIF 方向的图片
+	DryRun bool // 只统计需要处理的图片，不写入任何内容
+}
+
+// RegenerateResult 重新生成的统计
+type RegenerateResult struct {
+	Scanned     int
+	Regenerated int
+	Failed      int
+}
+
+// RegenerateImages 按原图的 EXIF 方向重新生成缩略图与 WebP，并更新宽高、感知哈希与颜色直方图
+// 每处理一张图片调用一次 report，err 不为空表示该图片处理失败
+func RegenerateImages(opts RegenerateOptions, report func(img *model.Image, orientation int, err error)) (*RegenerateResult, error) {
+	result := &RegenerateResult{}
+	var afterID uint
+	for {
+		images, err := op.GetImagesAfter(afterID, regenerateBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(images) == 0 {
+			return result, nil
+		}
+		for _, img := range images {
+			afterID = img.ID
+			result.Scanned++
+			orientation, err := regenerateImage(img, opts)
+			if err == nil && orientation < 0 {
+				continue
+			}
+			if err != nil {
+				result.Failed++
+			} else {
+				result.Regenerated++
+			}
+			if report != nil {
+				report(img, orientation, err)
+			}
+		}
+	}
+}
+
+// regenerateImage 处理单张图片，返回原图的方向值，不需要处理时返回 -1
+func regenerateImage(img *model.Image, opts RegenerateOptions) (int, error) {
+	reader, _, err := storage.GetStorage().Get(context.Background(), img.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	m, _ := imgmeta.Parse(reader)
+	if !opts.All && m.Orientation <= 1 {
+		return -1, nil
+	}
+	if opts.DryRun {
+		return m.Orientation, nil
+	}
+	if _, err := reader.Seek(0, io.SeekStart); err != nil {
+		return m.Orientation, errors.WithStack(err)
+	}
+
+	processingSem <- struct{}{}
+	defer func() { <-processingSem }()
+	src, orientation, err := imgmeta.Decode(reader)
+	if err != nil {
+		return m.Orientation, errors.Wrap(err, "image decode failed")
+	}
+	s := NewImageService()
+	if err := s.createThumbnail(src, img.ThumbnailPath); err != nil {
+		return orientation, err
+	}
+	if err := s.convertToWebP(src, img.WebpPath); err != nil {
+		return orientation, err
+	}
+	RemoveImageVariants(img.Hash)
+
+	hash := imghash.DHash(src)
+	img.Width, img.Height = src.Bounds().Dx(), src.Bounds().Dy()
+	img.PHash = imghash.Format(hash)
+	img.ColorHist = imghash.FormatHistogram(imghash.ColorHistogram(src))
+	if err := op.UpdateImageDerived(img); err != nil {
+		return orientation, err
+	}
+	phashIndex.Add(img.ID, hash)
+	return orientation, nil
+}
@@ -0,0 +1,293 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/request"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/setting"
+	"github.com/FXAZfung/image-board/pkg/random"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	uploadSessionDuration = 24 * time.Hour // 最后一次写入后未完成的上传保留时间
+	uploadGCInterval      = time.Hour
+	maxUserUploads        = 10 // 每个用户同时进行的上传数
+)
+
+func uploadDir() string {
+	return filepath.Join(conf.Conf.DataImage.CacheDir, "uploads")
+}
+
+func uploadPartPath(id string) string {
+	return filepath.Join(uploadDir(), id+".part")
+}
+
+// CreateUpload 创建断点续传的上传会话
+func CreateUpload(user *model.User, req request.CreateUploadReq) (*model.UploadSession, error) {
+	if err := CheckPermission(user, model.PermUpload); err != nil {
+		return nil, err
+	}
+	name := filepath.Base(req.FileName)
+	if !NewImageService().isAllowedExtension(filepath.Ext(name)) {
+		return nil, errors.Wrapf(errs.ErrInvalidFileExt, "%s", filepath.Ext(name))
+	}
+	if req.Size <= 0 || req.Size > int64(setting.GetInt(conf.ImageMaxSize, 20))<<20 {
+		return nil, errors.WithStack(errs.ErrFileTooLarge)
+	}
+	if err := validateStripLevel(req.StripMetadata); err != nil {
+		return nil, err
+	}
+
+	// 过期的会话不计入，由 StartUploadGC 定期清理
+	count, err := op.CountUserUploadSessions(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxUserUploads {
+		return nil, errors.WithStack(errs.ErrUploadTooMany)
+	}
+
+	state, err := sha256.New().(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	session := &model.UploadSession{
+		ID:            random.String(32),
+		UserID:        user.ID,
+		FileName:      name,
+		Description:   req.Description,
+		StripMetadata: req.StripMetadata,
+		Size:          req.Size,
+		HashState:     state,
+		ExpiresAt:     time.Now().Add(uploadSessionDuration),
+	}
+	if err := os.MkdirAll(uploadDir(), 0755); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := os.WriteFile(uploadPartPath(session.ID), nil, 0644); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err := op.CreateUploadSession(session); err != nil {
+		_ = os.Remove(uploadPartPath(session.ID))
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetUpload 获取上传会话
+func GetUpload(user *model.User, id string) (*model.UploadSession, error) {
+	return op.GetUploadSession(user.ID, id)
+}
+
+// WriteUploadChunk 从 offset 处追加分片，连接中断时保留已接收的部分
+func WriteUploadChunk(user *model.User, id string, offset int64, r io.Reader) (*model.UploadSession, error) {
+	unlock := lockKey("upload:" + id)
+	defer unlock()
+
+	session, err := op.GetUploadSession(user.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if offset != session.Offset {
+		return session, errors.WithStack(errs.ErrUploadOffset)
+	}
+
+	h := sha256.New()
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(session.HashState); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	f, err := os.OpenFile(uploadPartPath(id), os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer f.Close()
+	// 丢弃上次失败写入时残留的未记录数据
+	if err := f.Truncate(session.Offset); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if _, err := f.Seek(session.Offset, io.SeekStart); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	n, copyErr := io.Copy(io.MultiWriter(f, h), io.LimitReader(r, session.Size-session.Offset))
+	if copyErr == nil && session.Offset+n == session.Size {
+		// 已达到声明的大小，请求体中仍有数据则视为越界
+		if m, _ := r.Read(make([]byte, 1)); m > 0 {
+			copyErr = errors.WithStack(errs.ErrUploadOverflow)
+		}
+	}
+	if err := saveUploadProgress(session, h, n); err != nil {
+		return nil, err
+	}
+	if copyErr != nil {
+		return session, errors.WithStack(copyErr)
+	}
+	return session, nil
+}
+
+func saveUploadProgress(session *model.UploadSession, h hash.Hash, n int64) error {
+	if n == 0 {
+		return nil
+	}
+	state, err := h.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	session.Offset += n
+	session.HashState = state
+	session.ExpiresAt = time.Now().Add(uploadSessionDuration)
+	return op.UpdateUploadSessionProgress(session)
+}
+
+// FinishUpload 数据接收完成后交给上传流程处理，无论成功与否都会删除上传会话
+func FinishUpload(user *model.User, id string) (*model.Image, error) {
+	if err := CheckPermission(user, model.PermUpload); err != nil {
+		return nil, err
+	}
+	unlock := lockKey("upload:" + id)
+	defer unlock()
+
+	session, err := op.GetUploadSession(user.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !session.Completed() {
+		return nil, errors.WithStack(errs.ErrUploadIncomplete)
+	}
+	defer removeUpload(session.ID)
+
+	h := sha256.New()
+	if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(session.HashState); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	source := uploadSource{
+		name: session.FileName,
+		hash: hex.EncodeToString(h.Sum(nil)),
+		path: uploadPartPath(session.ID),
+	}
+	logFields := log.Fields{
+		"user_id":   user.ID,
+		"file_name": session.FileName,
+		"file_size": session.Size,
+		"operation": "resumable_upload",
+	}
+	image, err := NewImageService().processUpload(source, user, uploadOptions{description: session.Description, stripMetadata: session.StripMetadata}, logFields)
+	if err != nil {
+		log.WithFields(logFields).Errorf("Upload failed: %v", err)
+		return nil, err
+	}
+	return image, nil
+}
+
+// AbortUpload 取消上传并删除已接收的数据
+func AbortUpload(user *model.User, id string) error {
+	unlock := lockKey("upload:" + id)
+	defer unlock()
+
+	session, err := op.GetUploadSession(user.ID, id)
+	if err != nil {
+		return err
+	}
+	removeUpload(session.ID)
+	return nil
+}
+
+func removeUpload(id string) {
+	if err := op.DeleteUploadSession(id); err != nil {
+		log.Warnf("failed to delete upload session %s: %+v", id, err)
+	}
+	if err := os.Remove(uploadPartPath(id)); err != nil && !os.IsNotExist(err) {
+		log.Warnf("failed to remove upload data %s: %v", id, err)
+	}
+}
+
+// removeUserUploads 删除用户全部未完成的上传
+func removeUserUploads(userID uint) error {
+	sessions, err := op.DeleteUserUploadSessions(userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		_ = os.Remove(uploadPartPath(session.ID))
+	}
+	return nil
+}
+
+// CleanExpiredUploads 删除过期的上传会话，以及没有对应会话的临时文件
+func CleanExpiredUploads() {
+	sessions, err := op.GetExpiredUploadSessions()
+	if err != nil {
+		log.Warnf("failed to get expired uploads: %+v", err)
+		return
+	}
+	for _, session := range sessions {
+		removeExpiredUpload(session.ID)
+	}
+
+	entries, err := os.ReadDir(uploadDir())
+	if err != nil {
+		return
+	}
+	ids, err := op.GetUploadSessionIDs()
+	if err != nil {
+		log.Warnf("failed to get uploads: %+v", err)
+		return
+	}
+	active := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		active[id] = true
+	}
+	for _, entry := range entries {
+		id := strings.TrimSuffix(entry.Name(), ".part")
+		if active[id] {
+			continue
+		}
+		// 跳过刚创建、会话尚未写入数据库的文件
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) < time.Minute {
+			continue
+		}
+		_ = os.Remove(filepath.Join(uploadDir(), entry.Name()))
+	}
+}
+
+// removeExpiredUpload 与分片写入互斥，加锁后确认会话仍已过期再删除，期间续传的会话会被保留
+func removeExpiredUpload(id string) {
+	unlock := lockKey("upload:" + id)
+	defer unlock()
+
+	deleted, err := op.DeleteExpiredUploadSession(id)
+	if err != nil {
+		log.Warnf("failed to delete upload session %s: %+v", id, err)
+		return
+	}
+	if !deleted {
+		return
+	}
+	if err := os.Remove(uploadPartPath(id)); err != nil && !os.IsNotExist(err) {
+		log.Warnf("failed to remove upload data %s: %v", id, err)
+	}
+}
+
+// StartUploadGC 定期清理过期的上传
+func StartUploadGC() {
+	go func() {
+		CleanExpiredUploads()
+		for range time.Tick(uploadGCInterval) {
+			CleanExpiredUploads()
+		}
+	}()
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"io"
+	"mime/multipart"
+	"sort"
+
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/request"
+	"github.com/FXAZfung/image-board/internal/model/response"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/setting"
+	"github.com/FXAZfung/image-board/pkg/imghash"
+	"github.com/FXAZfung/image-board/pkg/imgmeta"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultSimilarLimit    = 20
+	maxSimilarLimit        = 100
+	defaultSimilarDistance = 16
+	maxSimilarCandidates   = 500 // 按感知哈希距离取出的候选数，过滤不可见图片与颜色排序均在候选中进行
+	colorWeight            = 0.3 // 结合颜色排序时颜色直方图相似度的权重
+)
+
+// SearchSimilarImages 以图搜图，查询图片只用于计算哈希，不会保存
+func SearchSimilarImages(file *multipart.FileHeader, req request.SimilarImageReq, viewer *model.User) ([]response.SimilarImage, error) {
+	if file.Size > int64(setting.GetInt(conf.ImageMaxSize, 20))<<20 {
+		return nil, errors.WithStack(errs.ErrFileTooLarge)
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "file open failed")
+	}
+	defer f.Close()
+	if err := checkImagePixels(f); err != nil {
+		return nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	processingSem <- struct{}{}
+	// 与图库中的哈希一致，按 EXIF 方向转为正向后计算
+	src, _, err := imgmeta.Decode(f)
+	var hash uint64
+	var hist imghash.Histogram
+	if err == nil {
+		hash = imghash.DHash(src)
+		hist = imghash.ColorHistogram(src)
+	}
+	<-processingSem
+	if err != nil {
+		return nil, errors.WithStack(errs.ErrCorruptedFile)
+	}
+
+	maxDistance := defaultSimilarDistance
+	if req.MaxDistance != nil {
+		maxDistance = *req.MaxDistance
+	}
+	limit := req.Limit
+	if limit < 1 || limit > maxSimilarLimit {
+		limit = defaultSimilarLimit
+	}
+
+	matches := phashIndex.Search(hash, maxDistance)
+	matches = matches[:min(len(matches), maxSimilarCandidates)]
+	if len(matches) == 0 {
+		return []response.SimilarImage{}, nil
+	}
+	ids := make([]uint, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ID
+	}
+	images, err := op.GetImagesByIDs(ids, viewer)
+	if err != nil {
+		return nil, err
+	}
+	visible := make(map[uint]*model.Image, len(images))
+	for _, img := range images {
+		visible[img.ID] = img
+	}
+
+	results := make([]response.SimilarImage, 0, len(images))
+	for _, m := range matches {
+		img, ok := visible[m.ID]
+		if !ok {
+			continue
+		}
+		similarity := 1 - float64(m.Distance)/64
+		if req.Color {
+			// 补算完成前没有直方图的图片只按感知哈希计算
+			if h, err := imghash.ParseHistogram(img.ColorHist); err == nil {
+				similarity = (1-colorWeight)*similarity + colorWeight*hist.Similarity(h)
+			}
+		}
+		results = append(results, response.SimilarImage{Image: img, Distance: m.Distance, Similarity: similarity})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Similarity > results[j].Similarity
+	})
+	return results[:min(len(results), limit)], nil
+}
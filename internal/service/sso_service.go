@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FXAZfung/go-cache"
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/setting"
+	"github.com/FXAZfung/image-board/internal/sso"
+	"github.com/FXAZfung/image-board/pkg/random"
+	"github.com/FXAZfung/image-board/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ssoState 发起授权时保存的状态，回调时取出校验
+type ssoState struct {
+	nonce       string
+	verifier    string
+	redirectURL string
+	browser     string // 发起授权的浏览器 cookie 中保存的值，回调时必须一致
+	bindUserID  uint   // 非 0 时为已登录用户绑定 SSO 账户
+}
+
+var ssoStateCache = cache.NewMemCache[*ssoState]()
+
+const ssoStateDuration = 10 * time.Minute
+
+var (
+	ssoProviderMu sync.Mutex
+	ssoProvider   *sso.Provider
+)
+
+// ssoRedirectURL 回调地址，未配置 sso_redirect_uri 时使用配置文件中的 site_url，不信任请求头中的地址
+func ssoRedirectURL() (string, error) {
+	if uri := setting.GetStr(conf.SSORedirectURI); uri != "" {
+		return uri, nil
+	}
+	if site := strings.TrimSuffix(conf.Conf.SiteURL, "/"); site != "" {
+		return site + "/api/auth/sso/callback", nil
+	}
+	return "", errors.WithStack(errs.ErrSSORedirectURL)
+}
+
+func ssoConfig(redirectURL string) sso.Config {
+	return sso.Config{
+		Issuer:       setting.GetStr(conf.SSOIssuer),
+		ClientID:     setting.GetStr(conf.SSOClientID),
+		ClientSecret: setting.GetStr(conf.SSOClientSecret),
+		RedirectURL:  redirectURL,
+		Scopes:       strings.Fields(setting.GetStr(conf.SSOScopes, "openid profile email")),
+	}
+}
+
+// getSSOProvider 获取当前配置对应的提供方，配置变化后重新发现
+func getSSOProvider(ctx context.Context, redirectURL string) (*sso.Provider, error) {
+	if !setting.GetBool(conf.SSOLoginEnabled) {
+		return nil, errors.WithStack(errs.ErrSSODisabled)
+	}
+	config := ssoConfig(redirectURL)
+	ssoProviderMu.Lock()
+	defer ssoProviderMu.Unlock()
+	if ssoProvider != nil && sameSSOConfig(ssoProvider.Config(), config) {
+		return ssoProvider, nil
+	}
+	p, err := sso.NewProvider(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	ssoProvider = p
+	return p, nil
+}
+
+func sameSSOConfig(a, b sso.Config) bool {
+	return a.Issuer == b.Issuer && a.ClientID == b.ClientID && a.ClientSecret == b.ClientSecret &&
+		a.RedirectURL == b.RedirectURL && strings.Join(a.Scopes, " ") == strings.Join(b.Scopes, " ")
+}
+
+// SSOAuthURL 生成跳转到提供方的授权链接，bindUser 不为空时回调将绑定到该用户
+// 返回的 browser 需要保存在发起授权的浏览器的 cookie 中，回调时校验，防止将他人发起的授权在受害者的浏览器中完成
+func SSOAuthURL(ctx context.Context, bindUser *model.User) (url, browser string, err error) {
+	redirectURL, err := ssoRedirectURL()
+	if err != nil {
+		return "", "", err
+	}
+	p, err := getSSOProvider(ctx, redirectURL)
+	if err != nil {
+		return "", "", err
+	}
+	state := &ssoState{
+		nonce:       random.String(32),
+		verifier:    sso.NewVerifier(),
+		redirectURL: p.Config().RedirectURL,
+		browser:     random.String(32),
+	}
+	if bindUser != nil {
+		if bindUser.IsGuest() {
+			return "", "", errors.WithStack(errs.ErrPermissionDenied)
+		}
+		state.bindUserID = bindUser.ID
+	}
+	key := random.String(32)
+	ssoStateCache.Set(key, state, cache.WithEx[*ssoState](ssoStateDuration))
+	return p.AuthURL(key, state.nonce, state.verifier), state.browser, nil
+}
+
+// SSOCallback 处理提供方回调，返回登录的用户以及本次是否为绑定操作
+// browser 为浏览器 cookie 中的值，必须与发起授权时一致
+func SSOCallback(ctx context.Context, stateKey, browser, code string) (*model.User, bool, error) {
+	state, ok := ssoStateCache.Get(stateKey)
+	if !ok {
+		return nil, false, errors.WithStack(errs.ErrSSOState)
+	}
+	ssoStateCache.Del(stateKey)
+	if subtle.ConstantTimeCompare([]byte(state.browser), []byte(browser)) != 1 {
+		return nil, false, errors.WithStack(errs.ErrSSOState)
+	}
+
+	p, err := getSSOProvider(ctx, state.redirectURL)
+	if err != nil {
+		return nil, false, err
+	}
+	rawIDToken, err := p.Exchange(ctx, code, state.verifier)
+	if err != nil {
+		return nil, false, err
+	}
+	claims, err := p.Verify(ctx, rawIDToken, state.nonce)
+	if err != nil {
+		return nil, false, err
+	}
+	if !ssoDomainAllowed(claims) {
+		return nil, false, errors.WithStack(errs.ErrSSODomain)
+	}
+
+	if state.bindUserID != 0 {
+		user, err := bindSSO(state.bindUserID, claims.Subject())
+		return user, true, err
+	}
+
+	user, err := op.GetUserBySsoID(claims.Subject())
+	switch {
+	case errors.Is(err, errs.ErrUserNotFound):
+		if !setting.GetBool(conf.SSOAutoRegister) {
+			return nil, false, errors.WithStack(errs.ErrSSONotBound)
+		}
+		user, err = registerSSOUser(claims)
+		if err != nil {
+			return nil, false, err
+		}
+	case err != nil:
+		return nil, false, err
+	default:
+		if user, err = syncSSOPermission(user, claims); err != nil {
+			return nil, false, err
+		}
+	}
+	if user.Disabled {
+		return nil, false, errors.New("Current user is disabled, replace please")
+	}
+	return user, false, nil
+}
+
+// 绑定与同步权限时 user 为缓存中的用户，在副本上修改并只更新对应字段，不会覆盖管理员同时做出的修改
+
+// UnbindSSO 解除用户绑定的 SSO 账户
+func UnbindSSO(user *model.User) error {
+	u := *user
+	u.SsoID = ""
+	return op.UpdateUserFields(&u, "sso_id")
+}
+
+func bindSSO(userID uint, subject string) (*model.User, error) {
+	if other, err := op.GetUserBySsoID(subject); err == nil && other.ID != userID {
+		return nil, errors.WithStack(errs.ErrSSOAlreadyBound)
+	}
+	user, err := op.GetUserById(userID)
+	if err != nil {
+		return nil, err
+	}
+	u := *user
+	u.SsoID = subject
+	if err := op.UpdateUserFields(&u, "sso_id"); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// ssoDomainAllowed 配置了允许的域名时，要求邮箱已验证且属于其中之一
+func ssoDomainAllowed(claims sso.Claims) bool {
+	allowed := strings.TrimSpace(setting.GetStr(conf.SSOAllowedDomains))
+	if allowed == "" {
+		return true
+	}
+	email := strings.ToLower(claims.Email())
+	at := strings.LastIndex(email, "@")
+	if at < 0 || !claims.EmailVerified() {
+		return false
+	}
+	for _, domain := range strings.Split(allowed, ",") {
+		if strings.TrimSpace(strings.ToLower(domain)) == email[at+1:] {
+			return true
+		}
+	}
+	return false
+}
+
+// ssoPermission 根据角色映射计算权限，未配置映射时返回 false
+func ssoPermission(claims sso.Claims) (int32, bool, error) {
+	mapping := map[string][]string{}
+	if err := utils.Json.UnmarshalFromString(setting.GetStr(conf.SSORoleMapping, "{}"), &mapping); err != nil {
+		return 0, false, errors.Wrap(err, "invalid sso role mapping")
+	}
+	if len(mapping) == 0 {
+		return 0, false, nil
+	}
+	perm := model.DefaultPermission
+	for _, role := range claims.Strings(setting.GetStr(conf.SSORoleClaim, "groups")) {
+		p, err := model.ParsePermissions(mapping[role])
+		if err != nil {
+			return 0, false, errors.Wrapf(err, "invalid sso role mapping of %s", role)
+		}
+		perm |= p
+	}
+	return perm, true, nil
+}
+
+// syncSSOPermission 按角色映射同步权限，返回同步后的用户
+func syncSSOPermission(user *model.User, claims sso.Claims) (*model.User, error) {
+	if user.IsAdmin() {
+		return user, nil
+	}
+	perm, ok, err := ssoPermission(claims)
+	if err != nil || !ok || perm == user.Permission {
+		return user, err
+	}
+	u := *user
+	u.Permission = perm
+	if err := op.UpdateUserFields(&u, "permission"); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func registerSSOUser(claims sso.Claims) (*model.User, error) {
+	perm, ok, err := ssoPermission(claims)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		perm = model.DefaultPermission
+	}
+	user := &model.User{
+		Username:   ssoUsername(claims),
+		Role:       model.GENERAL,
+		Permission: perm,
+		SsoID:      claims.Subject(),
+	}
+	if err := op.CreateUser(user); err != nil {
+		return nil, err
+	}
+	log.Infof("created user %s for sso account %s", user.Username, user.SsoID)
+	return user, nil
+}
+
+// ssoUsername 依次尝试 preferred_username、邮箱前缀，已被占用时追加随机后缀
+func ssoUsername(claims sso.Claims) string {
+	name := claims.PreferredUsername()
+	if name == "" {
+		if email := claims.Email(); strings.Contains(email, "@") {
+			name = email[:strings.LastIndex(email, "@")]
+		}
+	}
+	if name == "" {
+		name = "sso"
+	}
+	candidate := name
+	for i := 0; i < 5; i++ {
+		if _, err := op.GetUserByName(candidate); errors.Is(err, errs.ErrUserNotFound) {
+			return candidate
+		}
+		candidate = name + "_" + strings.ToLower(random.String(4))
+	}
+	return name + "_" + strings.ToLower(random.String(8))
+}
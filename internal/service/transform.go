@@ -0,0 +1,250 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/request"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/storage"
+	"github.com/FXAZfung/image-board/pkg/imgmeta"
+	"github.com/FXAZfung/image-board/pkg/singleflight"
+	"github.com/FXAZfung/image-board/pkg/utils"
+	"github.com/chai2010/webp"
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	FitContain = "contain"
+	FitCover   = "cover"
+
+	FormatWebP = "webp"
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+)
+
+// TransformOptions 图片处理参数
+type TransformOptions struct {
+	Width   int    `json:"w"`
+	Height  int    `json:"h"`
+	Fit     string `json:"fit"`
+	Quality int    `json:"q"`
+	Format  string `json:"fmt"`
+}
+
+func (o TransformOptions) ext() string {
+	if o.Format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + o.Format
+}
+
+// cacheName 缓存文件名，由图片 hash 与处理参数组成
+func (o TransformOptions) cacheName(hash string) string {
+	return fmt.Sprintf("%s_%dx%d_%s_q%d%s", hash, o.Width, o.Height, o.Fit, o.Quality, o.ext())
+}
+
+// Tag 处理参数的唯一标识，用于生成 ETag
+func (o TransformOptions) Tag() string {
+	return fmt.Sprintf("%dx%d-%s-q%d-%s", o.Width, o.Height, o.Fit, o.Quality, o.Format)
+}
+
+func (o TransformOptions) validate() error {
+	if o.Width < 0 || o.Height < 0 || (o.Width == 0 && o.Height == 0) {
+		return fmt.Errorf("invalid size %dx%d", o.Width, o.Height)
+	}
+	if o.Fit != FitContain && o.Fit != FitCover {
+		return fmt.Errorf("invalid fit: %s", o.Fit)
+	}
+	if o.Quality < 1 || o.Quality > 100 {
+		return fmt.Errorf("invalid quality: %d", o.Quality)
+	}
+	switch o.Format {
+	case FormatWebP, FormatJPEG, FormatPNG:
+	default:
+		return fmt.Errorf("invalid format: %s", o.Format)
+	}
+	return nil
+}
+
+var (
+	presetsMu        sync.RWMutex
+	transformPresets map[string]TransformOptions
+)
+
+var variantG singleflight.Group[string]
+
+func init() {
+	op.RegisterSettingItemHook(config.ImageTransformPresets, func(item *model.SettingItem) error {
+		presets := make(map[string]TransformOptions)
+		if err := utils.Json.UnmarshalFromString(item.Value, &presets); err != nil {
+			return errors.WithStack(err)
+		}
+		for name, preset := range presets {
+			if preset.Fit == "" {
+				preset.Fit = FitContain
+			}
+			if err := preset.validate(); err != nil {
+				return errors.Wrapf(err, "preset %s", name)
+			}
+			presets[name] = preset
+		}
+		presetsMu.Lock()
+		transformPresets = presets
+		presetsMu.Unlock()
+		return nil
+	})
+}
+
+// ResolveTransform 将请求参数匹配到允许的预设，未匹配时返回 errs.ErrTransformDenied
+func ResolveTransform(req request.ImageTransformReq) (TransformOptions, error) {
+	presetsMu.RLock()
+	defer presetsMu.RUnlock()
+
+	if req.Preset != "" {
+		if preset, ok := transformPresets[req.Preset]; ok {
+			return preset, nil
+		}
+		return TransformOptions{}, errors.WithStack(errs.ErrTransformDenied)
+	}
+
+	format := strings.ToLower(req.Format)
+	if format == "jpg" {
+		format = FormatJPEG
+	}
+	names := make([]string, 0, len(transformPresets))
+	for name := range transformPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	// 宽高必须完全一致，其余参数未指定时使用预设值
+	for _, name := range names {
+		preset := transformPresets[name]
+		if req.Width != preset.Width || req.Height != preset.Height {
+			continue
+		}
+		if req.Fit != "" && req.Fit != preset.Fit {
+			continue
+		}
+		if req.Quality != 0 && req.Quality != preset.Quality {
+			continue
+		}
+		if format != "" && format != preset.Format {
+			continue
+		}
+		return preset, nil
+	}
+	return TransformOptions{}, errors.WithStack(errs.ErrTransformDenied)
+}
+
+// ThumbnailOptions 与上传时生成的缩略图尺寸一致的处理参数
+func ThumbnailOptions(format string) TransformOptions {
+	s := NewImageService()
+	return TransformOptions{Width: s.thumbnailWidth, Fit: FitContain, Quality: s.quality, Format: format}
+}
+
+func variantCacheDir(hash string) string {
+	return filepath.Join(config.Conf.DataImage.CacheDir, "variants", hash[:2])
+}
+
+// GetImageVariant 返回按 opts 处理后的图片缓存文件路径，缓存不存在时生成
+func GetImageVariant(img *model.Image, opts TransformOptions) (string, error) {
+	cachePath := filepath.Join(variantCacheDir(img.Hash), opts.cacheName(img.Hash))
+	if utils.IsExist(cachePath) {
+		return cachePath, nil
+	}
+
+	p, err, _ := variantG.Do(cachePath, func() (string, error) {
+		if utils.IsExist(cachePath) {
+			return cachePath, nil
+		}
+		processingSem <- struct{}{}
+		defer func() { <-processingSem }()
+
+		// 相同参数的并发请求共享同一次处理，不跟随单个请求的 context 取消
+		if err := createVariant(context.Background(), img, opts, cachePath); err != nil {
+			return "", err
+		}
+		return cachePath, nil
+	})
+	return p, err
+}
+
+func createVariant(ctx context.Context, img *model.Image, opts TransformOptions, cachePath string) error {
+	reader, _, err := storage.GetStorage().Get(ctx, img.Path)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	// 按 EXIF 方向转为正向，与上传时生成的缩略图一致
+	src, _, err := imgmeta.Decode(reader)
+	if err != nil {
+		return fmt.Errorf("variant decode failed: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := encodeVariant(&buf, transform(src, opts), opts); err != nil {
+		return fmt.Errorf("variant encode failed: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	tmpPath := cachePath + ".tmp"
+	if err := os.WriteFile(tmpPath, buf.Bytes(), 0644); err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(os.Rename(tmpPath, cachePath))
+}
+
+// transform 按参数缩放/裁剪图片，contain 模式不会放大原图
+func transform(src image.Image, opts TransformOptions) image.Image {
+	bounds := src.Bounds()
+	if opts.Fit == FitCover && opts.Width > 0 && opts.Height > 0 {
+		return imaging.Fill(src, opts.Width, opts.Height, imaging.Center, imaging.Lanczos)
+	}
+	if opts.Width > 0 && opts.Height > 0 {
+		return imaging.Fit(src, opts.Width, opts.Height, imaging.Lanczos)
+	}
+	if (opts.Width > 0 && bounds.Dx() <= opts.Width) || (opts.Height > 0 && bounds.Dy() <= opts.Height) {
+		return src
+	}
+	return imaging.Resize(src, opts.Width, opts.Height, imaging.Lanczos)
+}
+
+func encodeVariant(buf *bytes.Buffer, img image.Image, opts TransformOptions) error {
+	switch opts.Format {
+	case FormatWebP:
+		return webp.Encode(buf, img, &webp.Options{Quality: float32(opts.Quality)})
+	case FormatPNG:
+		return imaging.Encode(buf, img, imaging.PNG)
+	default:
+		return imaging.Encode(buf, img, imaging.JPEG, imaging.JPEGQuality(opts.Quality))
+	}
+}
+
+// RemoveImageVariants 删除图片的所有实时处理缓存
+func RemoveImageVariants(hash string) {
+	matches, err := filepath.Glob(filepath.Join(variantCacheDir(hash), hash+"_*"))
+	if err != nil {
+		return
+	}
+	for _, p := range matches {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			log.Warnf("failed to remove variant %s: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/setting"
+	"github.com/FXAZfung/image-board/pkg/random"
+	"github.com/FXAZfung/image-board/pkg/totp"
+	"github.com/pkg/errors"
+)
+
+const recoveryCodeCount = 10
+
+// 以下函数接收的 user 为缓存中的用户，均在副本上修改并只更新两步验证相关的字段，
+// 不会覆盖同时进行的权限、状态等修改
+
+// EnrollTwoFactor 为用户生成待验证的 TOTP 密钥，返回密钥与 otpauth 链接
+// 验证通过 EnableTwoFactor 后才会启用，重复调用会替换未启用的密钥
+func EnrollTwoFactor(user *model.User) (string, string, error) {
+	if user.IsGuest() {
+		return "", "", errors.WithStack(errs.ErrPermissionDenied)
+	}
+	if user.OtpEnabled {
+		return "", "", errors.WithStack(errs.ErrOtpAlreadyEnabled)
+	}
+	secret := totp.GenerateSecret()
+	u := *user
+	u.OtpSecret = secret
+	if err := op.UpdateUserFields(&u, "otp_secret"); err != nil {
+		return "", "", err
+	}
+	issuer := setting.GetStr(conf.SiteTitle, "image-board")
+	return secret, totp.URI(issuer, user.Username, secret), nil
+}
+
+// EnableTwoFactor 校验验证码后启用两步验证，返回一次性展示的恢复码
+func EnableTwoFactor(user *model.User, code string) ([]string, error) {
+	if user.OtpEnabled {
+		return nil, errors.WithStack(errs.ErrOtpAlreadyEnabled)
+	}
+	if user.OtpSecret == "" {
+		return nil, errors.WithStack(errs.ErrOtpNotEnrolled)
+	}
+	counter, ok := totp.Validate(user.OtpSecret, code, time.Now())
+	if !ok {
+		return nil, errors.WithStack(errs.ErrOtpCode)
+	}
+	codes, err := resetRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	u := *user
+	u.OtpEnabled = true
+	u.OtpLastCounter = counter
+	if err := op.UpdateUserFields(&u, "otp_enabled", "otp_last_counter"); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor 校验密码与验证码后关闭两步验证
+func DisableTwoFactor(user *model.User, password, code string) error {
+	if !user.OtpEnabled {
+		return errors.WithStack(errs.ErrOtpNotEnabled)
+	}
+	if err := verifyPassword(user, password); err != nil {
+		return err
+	}
+	if err := VerifyTwoFactor(user, code); err != nil {
+		return err
+	}
+	return ResetTwoFactor(user)
+}
+
+// verifyPassword 按用户的认证方式校验当前密码，没有本地密码的用户（SSO 创建）只需校验验证码
+func verifyPassword(user *model.User, password string) error {
+	switch {
+	case user.IsLdap() && setting.GetBool(conf.LDAPLoginEnabled):
+		return verifyLDAPPassword(user, password)
+	case user.PwdHash == "":
+		return nil
+	default:
+		return user.ValidatePwdStaticHash(password)
+	}
+}
+
+// RegenerateRecoveryCodes 校验验证码后重新生成恢复码，旧的恢复码全部失效
+func RegenerateRecoveryCodes(user *model.User, code string) ([]string, error) {
+	if !user.OtpEnabled {
+		return nil, errors.WithStack(errs.ErrOtpNotEnabled)
+	}
+	if err := VerifyTwoFactor(user, code); err != nil {
+		return nil, err
+	}
+	return resetRecoveryCodes(user.ID)
+}
+
+// VerifyTwoFactor 校验 TOTP 验证码或恢复码，同一验证码不能重复使用
+func VerifyTwoFactor(user *model.User, code string) error {
+	code = strings.TrimSpace(code)
+	if counter, ok := totp.Validate(user.OtpSecret, code, time.Now()); ok {
+		// 在数据库中比较并更新时间步，并发的请求不能同时使用同一验证码
+		used, err := op.UseOtpCounter(user, counter)
+		if err != nil {
+			return err
+		}
+		if !used {
+			return errors.WithStack(errs.ErrOtpCode)
+		}
+		return nil
+	}
+	ok, err := op.UseRecoveryCode(user.ID, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.WithStack(errs.ErrOtpCode)
+	}
+	return nil
+}
+
+// ResetTwoFactor 直接关闭用户的两步验证并删除恢复码
+func ResetTwoFactor(user *model.User) error {
+	u := *user
+	u.OtpEnabled = false
+	u.OtpSecret = ""
+	u.OtpLastCounter = 0
+	if err := op.UpdateUserFields(&u, "otp_enabled", "otp_secret", "otp_last_counter"); err != nil {
+		return err
+	}
+	return op.DeleteRecoveryCodes(user.ID)
+}
+
+func resetRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code := strings.ToLower(random.String(10))
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	if err := op.SetRecoveryCodes(userID, codes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
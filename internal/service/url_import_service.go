@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/fetch"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/request"
+	"github.com/FXAZfung/image-board/internal/setting"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// 链接中没有可用扩展名时根据内容类型补全
+var contentTypeExts = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+func fetchConfig() (fetch.Config, error) {
+	networks, err := fetch.ParseNetworks(setting.GetStr(conf.URLImportAllowedNetworks))
+	if err != nil {
+		return fetch.Config{}, err
+	}
+	return fetch.Config{
+		MaxSize:         int64(setting.GetInt(conf.URLImportMaxSize, 20)) << 20,
+		Timeout:         time.Duration(setting.GetInt(conf.URLImportTimeout, 30)) * time.Second,
+		AllowedNetworks: networks,
+	}, nil
+}
+
+// ImportImage 下载链接指向的图片并按普通上传处理
+func ImportImage(ctx context.Context, user *model.User, req request.ImportImageReq) (*model.Image, error) {
+	if err := CheckPermission(user, model.PermUpload); err != nil {
+		return nil, err
+	}
+	if !setting.GetBool(conf.URLImportEnabled) {
+		return nil, errors.WithStack(errs.ErrURLImportDisabled)
+	}
+	if err := validateStripLevel(req.StripMetadata); err != nil {
+		return nil, err
+	}
+	config, err := fetchConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+	logFields := log.Fields{
+		"user_id":   user.ID,
+		"url":       redactURL(req.URL),
+		"operation": "url_import",
+	}
+	defer func() {
+		log.WithFields(logFields).
+			WithField("duration", time.Since(startTime)).
+			Info("Import processing completed")
+	}()
+
+	resp, err := fetch.Get(ctx, config, req.URL)
+	if err != nil {
+		log.WithFields(logFields).Warnf("Import failed: %v", err)
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	name := resp.FileName
+	if ext := path.Ext(name); !NewImageService().isAllowedExtension(ext) {
+		if typeExt, ok := contentTypeExts[resp.ContentType]; ok {
+			name = strings.TrimSuffix(name, ext) + typeExt
+		}
+	}
+	logFields["file_name"] = name
+	source := uploadSource{
+		name:  name,
+		limit: config.MaxSize,
+		open: func() (io.ReadCloser, error) {
+			return resp.Body, nil
+		},
+	}
+	image, err := NewImageService().processUpload(source, user, uploadOptions{description: req.Description, stripMetadata: req.StripMetadata}, logFields)
+	if err != nil {
+		log.WithFields(logFields).Errorf("Import failed: %v", err)
+		return nil, err
+	}
+	return image, nil
+}
+
+// redactURL 日志中去掉链接里的用户信息与查询参数，避免记录签名等敏感内容
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
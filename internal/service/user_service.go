@@ -0,0 +1,157 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/request"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// 删除用户时对其图片的处理方式
+const (
+	UserImagesReassign = "reassign" // 转移给管理员
+	UserImagesDelete   = "delete"   // 一并删除
+)
+
+// canManage 非管理员只能管理权限不超过自己的普通用户，避免通过重置密码登录目标用户获得更多权限
+func canManage(operator, target *model.User) bool {
+	return operator.IsAdmin() || (!target.IsAdmin() && operator.HasPermission(target.Permission))
+}
+
+// DeleteUser 删除用户，mode 决定其上传的图片转移给管理员还是一并删除
+func DeleteUser(operator *model.User, id uint, mode string) error {
+	user, err := op.GetUserById(id)
+	if err != nil {
+		return err
+	}
+	if user.IsAdmin() || user.IsGuest() {
+		return errors.WithStack(errs.ErrBuiltinUserDelete)
+	}
+	if !canManage(operator, user) {
+		return errors.WithStack(errs.ErrPermissionDenied)
+	}
+
+	switch mode {
+	case "", UserImagesReassign:
+		admin, err := op.GetAdmin()
+		if err != nil {
+			return err
+		}
+		n, err := op.ReassignImages(id, admin.ID)
+		if err != nil {
+			return fmt.Errorf("failed to reassign images: %w", err)
+		}
+		log.Infof("reassigned %d images of user %s to %s", n, user.Username, admin.Username)
+	case UserImagesDelete:
+		images, err := op.GetImagesByUserID(id)
+		if err != nil {
+			return err
+		}
+		for _, image := range images {
+			if err := op.DeleteImage(image.ID); err != nil {
+				return fmt.Errorf("failed to delete image %d: %w", image.ID, err)
+			}
+			phashIndex.Remove(image.ID)
+			go removeImageFiles(image)
+		}
+		// 图片删除会修改标签计数
+		op.TagCacheUpdate()
+		log.Infof("deleted %d images of user %s", len(images), user.Username)
+	default:
+		return errors.Errorf("invalid images mode: %s", mode)
+	}
+
+	if err := op.RevokeUserSessions(id, ""); err != nil {
+		return err
+	}
+	if err := op.DeleteUserAPIKeys(id); err != nil {
+		return err
+	}
+	if err := op.DeleteRecoveryCodes(id); err != nil {
+		return err
+	}
+	if err := removeUserUploads(id); err != nil {
+		return err
+	}
+	return op.DeleteUser(id)
+}
+
+// ChangePassword 校验旧密码后修改密码，并撤销除 keepSession 外的全部会话
+func ChangePassword(user *model.User, oldPassword, newPassword, keepSession string) error {
+	if user.IsGuest() {
+		return errors.WithStack(errs.ErrPermissionDenied)
+	}
+	if err := user.ValidatePwdStaticHash(oldPassword); err != nil {
+		return err
+	}
+	// user 为缓存中的用户，在副本上修改，保存失败时缓存不受影响
+	u := *user
+	u.SetPassword(newPassword)
+	if err := op.UpdateUserFields(&u, "pwd_hash"); err != nil {
+		return err
+	}
+	return op.RevokeUserSessions(u.ID, keepSession)
+}
+
+// UpdateUser 修改用户的密码、状态、角色与权限
+// 全部校验通过后才在副本上修改并保存，缓存中的用户不会出现未保存的修改
+// 重置密码或禁用后撤销其全部会话与 API 密钥
+func UpdateUser(operator *model.User, id uint, req request.UpdateUserReq) (*model.User, error) {
+	target, err := op.GetUserById(id)
+	if err != nil {
+		return nil, err
+	}
+	if !canManage(operator, target) {
+		return nil, errors.WithStack(errs.ErrPermissionDenied)
+	}
+
+	// 授予/撤销权限，只能操作自己拥有的权限
+	grant, err := model.ParsePermissions(req.Grant)
+	if err != nil {
+		return nil, err
+	}
+	revoke, err := model.ParsePermissions(req.Revoke)
+	if err != nil {
+		return nil, err
+	}
+	if !operator.HasPermission(grant | revoke) {
+		return nil, errors.WithStack(errs.ErrPermissionDenied)
+	}
+
+	// 只有管理员能更改角色，管理员与游客均只存在一个，不能授予或更改
+	if req.Role != nil && operator.IsAdmin() && *req.Role != target.Role {
+		if *req.Role != model.GENERAL || target.IsAdmin() || target.IsGuest() {
+			return nil, errors.WithStack(errs.ErrInvalidRole)
+		}
+	}
+
+	user := *target
+	if req.Password != "" {
+		user.SetPassword(req.Password)
+	}
+	if req.Disable != nil {
+		user.Disabled = *req.Disable
+	}
+	if req.Role != nil && operator.IsAdmin() {
+		user.Role = *req.Role
+	}
+	user.Grant(grant)
+	user.Revoke(revoke)
+	if err := op.UpdateUser(&user); err != nil {
+		return nil, err
+	}
+
+	if req.Password != "" || user.Disabled {
+		if err := op.RevokeUserSessions(user.ID, ""); err != nil {
+			return nil, err
+		}
+		if err := op.DeleteUserAPIKeys(user.ID); err != nil {
+			return nil, err
+		}
+	}
+	return &user, nil
+}
@@ -2,14 +2,28 @@ package sign
 
 import (
 	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
 	"github.com/FXAZfung/image-board/internal/setting"
 	"github.com/FXAZfung/image-board/pkg/sign"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
-var once sync.Once
-var instance sign.Sign
+var (
+	mu       sync.RWMutex
+	instance sign.Sign
+)
+
+func init() {
+	// 签名密钥独立于管理员 Token，修改后立即生效
+	op.RegisterSettingItemHook(conf.SignSecret, func(item *model.SettingItem) error {
+		setSecret(item.Value)
+		return nil
+	})
+}
 
 func Sign(data string) string {
 	expire := setting.GetInt(conf.LinkExpiration, 0)
@@ -21,20 +35,40 @@ func Sign(data string) string {
 }
 
 func WithDuration(data string, d time.Duration) string {
-	once.Do(Instance)
-	return instance.Sign(data, time.Now().Add(d).Unix())
+	return getInstance().Sign(data, time.Now().Add(d).Unix())
 }
 
 func NotExpired(data string) string {
-	once.Do(Instance)
-	return instance.Sign(data, 0)
+	return getInstance().Sign(data, 0)
 }
 
 func Verify(data string, sign string) error {
-	once.Do(Instance)
-	return instance.Verify(data, sign)
+	return getInstance().Verify(data, sign)
 }
 
 func Instance() {
-	instance = sign.NewHMACSign([]byte(setting.GetStr(conf.Token)))
+	setSecret(setting.GetStr(conf.SignSecret))
+}
+
+func setSecret(secret string) {
+	mu.Lock()
+	defer mu.Unlock()
+	instance = sign.NewHMACSign([]byte(secret))
+}
+
+func getInstance() sign.Sign {
+	mu.RLock()
+	s := instance
+	mu.RUnlock()
+	if s == nil {
+		Instance()
+		return getInstance()
+	}
+	return s
+}
+
+// ExpireAt 返回签名中的过期时间戳，0 表示永不过期
+func ExpireAt(s string) int64 {
+	expire, _ := strconv.ParseInt(s[strings.LastIndex(s, ":")+1:], 10, 64)
+	return expire
 }
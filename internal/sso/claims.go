@@ -0,0 +1,61 @@
+package sso
+
+import "strings"
+
+// Claims id_token 中的声明
+type Claims map[string]interface{}
+
+func (c Claims) str(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+func (c Claims) Subject() string {
+	return c.str("sub")
+}
+
+func (c Claims) Email() string {
+	return c.str("email")
+}
+
+// EmailVerified 未提供 email_verified 声明时视为已验证
+func (c Claims) EmailVerified() bool {
+	switch v := c["email_verified"].(type) {
+	case bool:
+		return v
+	case string:
+		return v != "false"
+	default:
+		return true
+	}
+}
+
+func (c Claims) PreferredUsername() string {
+	return c.str("preferred_username")
+}
+
+// Strings 以字符串列表形式获取声明，支持字符串、字符串数组及以点分隔的嵌套路径
+func (c Claims) Strings(path string) []string {
+	var v interface{} = map[string]interface{}(c)
+	for _, key := range strings.Split(path, ".") {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[key]
+	}
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,316 @@
+// Package sso 实现 OpenID Connect 授权码模式（PKCE）登录所需的客户端逻辑
+package sso
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FXAZfung/image-board/pkg/random"
+	"github.com/FXAZfung/image-board/pkg/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/pkg/errors"
+)
+
+// Config OIDC 客户端配置
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+// Provider 通过 issuer 发现得到的 OIDC 提供方
+type Provider struct {
+	config    Config
+	discovery discovery
+	client    *http.Client
+
+	keysMu      sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+// jwks 刷新的最小间隔，避免未知 kid 的令牌频繁触发请求
+const jwksRefreshInterval = time.Minute
+
+var signingMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// NewProvider 读取 issuer 的 .well-known/openid-configuration 创建提供方
+func NewProvider(ctx context.Context, config Config) (*Provider, error) {
+	p := &Provider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	wellKnown := strings.TrimSuffix(config.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &p.discovery); err != nil {
+		return nil, errors.Wrap(err, "failed to discover oidc provider")
+	}
+	if strings.TrimSuffix(p.discovery.Issuer, "/") != strings.TrimSuffix(config.Issuer, "/") {
+		return nil, errors.Errorf("issuer mismatch: expected %s, got %s", config.Issuer, p.discovery.Issuer)
+	}
+	if p.discovery.AuthorizationEndpoint == "" || p.discovery.TokenEndpoint == "" || p.discovery.JwksURI == "" {
+		return nil, errors.New("incomplete oidc discovery document")
+	}
+	return p, nil
+}
+
+// Config 提供方使用的客户端配置
+func (p *Provider) Config() Config {
+	return p.config
+}
+
+// NewVerifier 生成 PKCE code_verifier
+func NewVerifier() string {
+	return random.String(64)
+}
+
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthURL 生成跳转到提供方的授权链接
+func (p *Provider) AuthURL(state, nonce, verifier string) string {
+	v := url.Values{}
+	v.Set("response_type", "code")
+	v.Set("client_id", p.config.ClientID)
+	v.Set("redirect_uri", p.config.RedirectURL)
+	v.Set("scope", strings.Join(p.config.Scopes, " "))
+	v.Set("state", state)
+	v.Set("nonce", nonce)
+	v.Set("code_challenge", codeChallenge(verifier))
+	v.Set("code_challenge_method", "S256")
+	sep := "?"
+	if strings.Contains(p.discovery.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.discovery.AuthorizationEndpoint + sep + v.Encode()
+}
+
+// Exchange 使用授权码换取 id_token
+func (p *Provider) Exchange(ctx context.Context, code, verifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", verifier)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	var resp struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := p.doJSON(req, &resp); err != nil {
+		return "", errors.Wrap(err, "failed to exchange code")
+	}
+	if resp.Error != "" {
+		return "", errors.Errorf("failed to exchange code: %s %s", resp.Error, resp.ErrorDescription)
+	}
+	if resp.IDToken == "" {
+		return "", errors.New("no id_token in token response")
+	}
+	return resp.IDToken, nil
+}
+
+// Verify 校验 id_token 的签名、issuer、audience、有效期与 nonce
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (Claims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.key(ctx, kid)
+	},
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(p.discovery.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid id_token")
+	}
+	if got, _ := claims["nonce"].(string); got != nonce {
+		return nil, errors.New("invalid id_token: nonce mismatch")
+	}
+	if Claims(claims).Subject() == "" {
+		return nil, errors.New("invalid id_token: missing sub")
+	}
+	return Claims(claims), nil
+}
+
+// key 根据 kid 获取签名公钥，未找到时刷新 jwks
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.keysMu.RLock()
+	key, ok := p.lookupKey(kid)
+	fresh := time.Since(p.keysFetched) < jwksRefreshInterval
+	p.keysMu.RUnlock()
+	if ok {
+		return key, nil
+	}
+	if fresh {
+		return nil, errors.Errorf("unknown key id: %s", kid)
+	}
+
+	p.keysMu.Lock()
+	defer p.keysMu.Unlock()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	keys, err := p.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetched = time.Now()
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, errors.Errorf("unknown key id: %s", kid)
+}
+
+// lookupKey 令牌未指定 kid 且只有一个公钥时直接使用该公钥
+func (p *Provider) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := p.getJSON(ctx, p.discovery.JwksURI, &set); err != nil {
+		return nil, errors.Wrap(err, "failed to fetch jwks")
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			// 忽略不支持的密钥类型
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	req.Header.Set("Accept", "application/json")
+	return p.doJSON(req, v)
+}
+
+func (p *Provider) doJSON(req *http.Request, v interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	// 令牌端点的错误响应同样是 JSON，交由调用方处理
+	if resp.StatusCode >= 500 || (resp.StatusCode >= 300 && !isJSON(resp)) {
+		return errors.Errorf("unexpected status %d from %s", resp.StatusCode, req.URL.Host)
+	}
+	return errors.WithStack(utils.Json.Unmarshal(body, v))
+}
+
+func isJSON(resp *http.Response) bool {
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json")
+}
@@ -0,0 +1,192 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeProvider 进程内的 OIDC 提供方，授权码直接对应待签发的声明
+type fakeProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	codes  map[string]jwt.MapClaims
+	// 授权码对应的 PKCE challenge
+	challenges map[string]string
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeProvider{key: key, codes: map[string]jwt.MapClaims{}, challenges: map[string]string{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{
+			"issuer":                 f.server.URL,
+			"authorization_endpoint": f.server.URL + "/authorize",
+			"token_endpoint":         f.server.URL + "/token",
+			"jwks_uri":               f.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+			"kid": "k1",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		code := r.FormValue("code")
+		claims, ok := f.codes[code]
+		if id != "client" || secret != "secret" || !ok || codeChallenge(r.FormValue("code_verifier")) != f.challenges[code] {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]string{"error": "invalid_grant"})
+			return
+		}
+		delete(f.codes, code)
+		writeJSON(w, map[string]string{"id_token": f.sign(t, claims)})
+	})
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeProvider) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	s, err := token.SignedString(f.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// authorize 模拟用户在提供方完成登录，返回授权码
+func (f *fakeProvider) authorize(t *testing.T, authURL string, claims jwt.MapClaims) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	claims["nonce"] = q.Get("nonce")
+	code := "code-" + q.Get("state")
+	f.codes[code] = claims
+	f.challenges[code] = q.Get("code_challenge")
+	return code
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeProvider) claims(sub string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":                f.server.URL,
+		"aud":                "client",
+		"sub":                sub,
+		"iat":                now.Unix(),
+		"exp":                now.Add(time.Hour).Unix(),
+		"email":              sub + "@example.com",
+		"preferred_username": sub,
+		"groups":             []string{"editors"},
+	}
+}
+
+func newTestProvider(t *testing.T, f *fakeProvider) *Provider {
+	p, err := NewProvider(context.Background(), Config{
+		Issuer:       f.server.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/callback",
+		Scopes:       []string{"openid", "email"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoginFlow(t *testing.T) {
+	f := newFakeProvider(t)
+	p := newTestProvider(t, f)
+	ctx := context.Background()
+
+	verifier := NewVerifier()
+	code := f.authorize(t, p.AuthURL("s1", "n1", verifier), f.claims("alice"))
+	raw, err := p.Exchange(ctx, code, verifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := p.Verify(ctx, raw, "n1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject() != "alice" || claims.Email() != "alice@example.com" || !claims.EmailVerified() {
+		t.Errorf("unexpected claims: %v", claims)
+	}
+	if groups := claims.Strings("groups"); len(groups) != 1 || groups[0] != "editors" {
+		t.Errorf("Strings(groups) = %v", groups)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	f := newFakeProvider(t)
+	p := newTestProvider(t, f)
+	code := f.authorize(t, p.AuthURL("s1", "n1", NewVerifier()), f.claims("alice"))
+	if _, err := p.Exchange(context.Background(), code, NewVerifier()); err == nil {
+		t.Error("Exchange() succeeded with a wrong code_verifier")
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	f := newFakeProvider(t)
+	p := newTestProvider(t, f)
+	ctx := context.Background()
+
+	valid := f.claims("alice")
+	valid["nonce"] = "n1"
+	if _, err := p.Verify(ctx, f.sign(t, valid), "n2"); err == nil {
+		t.Error("Verify() accepted a nonce mismatch")
+	}
+
+	tests := map[string]func(jwt.MapClaims){
+		"audience": func(c jwt.MapClaims) { c["aud"] = "other" },
+		"issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"expired":  func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+	}
+	for name, mutate := range tests {
+		claims := f.claims("alice")
+		claims["nonce"] = "n1"
+		mutate(claims)
+		if _, err := p.Verify(ctx, f.sign(t, claims), "n1"); err == nil {
+			t.Errorf("Verify() accepted a token with invalid %s", name)
+		}
+	}
+
+	// 非提供方密钥签名
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, valid)
+	token.Header["kid"] = "k1"
+	forged, _ := token.SignedString(other)
+	if _, err := p.Verify(ctx, forged, "n1"); err == nil {
+		t.Error("Verify() accepted a token signed by an unknown key")
+	}
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/pkg/errors"
+)
+
+// Local 本地磁盘存储，key 直接映射为 root 下的相对路径
+type Local struct {
+	root string
+}
+
+func NewLocal(root string) *Local {
+	return &Local{root: root}
+}
+
+// fullPath 将 key 转换为磁盘路径，".." 不会越过 root
+func (l *Local) fullPath(key string) string {
+	return filepath.Join(l.root, filepath.FromSlash(path.Clean("/"+key)))
+}
+
+func (l *Local) object(key string, info fs.FileInfo) *Object {
+	return &Object{
+		Key:         key,
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+		ContentType: mime.TypeByExtension(path.Ext(key)),
+	}
+}
+
+func (l *Local) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p := l.fullPath(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return errors.WithStack(err)
+	}
+	// 先写入临时文件再重命名，避免读到写了一半的文件，同一 key 的并发写入各自使用不同的临时文件
+	f, err := os.CreateTemp(filepath.Dir(p), filepath.Base(p)+".*.tmp")
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	tmpPath := f.Name()
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return errors.WithStack(err)
+	}
+	// CreateTemp 创建的文件只有所有者可读，与直接创建的文件保持一致
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return errors.WithStack(err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return errors.WithStack(err)
+	}
+	if err := os.Rename(tmpPath, p); err != nil {
+		os.Remove(tmpPath)
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadSeekCloser, *Object, error) {
+	f, err := os.Open(l.fullPath(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, errors.WithStack(errs.ObjectNotFound)
+		}
+		return nil, nil, errors.WithStack(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, errors.WithStack(err)
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, nil, errors.WithStack(errs.NotFile)
+	}
+	return f, l.object(key, info), nil
+}
+
+func (l *Local) Stat(ctx context.Context, key string) (*Object, error) {
+	info, err := os.Stat(l.fullPath(key))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.WithStack(errs.ObjectNotFound)
+		}
+		return nil, errors.WithStack(err)
+	}
+	if info.IsDir() {
+		return nil, errors.WithStack(errs.NotFile)
+	}
+	return l.object(key, info), nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	if err := os.Remove(l.fullPath(key)); err != nil && !os.IsNotExist(err) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (l *Local) List(ctx context.Context, prefix string) ([]*Object, error) {
+	// 从 prefix 所在的目录开始遍历
+	dir := prefix
+	if !strings.HasSuffix(dir, "/") {
+		dir = path.Dir(dir)
+	}
+	var objects []*Object
+	err := filepath.WalkDir(l.fullPath(dir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(p, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(l.root, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, l.object(key, info))
+		return nil
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return objects, nil
+}
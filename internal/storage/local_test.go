@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestLocalPutConcurrent 同一 key 的并发写入不会互相覆盖临时文件，最终内容为某一次完整的写入
+func TestLocalPutConcurrent(t *testing.T) {
+	root := t.TempDir()
+	l := NewLocal(root)
+	ctx := context.Background()
+
+	contents := make([][]byte, 8)
+	for i := range contents {
+		contents[i] = bytes.Repeat([]byte{byte('a' + i)}, 256*1024)
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(contents))
+	for i, b := range contents {
+		wg.Add(1)
+		go func(i int, b []byte) {
+			defer wg.Done()
+			errs[i] = l.Put(ctx, "a/b.jpg", bytes.NewReader(b), int64(len(b)), "image/jpeg")
+		}(i, b)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Put %d: %v", i, err)
+		}
+	}
+
+	r, obj, err := l.Get(ctx, "a/b.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(r)
+	r.Close()
+	if obj.Size != int64(len(got)) || len(got) != 256*1024 || !bytes.Equal(got, bytes.Repeat(got[:1], len(got))) {
+		t.Fatalf("content is mixed or truncated, size=%d", len(got))
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("temp files left: %v", entries)
+	}
+	info, _ := entries[0].Info()
+	if info.Mode().Perm() != 0644 {
+		t.Fatalf("mode = %v, want 0644", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/pkg/errors"
+)
+
+// Presigner 支持生成预签名下载链接的存储后端
+type Presigner interface {
+	PresignGet(ctx context.Context, key string, expire time.Duration) (string, error)
+}
+
+type S3Options struct {
+	Endpoint  string
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	PathStyle bool
+	RootPath  string
+}
+
+// S3 兼容 S3 协议的对象存储（AWS S3、MinIO、R2 等）
+type S3 struct {
+	client *minio.Client
+	bucket string
+	root   string
+}
+
+func NewS3(opts S3Options) (*S3, error) {
+	lookup := minio.BucketLookupAuto
+	if opts.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(opts.Endpoint, &minio.Options{
+		Creds:        credentials.NewStaticV4(opts.AccessKey, opts.SecretKey, ""),
+		Secure:       opts.UseSSL,
+		Region:       opts.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &S3{
+		client: client,
+		bucket: opts.Bucket,
+		root:   strings.Trim(opts.RootPath, "/"),
+	}, nil
+}
+
+// objectName 将 key 转换为桶内的对象名
+func (s *S3) objectName(key string) string {
+	key = strings.TrimPrefix(key, "/")
+	if s.root == "" {
+		return key
+	}
+	return s.root + "/" + key
+}
+
+func (s *S3) keyOf(name string) string {
+	if s.root == "" {
+		return name
+	}
+	return strings.TrimPrefix(name, s.root+"/")
+}
+
+func (s *S3) object(info minio.ObjectInfo) *Object {
+	return &Object{
+		Key:         s.keyOf(info.Key),
+		Size:        info.Size,
+		ModTime:     info.LastModified,
+		ContentType: info.ContentType,
+	}
+}
+
+func convertS3Err(err error) error {
+	resp := minio.ToErrorResponse(err)
+	if resp.StatusCode == http.StatusNotFound || resp.Code == "NoSuchKey" {
+		return errors.WithStack(errs.ObjectNotFound)
+	}
+	return errors.WithStack(err)
+}
+
+func (s *S3) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, s.objectName(key), r, size, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	return errors.WithStack(err)
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadSeekCloser, *Object, error) {
+	obj, err := s.client.GetObject(ctx, s.bucket, s.objectName(key), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, convertS3Err(err)
+	}
+	// GetObject 是惰性的，先 Stat 一次以便尽早发现对象不存在
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, nil, convertS3Err(err)
+	}
+	return obj, s.object(info), nil
+}
+
+func (s *S3) Stat(ctx context.Context, key string) (*Object, error) {
+	info, err := s.client.StatObject(ctx, s.bucket, s.objectName(key), minio.StatObjectOptions{})
+	if err != nil {
+		return nil, convertS3Err(err)
+	}
+	return s.object(info), nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	err := s.client.RemoveObject(ctx, s.bucket, s.objectName(key), minio.RemoveObjectOptions{})
+	if err != nil && !errs.IsObjectNotFound(convertS3Err(err)) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]*Object, error) {
+	var objects []*Object
+	for info := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{
+		Prefix:    s.objectName(prefix),
+		Recursive: true,
+	}) {
+		if info.Err != nil {
+			return nil, errors.WithStack(info.Err)
+		}
+		objects = append(objects, s.object(info))
+	}
+	return objects, nil
+}
+
+func (s *S3) PresignGet(ctx context.Context, key string, expire time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, s.objectName(key), expire, nil)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	return u.String(), nil
+}
@@ -0,0 +1,206 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FXAZfung/image-board/internal/errs"
+)
+
+// fakeS3 一个只实现了基本对象操作的进程内 S3
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+}
+
+// decodeChunked 解码 aws-chunked 流式签名上传的请求体
+func decodeChunked(r io.Reader) ([]byte, error) {
+	br := bufio.NewReader(r)
+	var out bytes.Buffer
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		sizeHex := strings.SplitN(strings.TrimSpace(line), ";", 2)[0]
+		size, err := strconv.ParseInt(sizeHex, 16, 64)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			return out.Bytes(), nil
+		}
+		if _, err := io.CopyN(&out, br, size); err != nil {
+			return nil, err
+		}
+		if _, err := br.Discard(2); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	// path-style: /bucket/key
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	key := ""
+	if len(parts) == 2 {
+		key = parts[1]
+	}
+	notFound := func() {
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusNotFound)
+		if r.Method != http.MethodHead {
+			fmt.Fprintf(w, `<Error><Code>NoSuchKey</Code><Message>not found</Message><Key>%s</Key></Error>`, key)
+		}
+	}
+	switch {
+	case r.Method == http.MethodPut:
+		var data []byte
+		var err error
+		if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+			data, err = decodeChunked(r.Body)
+		} else {
+			data, err = io.ReadAll(r.Body)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = data
+		f.types[key] = r.Header.Get("Content-Type")
+		w.Header().Set("ETag", `"etag"`)
+	case r.Method == http.MethodGet && key == "":
+		prefix := r.URL.Query().Get("prefix")
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		var sb strings.Builder
+		sb.WriteString(`<ListBucketResult><Name>bucket</Name><IsTruncated>false</IsTruncated>`)
+		for _, k := range keys {
+			fmt.Fprintf(&sb, `<Contents><Key>%s</Key><Size>%d</Size><LastModified>2024-01-01T00:00:00.000Z</LastModified></Contents>`, k, len(f.objects[k]))
+		}
+		sb.WriteString(`</ListBucketResult>`)
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(sb.String()))
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		data, ok := f.objects[key]
+		if !ok {
+			notFound()
+			return
+		}
+		w.Header().Set("Content-Type", f.types[key])
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("ETag", `"etag"`)
+		http.ServeContent(w, r, key, time.Time{}, bytes.NewReader(data))
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestS3(t *testing.T) (*S3, *fakeS3) {
+	fake := newFakeS3()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	s, err := NewS3(S3Options{
+		Endpoint:  u.Host,
+		Bucket:    "bucket",
+		Region:    "us-east-1",
+		AccessKey: "access",
+		SecretKey: "secret",
+		PathStyle: true,
+		RootPath:  "/images/",
+	})
+	if err != nil {
+		t.Fatalf("NewS3() error = %v", err)
+	}
+	return s, fake
+}
+
+func TestS3PutGetDelete(t *testing.T) {
+	s, fake := newTestS3(t)
+	ctx := context.Background()
+	data := []byte("hello image")
+
+	if err := s.Put(ctx, "2024/01/a.jpg", bytes.NewReader(data), int64(len(data)), "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if _, ok := fake.objects["images/2024/01/a.jpg"]; !ok {
+		t.Fatalf("object not stored under root path, got %v", fake.objects)
+	}
+
+	r, obj, err := s.Get(ctx, "2024/01/a.jpg")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	got, _ := io.ReadAll(r)
+	r.Close()
+	if !bytes.Equal(got, data) {
+		t.Errorf("Get() = %q, want %q", got, data)
+	}
+	if obj.Key != "2024/01/a.jpg" || obj.Size != int64(len(data)) || obj.ContentType != "image/jpeg" {
+		t.Errorf("Get() object = %+v", obj)
+	}
+
+	objects, err := s.List(ctx, "2024/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "2024/01/a.jpg" {
+		t.Errorf("List() = %+v", objects)
+	}
+
+	if err := s.Delete(ctx, "2024/01/a.jpg"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := s.Stat(ctx, "2024/01/a.jpg"); !errs.IsObjectNotFound(err) {
+		t.Errorf("Stat() after delete error = %v, want ObjectNotFound", err)
+	}
+	if _, _, err := s.Get(ctx, "2024/01/a.jpg"); !errs.IsObjectNotFound(err) {
+		t.Errorf("Get() after delete error = %v, want ObjectNotFound", err)
+	}
+}
+
+func TestS3PresignGet(t *testing.T) {
+	s, _ := newTestS3(t)
+	raw, err := s.PresignGet(context.Background(), "2024/01/a.jpg", time.Minute)
+	if err != nil {
+		t.Fatalf("PresignGet() error = %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("invalid presigned url %q", raw)
+	}
+	if u.Path != "/bucket/images/2024/01/a.jpg" {
+		t.Errorf("presigned path = %s", u.Path)
+	}
+	if u.Query().Get("X-Amz-Expires") != "60" || u.Query().Get("X-Amz-Signature") == "" {
+		t.Errorf("presigned query = %s", u.RawQuery)
+	}
+}
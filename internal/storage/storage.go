@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Object 存储对象的元信息
+type Object struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ModTime     time.Time `json:"mod_time"`
+	ContentType string    `json:"content_type"`
+}
+
+// Storage 图片存储后端
+// key 为以 "/" 分隔的相对路径，例如 "2024/05/<hash>.jpg"，与具体后端无关
+type Storage interface {
+	// Put 写入对象，size 未知时传 -1
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，调用方负责关闭返回的 reader
+	Get(ctx context.Context, key string) (io.ReadSeekCloser, *Object, error)
+	// Stat 获取对象信息，对象不存在时返回 errs.ObjectNotFound
+	Stat(ctx context.Context, key string) (*Object, error)
+	// Delete 删除对象，对象不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+	// List 列出所有以 prefix 开头的对象
+	List(ctx context.Context, prefix string) ([]*Object, error)
+}
+
+var storage Storage
+
+func Init(s Storage) {
+	storage = s
+}
+
+func GetStorage() Storage {
+	return storage
+}
@@ -0,0 +1,61 @@
+package imghash
+
+import (
+	"encoding/hex"
+	"image"
+
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+)
+
+const histogramBins = 4 // 每个颜色通道的分段数
+
+// Histogram 颜色直方图，RGB 每个通道分为 4 段共 64 格，每格为像素占比乘以 255
+type Histogram [histogramBins * histogramBins * histogramBins]uint8
+
+// ColorHistogram 缩小后统计颜色分布，忽略完全透明的像素
+func ColorHistogram(img image.Image) Histogram {
+	small := imaging.Resize(img, 64, 64, imaging.Box)
+	var counts [len(Histogram{})]int
+	total := 0
+	for i := 0; i < len(small.Pix); i += 4 {
+		if small.Pix[i+3] == 0 {
+			continue
+		}
+		r, g, b := int(small.Pix[i])*histogramBins/256, int(small.Pix[i+1])*histogramBins/256, int(small.Pix[i+2])*histogramBins/256
+		counts[(r*histogramBins+g)*histogramBins+b]++
+		total++
+	}
+	var h Histogram
+	if total == 0 {
+		return h
+	}
+	for i, c := range counts {
+		h[i] = uint8((c*255 + total/2) / total)
+	}
+	return h
+}
+
+// Similarity 直方图交集，0 表示颜色完全不同，1 表示分布相同
+func (h Histogram) Similarity(o Histogram) float64 {
+	var sum int
+	for i := range h {
+		sum += int(min(h[i], o[i]))
+	}
+	return min(float64(sum)/255, 1)
+}
+
+// FormatHistogram 格式化为 128 位十六进制字符串
+func FormatHistogram(h Histogram) string {
+	return hex.EncodeToString(h[:])
+}
+
+// ParseHistogram 解析 FormatHistogram 的结果
+func ParseHistogram(s string) (Histogram, error) {
+	var h Histogram
+	if hex.DecodedLen(len(s)) != len(h) {
+		return h, errors.Errorf("invalid histogram length %d", len(s))
+	}
+	_, err := hex.Decode(h[:], []byte(s))
+	return h, errors.WithStack(err)
+}
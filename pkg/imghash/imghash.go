@@ -0,0 +1,46 @@
+// Package imghash 计算图片的感知哈希（dHash），内容相近的图片哈希的汉明距离较小
+package imghash
+
+import (
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+
+	"github.com/disintegration/imaging"
+)
+
+// DHash 缩放为 9x8 的灰度图，每行相邻像素左侧较亮时记为 1，共 64 位
+func DHash(img image.Image) uint64 {
+	small := imaging.Resize(img, 9, 8, imaging.Box)
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if luminance(small, x, y) > luminance(small, x+1, y) {
+				hash |= 1 << uint(y*8+x)
+			}
+		}
+	}
+	return hash
+}
+
+func luminance(img *image.NRGBA, x, y int) uint32 {
+	i := img.PixOffset(x, y)
+	r, g, b := uint32(img.Pix[i]), uint32(img.Pix[i+1]), uint32(img.Pix[i+2])
+	return 299*r + 587*g + 114*b
+}
+
+// Distance 两个哈希的汉明距离，0 ~ 64
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Format 格式化为 16 位十六进制字符串
+func Format(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+// Parse 解析 Format 的结果
+func Parse(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
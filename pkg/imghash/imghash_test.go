@@ -0,0 +1,81 @@
+package imghash
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// gradient 生成带有对角渐变与色块的测试图片，seed 不同时内容不同
+func gradient(w, h, seed int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8((x*255/w + y*seed*255/h) % 256)
+			if (x*4/w+y*3/h+seed)%2 == 0 {
+				v = 255 - v
+			}
+			img.Set(x, y, color.NRGBA{R: v, G: v / 2, B: 255 - v, A: 255})
+		}
+	}
+	return img
+}
+
+func TestDHash(t *testing.T) {
+	src := gradient(640, 480, 1)
+	hash := DHash(src)
+
+	similar := map[string]image.Image{
+		"identical": src,
+		"resized":   imaging.Resize(src, 200, 0, imaging.Lanczos),
+		"blurred":   imaging.Blur(src, 1),
+		"brighter":  imaging.AdjustBrightness(src, 10),
+	}
+	for name, img := range similar {
+		if d := Distance(hash, DHash(img)); d > 6 {
+			t.Errorf("%s: distance = %d, want <= 6", name, d)
+		}
+	}
+
+	different := gradient(640, 480, 3)
+	if d := Distance(hash, DHash(different)); d < 15 {
+		t.Errorf("different image: distance = %d, want >= 15", d)
+	}
+}
+
+func TestFormatParse(t *testing.T) {
+	for _, hash := range []uint64{0, 1, 0xdeadbeefcafebabe, ^uint64(0)} {
+		s := Format(hash)
+		if len(s) != 16 {
+			t.Errorf("Format(%x) = %q, want 16 characters", hash, s)
+		}
+		got, err := Parse(s)
+		if err != nil || got != hash {
+			t.Errorf("Parse(%q) = %x, %v, want %x", s, got, err, hash)
+		}
+	}
+	if d := Distance(0, ^uint64(0)); d != 64 {
+		t.Errorf("Distance = %d, want 64", d)
+	}
+}
+
+func TestColorHistogram(t *testing.T) {
+	src := gradient(640, 480, 1)
+	h := ColorHistogram(src)
+	if s := h.Similarity(ColorHistogram(imaging.Resize(src, 200, 0, imaging.Lanczos))); s < 0.9 {
+		t.Errorf("resized: similarity = %.2f, want >= 0.9", s)
+	}
+	if s := h.Similarity(ColorHistogram(imaging.Invert(src))); s > 0.5 {
+		t.Errorf("inverted: similarity = %.2f, want <= 0.5", s)
+	}
+
+	got, err := ParseHistogram(FormatHistogram(h))
+	if err != nil || got != h {
+		t.Errorf("ParseHistogram = %v, %v, want %v", got, err, h)
+	}
+	if _, err := ParseHistogram("abc"); err == nil {
+		t.Error("ParseHistogram(abc) error = nil")
+	}
+}
@@ -0,0 +1,153 @@
+package imghash
+
+import (
+	"sort"
+	"sync"
+)
+
+// Match 查询结果
+type Match struct {
+	ID       uint
+	Hash     uint64
+	Distance int
+}
+
+// Index 基于 BK 树的感知哈希索引，查询时只访问距离可能满足条件的节点，可并发使用
+type Index struct {
+	mu     sync.RWMutex
+	root   *node
+	hashes map[uint]uint64
+}
+
+// node 相同哈希的图片共用一个节点，删除后保留空节点用于查询时的路由
+type node struct {
+	hash     uint64
+	ids      []uint
+	children map[int]*node
+}
+
+func NewIndex() *Index {
+	return &Index{hashes: make(map[uint]uint64)}
+}
+
+// Len 索引中的图片数
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.hashes)
+}
+
+// Get 返回图片的哈希
+func (x *Index) Get(id uint) (uint64, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	hash, ok := x.hashes[id]
+	return hash, ok
+}
+
+// Add 添加图片，已存在时更新其哈希
+func (x *Index) Add(id uint, hash uint64) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if old, ok := x.hashes[id]; ok {
+		if old == hash {
+			return
+		}
+		x.remove(id, old)
+	}
+	x.hashes[id] = hash
+
+	if x.root == nil {
+		x.root = &node{hash: hash, ids: []uint{id}}
+		return
+	}
+	n := x.root
+	for {
+		d := Distance(n.hash, hash)
+		if d == 0 {
+			n.ids = append(n.ids, id)
+			return
+		}
+		child, ok := n.children[d]
+		if !ok {
+			if n.children == nil {
+				n.children = make(map[int]*node)
+			}
+			n.children[d] = &node{hash: hash, ids: []uint{id}}
+			return
+		}
+		n = child
+	}
+}
+
+// Remove 删除图片
+func (x *Index) Remove(id uint) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if hash, ok := x.hashes[id]; ok {
+		x.remove(id, hash)
+		delete(x.hashes, id)
+	}
+}
+
+func (x *Index) remove(id uint, hash uint64) {
+	for n := x.root; n != nil; {
+		d := Distance(n.hash, hash)
+		if d == 0 {
+			for i, v := range n.ids {
+				if v == id {
+					n.ids = append(n.ids[:i], n.ids[i+1:]...)
+					break
+				}
+			}
+			return
+		}
+		n = n.children[d]
+	}
+}
+
+// Search 返回与 hash 距离不超过 maxDistance 的图片，按距离与 ID 排序
+func (x *Index) Search(hash uint64, maxDistance int) []Match {
+	x.mu.RLock()
+	var matches []Match
+	if x.root != nil {
+		stack := []*node{x.root}
+		for len(stack) > 0 {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			d := Distance(n.hash, hash)
+			if d <= maxDistance {
+				for _, id := range n.ids {
+					matches = append(matches, Match{ID: id, Hash: n.hash, Distance: d})
+				}
+			}
+			// 三角不等式：只有与当前节点距离在 [d-maxDistance, d+maxDistance] 内的子树可能满足条件
+			for cd, child := range n.children {
+				if cd >= d-maxDistance && cd <= d+maxDistance {
+					stack = append(stack, child)
+				}
+			}
+		}
+	}
+	x.mu.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].ID < matches[j].ID
+	})
+	return matches
+}
+
+// IDs 返回索引中全部图片的 ID，按从小到大排序
+func (x *Index) IDs() []uint {
+	x.mu.RLock()
+	ids := make([]uint, 0, len(x.hashes))
+	for id := range x.hashes {
+		ids = append(ids, id)
+	}
+	x.mu.RUnlock()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,63 @@
+package imghash
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// bruteForce 逐个比较，作为 Search 的对照
+func bruteForce(hashes map[uint]uint64, hash uint64, maxDistance int) map[uint]int {
+	want := make(map[uint]int)
+	for id, h := range hashes {
+		if d := Distance(h, hash); d <= maxDistance {
+			want[id] = d
+		}
+	}
+	return want
+}
+
+func TestIndex(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	index := NewIndex()
+	hashes := make(map[uint]uint64)
+	base := r.Uint64()
+	for id := uint(1); id <= 2000; id++ {
+		// 一部分哈希靠近 base，保证查询有结果
+		h := r.Uint64()
+		if id%4 == 0 {
+			h = base ^ (1 << uint(r.Intn(64))) ^ (1 << uint(r.Intn(64)))
+		}
+		hashes[id] = h
+		index.Add(id, h)
+	}
+	// 相同哈希共用节点，删除后重新添加
+	index.Add(2001, base)
+	hashes[2001] = base
+	for id := uint(1); id <= 2000; id += 7 {
+		index.Remove(id)
+		delete(hashes, id)
+	}
+	index.Add(8, base)
+	hashes[8] = base
+
+	if index.Len() != len(hashes) {
+		t.Fatalf("Len = %d, want %d", index.Len(), len(hashes))
+	}
+	for _, maxDistance := range []int{0, 2, 6, 20} {
+		for _, query := range []uint64{base, base ^ 0xff, r.Uint64()} {
+			got := make(map[uint]int)
+			prev := 0
+			for _, m := range index.Search(query, maxDistance) {
+				if m.Distance < prev {
+					t.Errorf("Search results not sorted by distance")
+				}
+				prev = m.Distance
+				got[m.ID] = m.Distance
+			}
+			if want := bruteForce(hashes, query, maxDistance); !reflect.DeepEqual(got, want) {
+				t.Errorf("Search(%x, %d) returned %d matches, want %d", query, maxDistance, len(got), len(want))
+			}
+		}
+	}
+}
@@ -0,0 +1,297 @@
+// Package imgmeta 读取 JPEG、PNG、WebP 中的 EXIF 与 XMP 元数据
+// 只依赖标准库，元数据损坏时尽量返回已解析的部分
+package imgmeta
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const maxChunkSize = 1 << 20 // PNG、WebP 中元数据块的最大长度
+
+// Metadata 图片元数据，没有的字段为零值
+type Metadata struct {
+	Make         string
+	Model        string
+	LensModel    string
+	ExposureTime string // 快门时间，如 1/125
+	FNumber      float64
+	ISO          int
+	FocalLength  float64 // 毫米
+	DateTaken    time.Time
+	Orientation  int // EXIF 方向 1-8
+	GPS          *GPS
+
+	// 以下来自 XMP
+	Title       string
+	Description string
+	Creator     string
+	Keywords    []string
+	Rating      int
+}
+
+// GPS 拍摄位置
+type GPS struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  *float64 // 米，海平面以下为负
+}
+
+// IsEmpty 没有解析到任何元数据
+func (m *Metadata) IsEmpty() bool {
+	return m.Make == "" && m.Model == "" && m.LensModel == "" && m.ExposureTime == "" &&
+		m.FNumber == 0 && m.ISO == 0 && m.FocalLength == 0 && m.DateTaken.IsZero() &&
+		m.Orientation == 0 && m.GPS == nil && m.Title == "" && m.Description == "" &&
+		m.Creator == "" && len(m.Keywords) == 0 && m.Rating == 0
+}
+
+// Parse 读取图片中的 EXIF 与 XMP，两者都存在时以 EXIF 为准
+// 不支持的格式返回空的 Metadata，返回错误时 Metadata 仍包含已解析的部分
+func Parse(r io.ReadSeeker) (*Metadata, error) {
+	m := &Metadata{}
+	exifData, xmpData, err := extract(r)
+	if exifData != nil {
+		if e := parseTIFF(exifData, m); e != nil && err == nil {
+			err = e
+		}
+	}
+	if xmpData != nil {
+		if e := parseXMP(xmpData, m); e != nil && err == nil {
+			err = e
+		}
+	}
+	return m, err
+}
+
+var (
+	jpegSOI     = []byte{0xFF, 0xD8}
+	pngSig      = []byte("\x89PNG\r\n\x1a\n")
+	exifHeader  = []byte("Exif\x00\x00")
+	xmpHeader   = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	xmpKeyword  = []byte("XML:com.adobe.xmp")
+	errTruncate = errors.New("metadata is truncated")
+)
+
+// extract 根据文件头找到 EXIF（TIFF 结构）与 XMP 数据
+func extract(r io.ReadSeeker) (exifData, xmpData []byte, err error) {
+	head := make([]byte, 12)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, nil, errors.WithStack(err)
+	}
+	head = head[:n]
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	switch {
+	case bytes.HasPrefix(head, jpegSOI):
+		return extractJPEG(r)
+	case bytes.HasPrefix(head, pngSig):
+		return extractPNG(r)
+	case len(head) == 12 && string(head[:4]) == "RIFF" && string(head[8:]) == "WEBP":
+		return extractWebP(r)
+	}
+	return nil, nil, nil
+}
+
+// extractJPEG 遍历 SOS 之前的段，EXIF 与 XMP 位于 APP1
+func extractJPEG(r io.ReadSeeker) (exifData, xmpData []byte, err error) {
+	br := &byteReader{r: r}
+	if _, err := br.skip(2); err != nil {
+		return nil, nil, err
+	}
+	for {
+		marker, length, err := br.jpegSegment()
+		if err != nil || marker == 0xDA || marker == 0xD9 {
+			return exifData, xmpData, err
+		}
+		if marker != 0xE1 {
+			if _, err := br.skip(length); err != nil {
+				return exifData, xmpData, err
+			}
+			continue
+		}
+		payload, err := br.read(length)
+		if err != nil {
+			return exifData, xmpData, err
+		}
+		switch {
+		case exifData == nil && bytes.HasPrefix(payload, exifHeader):
+			exifData = payload[len(exifHeader):]
+		case xmpData == nil && bytes.HasPrefix(payload, xmpHeader):
+			xmpData = payload[len(xmpHeader):]
+		}
+	}
+}
+
+// extractPNG 读取 eXIf 块与关键字为 XML:com.adobe.xmp 的 iTXt 块
+func extractPNG(r io.ReadSeeker) (exifData, xmpData []byte, err error) {
+	br := &byteReader{r: r}
+	if _, err := br.skip(int64(len(pngSig))); err != nil {
+		return nil, nil, err
+	}
+	for {
+		hdr, err := br.read(8)
+		if err != nil {
+			return exifData, xmpData, err
+		}
+		length, typ := binary.BigEndian.Uint32(hdr), string(hdr[4:])
+		if typ == "IEND" {
+			return exifData, xmpData, nil
+		}
+		if (typ != "eXIf" && typ != "iTXt") || length > maxChunkSize {
+			if _, err := br.skip(int64(length) + 4); err != nil {
+				return exifData, xmpData, err
+			}
+			continue
+		}
+		data, err := br.read(int64(length))
+		if err != nil {
+			return exifData, xmpData, err
+		}
+		if _, err := br.skip(4); err != nil {
+			return exifData, xmpData, err
+		}
+		switch {
+		case typ == "eXIf" && exifData == nil:
+			exifData = data
+		case typ == "iTXt" && xmpData == nil && bytes.HasPrefix(data, append(xmpKeyword, 0)):
+			xmpData, _ = parseITXt(data[len(xmpKeyword)+1:])
+		}
+	}
+}
+
+// parseITXt 解析 iTXt 中关键字之后的部分：压缩标志、压缩方法、语言、翻译后的关键字、文本
+func parseITXt(data []byte) ([]byte, error) {
+	if len(data) < 2 {
+		return nil, errTruncate
+	}
+	compressed := data[0] == 1
+	rest := data[2:]
+	for i := 0; i < 2; i++ {
+		end := bytes.IndexByte(rest, 0)
+		if end < 0 {
+			return nil, errTruncate
+		}
+		rest = rest[end+1:]
+	}
+	if !compressed {
+		return rest, nil
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(rest))
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer zr.Close()
+	text, err := io.ReadAll(io.LimitReader(zr, maxChunkSize))
+	return text, errors.WithStack(err)
+}
+
+// extractWebP 读取 RIFF 中的 EXIF 与 XMP 块
+func extractWebP(r io.ReadSeeker) (exifData, xmpData []byte, err error) {
+	br := &byteReader{r: r}
+	if _, err := br.skip(12); err != nil {
+		return nil, nil, err
+	}
+	for {
+		hdr, err := br.read(8)
+		if err == errTruncate {
+			return exifData, xmpData, nil
+		}
+		if err != nil {
+			return exifData, xmpData, err
+		}
+		fourcc, size := string(hdr[:4]), int64(binary.LittleEndian.Uint32(hdr[4:]))
+		padded := size + size&1
+		if (fourcc != "EXIF" && fourcc != "XMP ") || size > maxChunkSize {
+			if _, err := br.skip(padded); err != nil {
+				return exifData, xmpData, err
+			}
+			continue
+		}
+		data, err := br.read(size)
+		if err != nil {
+			return exifData, xmpData, err
+		}
+		if _, err := br.skip(padded - size); err != nil {
+			return exifData, xmpData, err
+		}
+		if fourcc == "EXIF" {
+			// 部分程序写入时保留了 JPEG 中的 Exif 前缀
+			exifData = bytes.TrimPrefix(data, exifHeader)
+		} else {
+			xmpData = data
+		}
+	}
+}
+
+// byteReader 读取或跳过指定长度，数据不足时返回 errTruncate
+type byteReader struct {
+	r io.ReadSeeker
+}
+
+func (b *byteReader) read(n int64) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(b.r, buf); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, errTruncate
+		}
+		return nil, errors.WithStack(err)
+	}
+	return buf, nil
+}
+
+func (b *byteReader) skip(n int64) (int64, error) {
+	if n == 0 {
+		return 0, nil
+	}
+	cur, err := b.r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	end, err := b.r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	if cur+n > end {
+		return 0, errTruncate
+	}
+	return b.r.Seek(cur+n, io.SeekStart)
+}
+
+// jpegSegment 读取下一个段的标记与数据长度，没有数据的标记长度为 0
+func (b *byteReader) jpegSegment() (marker byte, length int64, err error) {
+	hdr, err := b.read(2)
+	if err != nil {
+		return 0, 0, err
+	}
+	if hdr[0] != 0xFF {
+		return 0, 0, errors.New("invalid jpeg marker")
+	}
+	marker = hdr[1]
+	// 标记前可以有任意个填充的 0xFF
+	for marker == 0xFF {
+		next, err := b.read(1)
+		if err != nil {
+			return 0, 0, err
+		}
+		marker = next[0]
+	}
+	if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD9) {
+		return marker, 0, nil
+	}
+	l, err := b.read(2)
+	if err != nil {
+		return 0, 0, err
+	}
+	length = int64(binary.BigEndian.Uint16(l)) - 2
+	if length < 0 {
+		return 0, 0, errors.New("invalid jpeg segment length")
+	}
+	return marker, length, nil
+}
@@ -0,0 +1,186 @@
+package imgmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+)
+
+type testEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+var le = binary.LittleEndian
+
+func ascii(tag uint16, s string) testEntry {
+	return testEntry{tag, 2, uint32(len(s) + 1), append([]byte(s), 0)}
+}
+
+func short(tag uint16, v uint16) testEntry {
+	return testEntry{tag, typeShort, 1, le.AppendUint16(nil, v)}
+}
+
+func rationals(tag uint16, v ...uint32) testEntry {
+	var b []byte
+	for _, x := range v {
+		b = le.AppendUint32(b, x)
+	}
+	return testEntry{tag, typeRational, uint32(len(v) / 2), b}
+}
+
+// buildTIFF 依次写入 IFD0、Exif IFD、GPS IFD，超过 4 字节的值放在最后
+func buildTIFF(ifd0, exif, gps []testEntry) []byte {
+	ifdSize := func(entries []testEntry) int { return 2 + 12*len(entries) + 4 }
+	exifOff := 8 + ifdSize(ifd0) + 24
+	gpsOff := exifOff + ifdSize(exif)
+	ifd0 = append(ifd0, testEntry{tagExifIFD, typeLong, 1, le.AppendUint32(nil, uint32(exifOff))},
+		testEntry{tagGPSIFD, typeLong, 1, le.AppendUint32(nil, uint32(gpsOff))})
+	dataOff := gpsOff + ifdSize(gps)
+
+	out := []byte("II*\x00")
+	out = le.AppendUint32(out, 8)
+	var data []byte
+	for _, entries := range [][]testEntry{ifd0, exif, gps} {
+		out = le.AppendUint16(out, uint16(len(entries)))
+		for _, e := range entries {
+			out = le.AppendUint16(out, e.tag)
+			out = le.AppendUint16(out, e.typ)
+			out = le.AppendUint32(out, e.count)
+			if len(e.value) <= 4 {
+				out = append(out, e.value...)
+				out = append(out, make([]byte, 4-len(e.value))...)
+			} else {
+				out = le.AppendUint32(out, uint32(dataOff+len(data)))
+				data = append(data, e.value...)
+			}
+		}
+		out = le.AppendUint32(out, 0)
+	}
+	return append(out, data...)
+}
+
+func testTIFF() []byte {
+	return buildTIFF(
+		[]testEntry{ascii(tagMake, "Canon"), ascii(tagModel, "Canon EOS R5"), short(tagOrientation, 6)},
+		[]testEntry{
+			rationals(tagExposureTime, 1, 125),
+			rationals(tagFNumber, 28, 10),
+			short(tagISO, 400),
+			ascii(tagDateTimeOriginal, "2024:05:01 10:20:30"),
+			ascii(tagOffsetTimeOrig, "+08:00"),
+			rationals(tagFocalLength, 50, 1),
+			ascii(tagLensModel, "RF50mm F1.8 STM"),
+			ascii(0xA431, "012345678"),
+		},
+		[]testEntry{
+			ascii(tagGPSLatitudeRef, "N"),
+			rationals(tagGPSLatitude, 22, 1, 32, 1, 2400, 100),
+			ascii(tagGPSLongitudeRef, "E"),
+			rationals(tagGPSLongitude, 114, 1, 3, 1, 3600, 100),
+			rationals(tagGPSAltitude, 125, 10),
+		},
+	)
+}
+
+const testXMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:xmp="http://ns.adobe.com/xap/1.0/" xmp:Rating="4" xmp:CreateDate="2020-01-01T00:00:00Z">
+<dc:title><rdf:Alt><rdf:li xml:lang="x-default">Harbour</rdf:li></rdf:Alt></dc:title>
+<dc:creator><rdf:Seq><rdf:li>Alice</rdf:li></rdf:Seq></dc:creator>
+<dc:subject><rdf:Bag><rdf:li>sea</rdf:li><rdf:li>night</rdf:li></rdf:Bag></dc:subject>
+</rdf:Description></rdf:RDF></x:xmpmeta>`
+
+func testJPEG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil); err != nil {
+		t.Fatal(err)
+	}
+	segment := func(payload []byte) []byte {
+		return append(binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(payload)+2)), payload...)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment(append(append([]byte{}, exifHeader...), testTIFF()...))...)
+	out = append(out, segment(append(append([]byte{}, xmpHeader...), testXMP...))...)
+	return append(out, data[2:]...)
+}
+
+func testPNG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8))); err != nil {
+		t.Fatal(err)
+	}
+	chunk := func(typ string, data []byte) []byte {
+		b := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+		b = append(append(b, typ...), data...)
+		return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[4:]))
+	}
+	data := buf.Bytes()
+	// 签名与 IHDR 之后插入元数据块
+	out := append([]byte{}, data[:33]...)
+	out = append(out, chunk("eXIf", testTIFF())...)
+	itxt := append(append([]byte{}, xmpKeyword...), 0, 0, 0, 0, 0)
+	out = append(out, chunk("iTXt", append(itxt, testXMP...))...)
+	return append(out, data[33:]...)
+}
+
+func TestParse(t *testing.T) {
+	for name, data := range map[string][]byte{"jpeg": testJPEG(t), "png": testPNG(t)} {
+		m, err := Parse(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if m.Make != "Canon" || m.Model != "Canon EOS R5" || m.LensModel != "RF50mm F1.8 STM" || m.Orientation != 6 {
+			t.Errorf("%s: camera = %q %q %q %d", name, m.Make, m.Model, m.LensModel, m.Orientation)
+		}
+		if m.ExposureTime != "1/125" || m.FNumber != 2.8 || m.ISO != 400 || m.FocalLength != 50 {
+			t.Errorf("%s: exposure = %s f/%g ISO %d %gmm", name, m.ExposureTime, m.FNumber, m.ISO, m.FocalLength)
+		}
+		want := time.Date(2024, 5, 1, 2, 20, 30, 0, time.UTC)
+		if !m.DateTaken.Equal(want) {
+			t.Errorf("%s: DateTaken = %v, want %v", name, m.DateTaken, want)
+		}
+		if m.GPS == nil || math.Abs(m.GPS.Latitude-22.54) > 1e-4 || math.Abs(m.GPS.Longitude-114.06) > 1e-4 ||
+			m.GPS.Altitude == nil || *m.GPS.Altitude != 12.5 {
+			t.Errorf("%s: GPS = %+v", name, m.GPS)
+		}
+		if m.Title != "Harbour" || m.Creator != "Alice" || m.Rating != 4 || len(m.Keywords) != 2 || m.Keywords[1] != "night" {
+			t.Errorf("%s: xmp = %q %q %d %v", name, m.Title, m.Creator, m.Rating, m.Keywords)
+		}
+	}
+
+	m, err := Parse(bytes.NewReader([]byte("GIF89a")))
+	if err != nil || !m.IsEmpty() {
+		t.Errorf("Parse(gif) = %+v, %v, want empty", m, err)
+	}
+}
+
+// TestParseMalformed 截断或损坏的数据不能导致 panic
+func TestParseMalformed(t *testing.T) {
+	for _, data := range [][]byte{testJPEG(t), testPNG(t)} {
+		for i := 0; i < len(data); i++ {
+			_, _ = Parse(bytes.NewReader(data[:i]))
+		}
+		corrupted := append([]byte{}, data...)
+		for i := 20; i < len(corrupted) && i < 600; i += 7 {
+			corrupted[i] ^= 0xA5
+			_, _ = Parse(bytes.NewReader(corrupted))
+		}
+	}
+
+	tiff := testTIFF()
+	// IFD0 的条目数改为极大值
+	le.PutUint16(tiff[8:], 0xFFFF)
+	m := &Metadata{}
+	if err := parseTIFF(tiff, m); err == nil {
+		t.Error("parseTIFF with too many entries: error = nil")
+	}
+}
@@ -0,0 +1,48 @@
+package imgmeta
+
+import (
+	"image"
+	"io"
+
+	"github.com/disintegration/imaging"
+	"github.com/pkg/errors"
+)
+
+// Decode 解码图片并按 EXIF 方向转为正向，返回原图中的方向值，元数据损坏时按原样解码
+func Decode(r io.ReadSeeker) (image.Image, int, error) {
+	m, _ := Parse(r)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	return Orient(img, m.Orientation), m.Orientation, nil
+}
+
+// Orient 按 EXIF 方向值变换图像，使其不依赖方向标签也能正向显示
+func Orient(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	}
+	return img
+}
+
+// Swapped 判断该方向值是否交换宽高
+func Swapped(orientation int) bool {
+	return orientation >= 5 && orientation <= 8
+}
@@ -0,0 +1,491 @@
+package imgmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// StripLevel 去除元数据的程度，级别越高去除的越多
+type StripLevel int
+
+const (
+	StripNone      StripLevel = iota
+	StripGPS                  // 去除拍摄位置
+	StripSensitive            // 另外去除序列号、相机所有者与厂商注释
+	StripAll                  // 去除全部 EXIF、XMP、IPTC 与注释，保留 ICC 色彩配置
+)
+
+var stripLevelNames = []string{"none", "gps", "sensitive", "all"}
+
+// ParseStripLevel 解析 none、gps、sensitive、all
+func ParseStripLevel(s string) (StripLevel, error) {
+	for i, name := range stripLevelNames {
+		if s == name {
+			return StripLevel(i), nil
+		}
+	}
+	return StripNone, errors.Errorf("invalid strip level: %s", s)
+}
+
+func (l StripLevel) String() string {
+	if l < 0 || int(l) >= len(stripLevelNames) {
+		return "unknown"
+	}
+	return stripLevelNames[l]
+}
+
+// 去除 StripSensitive 时删除的标签
+var (
+	sensitiveIFD0Tags = map[uint16]bool{
+		0xC62F: true, // CameraSerialNumber
+	}
+	sensitiveExifTags = map[uint16]bool{
+		0x927C: true, // MakerNote
+		0xA420: true, // ImageUniqueID
+		0xA430: true, // CameraOwnerName
+		0xA431: true, // BodySerialNumber
+		0xA435: true, // LensSerialNumber
+	}
+)
+
+const tagXMPPacket = 0x02BC // TIFF 中内嵌的 XMP
+
+// Strip 按 level 去除 r 中的元数据后写入 w，图像数据原样复制
+// 不支持的格式原样复制。EXIF 在原位置修改，厂商注释等依赖偏移量的数据不受影响
+func Strip(w io.Writer, r io.ReadSeeker, level StripLevel) error {
+	head := make([]byte, 12)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return errors.WithStack(err)
+	}
+	head = head[:n]
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return errors.WithStack(err)
+	}
+	switch {
+	case level == StripNone:
+	case bytes.HasPrefix(head, jpegSOI):
+		return stripJPEG(w, r, level)
+	case bytes.HasPrefix(head, pngSig):
+		return stripPNG(w, r, level)
+	case len(head) == 12 && string(head[:4]) == "RIFF" && string(head[8:]) == "WEBP":
+		return stripWebP(w, r, level)
+	}
+	_, err = io.Copy(w, r)
+	return errors.WithStack(err)
+}
+
+// stripEXIF 返回去除后的 EXIF，需要整段删除时返回 nil
+func stripEXIF(data []byte, level StripLevel) []byte {
+	if level >= StripAll {
+		return nil
+	}
+	out := append([]byte{}, data...)
+	if err := stripTIFF(out, level); err != nil {
+		// 无法安全修改时删除整段
+		return nil
+	}
+	return out
+}
+
+// keepXMP 判断 XMP 是否可以保留，XMP 不在原位置修改，包含需要去除的内容时整段删除
+func keepXMP(data []byte, level StripLevel) bool {
+	switch {
+	case level >= StripAll:
+		return false
+	case bytes.Contains(data, []byte("GPS")):
+		return false
+	case level >= StripSensitive && (bytes.Contains(data, []byte("SerialNumber")) || bytes.Contains(data, []byte("OwnerName"))):
+		return false
+	}
+	return true
+}
+
+// stripTIFF 在原位置删除 GPS IFD 与敏感标签，被删除的数据以 0 覆盖
+func stripTIFF(data []byte, level StripLevel) error {
+	if len(data) < 8 {
+		return errTruncate
+	}
+	t := &tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return errors.New("invalid tiff header")
+	}
+	ifd0Off := t.order.Uint32(data[4:])
+	ifd0, err := t.readIFD(ifd0Off)
+	if err != nil {
+		return err
+	}
+
+	remove0 := map[uint16]bool{tagGPSIFD: true, tagXMPPacket: true}
+	if off, ok := ifd0.uint(t, tagGPSIFD); ok {
+		if err := t.zeroIFD(off); err != nil {
+			return err
+		}
+	}
+	if level >= StripSensitive {
+		for tag := range sensitiveIFD0Tags {
+			remove0[tag] = true
+		}
+		if off, ok := ifd0.uint(t, tagExifIFD); ok {
+			if err := t.removeEntries(off, sensitiveExifTags); err != nil {
+				return err
+			}
+		}
+	}
+	return t.removeEntries(ifd0Off, remove0)
+}
+
+// entryValue 返回条目的值所在的位置，值在条目内时 inline 为 true
+func (t *tiff) entryValue(e []byte) (start, size uint64, inline bool) {
+	typ, count := t.order.Uint16(e[2:]), t.order.Uint32(e[4:])
+	if int(typ) >= len(typeSizes) {
+		return 0, 0, true
+	}
+	size = uint64(typeSizes[typ]) * uint64(count)
+	if size <= 4 {
+		return 0, size, true
+	}
+	return uint64(t.order.Uint32(e[8:])), size, false
+}
+
+// zeroValue 以 0 覆盖条目在条目外的值
+func (t *tiff) zeroValue(e []byte) {
+	start, size, inline := t.entryValue(e)
+	if inline || start+size > uint64(len(t.data)) {
+		return
+	}
+	clear(t.data[start : start+size])
+}
+
+// zeroIFD 以 0 覆盖整个 IFD 及其条目的值
+func (t *tiff) zeroIFD(offset uint32) error {
+	start, n, err := t.ifdBounds(offset)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < n; i++ {
+		t.zeroValue(t.data[start+2+i*12 : start+14+i*12])
+	}
+	clear(t.data[start : start+2+n*12+4])
+	return nil
+}
+
+// removeEntries 删除 IFD 中的指定标签，之后的条目前移，IFD 末尾空出的部分以 0 填充
+func (t *tiff) removeEntries(offset uint32, tags map[uint16]bool) error {
+	start, n, err := t.ifdBounds(offset)
+	if err != nil {
+		return err
+	}
+	var kept [][]byte
+	for i := 0; i < n; i++ {
+		e := t.data[start+2+i*12 : start+14+i*12]
+		if tags[t.order.Uint16(e)] {
+			t.zeroValue(e)
+			continue
+		}
+		kept = append(kept, append([]byte{}, e...))
+	}
+	if len(kept) == n {
+		return nil
+	}
+	next := append([]byte{}, t.data[start+2+n*12:start+2+n*12+4]...)
+	region := t.data[start : start+2+n*12+4]
+	clear(region)
+	t.order.PutUint16(region, uint16(len(kept)))
+	for i, e := range kept {
+		copy(region[2+i*12:], e)
+	}
+	copy(region[2+len(kept)*12:], next)
+	return nil
+}
+
+// ifdBounds 检查 IFD 及其后的下一个 IFD 偏移量均在数据范围内
+func (t *tiff) ifdBounds(offset uint32) (start, n int, err error) {
+	start = int(offset)
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return 0, 0, errTruncate
+	}
+	n = int(t.order.Uint16(t.data[start:]))
+	if n > maxIFDEntries {
+		return 0, 0, errors.New("too many ifd entries")
+	}
+	if start+2+n*12+4 > len(t.data) {
+		return 0, 0, errTruncate
+	}
+	return start, n, nil
+}
+
+// stripJPEG 逐段复制到 SOS，之后的图像数据原样复制
+func stripJPEG(w io.Writer, r io.ReadSeeker, level StripLevel) error {
+	br := &byteReader{r: r}
+	soi, err := br.read(2)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(soi); err != nil {
+		return errors.WithStack(err)
+	}
+	for {
+		marker, length, err := br.jpegSegment()
+		if err != nil {
+			return err
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD9) {
+			if _, err := w.Write([]byte{0xFF, marker}); err != nil {
+				return errors.WithStack(err)
+			}
+			if marker == 0xD9 {
+				return nil
+			}
+			continue
+		}
+		payload, err := br.read(length)
+		if err != nil {
+			return err
+		}
+		payload = stripJPEGSegment(marker, payload, level)
+		if payload != nil {
+			seg := binary.BigEndian.AppendUint16([]byte{0xFF, marker}, uint16(len(payload)+2))
+			if _, err := w.Write(append(seg, payload...)); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if marker == 0xDA {
+			_, err := io.Copy(w, r)
+			return errors.WithStack(err)
+		}
+	}
+}
+
+// stripJPEGSegment 返回处理后的段数据，返回 nil 表示删除该段
+func stripJPEGSegment(marker byte, payload []byte, level StripLevel) []byte {
+	switch {
+	case marker == 0xE1 && bytes.HasPrefix(payload, exifHeader):
+		exif := stripEXIF(payload[len(exifHeader):], level)
+		if exif == nil {
+			return nil
+		}
+		return append(append([]byte{}, exifHeader...), exif...)
+	case marker == 0xE1 && bytes.HasPrefix(payload, xmpHeader):
+		if !keepXMP(payload, level) {
+			return nil
+		}
+	case marker == 0xE1:
+		// 扩展 XMP 等无法判断内容的数据
+		return nil
+	case level >= StripAll:
+		// 保留 JFIF、ICC 与 Adobe 颜色信息，删除 IPTC、注释与其他应用数据
+		switch {
+		case marker == 0xE0, marker == 0xE2, marker == 0xEE:
+		case marker >= 0xE0 && marker <= 0xEF, marker == 0xFE:
+			return nil
+		}
+	}
+	return payload
+}
+
+// stripPNG 复制除需要删除的元数据块以外的全部块，修改过的块重新计算 CRC
+func stripPNG(w io.Writer, r io.ReadSeeker, level StripLevel) error {
+	br := &byteReader{r: r}
+	sig, err := br.read(int64(len(pngSig)))
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(sig); err != nil {
+		return errors.WithStack(err)
+	}
+	for {
+		hdr, err := br.read(8)
+		if err != nil {
+			return err
+		}
+		length, typ := binary.BigEndian.Uint32(hdr), string(hdr[4:])
+		switch typ {
+		case "eXIf", "iTXt", "tEXt", "zTXt", "tIME":
+		default:
+			if _, err := w.Write(hdr); err != nil {
+				return errors.WithStack(err)
+			}
+			if _, err := io.CopyN(w, r, int64(length)+4); err != nil {
+				return errors.WithStack(err)
+			}
+			if typ == "IEND" {
+				return nil
+			}
+			continue
+		}
+
+		if length > maxChunkSize {
+			// 过大的元数据块直接删除
+			if _, err := br.skip(int64(length) + 4); err != nil {
+				return err
+			}
+			continue
+		}
+		data, err := br.read(int64(length) + 4)
+		if err != nil {
+			return err
+		}
+		data = stripPNGChunk(typ, data[:length], level)
+		if data == nil {
+			continue
+		}
+		chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+		chunk = append(append(chunk, typ...), data...)
+		chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+		if _, err := w.Write(chunk); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+}
+
+// stripPNGChunk 返回处理后的块数据，返回 nil 表示删除该块
+func stripPNGChunk(typ string, data []byte, level StripLevel) []byte {
+	if level >= StripAll {
+		return nil
+	}
+	switch typ {
+	case "eXIf":
+		return stripEXIF(data, level)
+	case "iTXt", "tEXt", "zTXt":
+		keyword := data
+		if i := bytes.IndexByte(data, 0); i >= 0 {
+			keyword = data[:i]
+		}
+		switch {
+		case bytes.Equal(keyword, xmpKeyword):
+			if xmp, err := parseITXt(data[len(keyword)+1:]); err != nil || !keepXMP(xmp, level) {
+				return nil
+			}
+		case strings.HasPrefix(string(keyword), "Raw profile type"):
+			// ImageMagick 以文本保存的 EXIF、XMP 等，无法在原位置修改
+			return nil
+		}
+	}
+	return data
+}
+
+type riffChunk struct {
+	fourcc string
+	offset int64 // 数据在文件中的位置
+	size   int64
+	data   []byte // 修改后的数据，为 nil 时复制原数据
+	drop   bool
+}
+
+// stripWebP 删除或修改 EXIF、XMP 块，并更新 RIFF 长度与 VP8X 标志
+func stripWebP(w io.Writer, r io.ReadSeeker, level StripLevel) error {
+	br := &byteReader{r: r}
+	if _, err := br.skip(12); err != nil {
+		return err
+	}
+	var chunks []*riffChunk
+	var dropped byte // 被删除的元数据在 VP8X 中的标志位
+	for {
+		hdr, err := br.read(8)
+		if err == errTruncate {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		c := &riffChunk{fourcc: string(hdr[:4]), offset: offset, size: int64(binary.LittleEndian.Uint32(hdr[4:]))}
+		if c.fourcc == "EXIF" || c.fourcc == "XMP " {
+			if c.size > maxChunkSize {
+				c.drop = true
+			} else {
+				data, err := br.read(c.size)
+				if err != nil {
+					return err
+				}
+				if c.fourcc == "EXIF" {
+					exif := bytes.TrimPrefix(data, exifHeader)
+					c.data = stripEXIF(exif, level)
+					c.drop = c.data == nil
+					if !c.drop && len(exif) != len(data) {
+						c.data = append(append([]byte{}, exifHeader...), c.data...)
+					}
+				} else {
+					c.drop = !keepXMP(data, level)
+				}
+			}
+			if c.drop {
+				dropped |= map[string]byte{"EXIF": 0x08, "XMP ": 0x04}[c.fourcc]
+			}
+		}
+		chunks = append(chunks, c)
+		if _, err := r.Seek(c.offset+c.size+c.size&1, io.SeekStart); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	var total int64 = 4
+	for _, c := range chunks {
+		if !c.drop {
+			total += 8 + c.size + c.size&1
+		}
+	}
+	out := []byte("RIFF")
+	out = binary.LittleEndian.AppendUint32(out, uint32(total))
+	if _, err := w.Write(append(out, "WEBP"...)); err != nil {
+		return errors.WithStack(err)
+	}
+	for _, c := range chunks {
+		if c.drop {
+			continue
+		}
+		hdr := binary.LittleEndian.AppendUint32([]byte(c.fourcc), uint32(c.size))
+		if _, err := w.Write(hdr); err != nil {
+			return errors.WithStack(err)
+		}
+		if c.data == nil && c.fourcc == "VP8X" {
+			data, err := readAt(br, c.offset, c.size)
+			if err != nil {
+				return err
+			}
+			if len(data) > 0 {
+				data[0] &^= dropped
+			}
+			c.data = data
+		}
+		if c.data != nil {
+			if _, err := w.Write(c.data); err != nil {
+				return errors.WithStack(err)
+			}
+		} else {
+			if _, err := r.Seek(c.offset, io.SeekStart); err != nil {
+				return errors.WithStack(err)
+			}
+			if _, err := io.CopyN(w, r, c.size); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+		if c.size&1 == 1 {
+			if _, err := w.Write([]byte{0}); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+	}
+	return nil
+}
+
+func readAt(br *byteReader, offset, size int64) ([]byte, error) {
+	if _, err := br.r.Seek(offset, io.SeekStart); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return br.read(size)
+}
@@ -0,0 +1,129 @@
+package imgmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/chai2010/webp"
+)
+
+// testWebP 以扩展格式写入 VP8X、图像、EXIF 与 XMP 块
+func testWebP(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := webp.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), &webp.Options{Lossless: true}); err != nil {
+		t.Fatal(err)
+	}
+	chunk := func(fourcc string, data []byte) []byte {
+		b := append(le.AppendUint32([]byte(fourcc), uint32(len(data))), data...)
+		if len(data)%2 == 1 {
+			b = append(b, 0)
+		}
+		return b
+	}
+	// VP8X：标志位与画布宽高减一
+	vp8x := []byte{0x08 | 0x04, 0, 0, 0, 7, 0, 0, 7, 0, 0}
+	body := []byte("WEBP")
+	body = append(body, chunk("VP8X", vp8x)...)
+	body = append(body, buf.Bytes()[12:]...)
+	body = append(body, chunk("EXIF", testTIFF())...)
+	body = append(body, chunk("XMP ", []byte(testXMP))...)
+	return append(le.AppendUint32([]byte("RIFF"), uint32(len(body))), body...)
+}
+
+func TestStrip(t *testing.T) {
+	decoders := map[string]func([]byte) error{
+		"jpeg": func(b []byte) error { _, err := jpeg.Decode(bytes.NewReader(b)); return err },
+		"png":  func(b []byte) error { _, err := png.Decode(bytes.NewReader(b)); return err },
+		"webp": func(b []byte) error { _, err := webp.Decode(bytes.NewReader(b)); return err },
+	}
+	inputs := map[string][]byte{"jpeg": testJPEG(t), "png": testPNG(t), "webp": testWebP(t)}
+
+	for name, data := range inputs {
+		for level := StripNone; level <= StripAll; level++ {
+			var out bytes.Buffer
+			if err := Strip(&out, bytes.NewReader(data), level); err != nil {
+				t.Fatalf("%s/%s: %v", name, level, err)
+			}
+			if err := decoders[name](out.Bytes()); err != nil {
+				t.Fatalf("%s/%s: decode stripped image: %v", name, level, err)
+			}
+			m, err := Parse(bytes.NewReader(out.Bytes()))
+			if err != nil {
+				t.Fatalf("%s/%s: parse stripped image: %v", name, level, err)
+			}
+			serial := bytes.Contains(out.Bytes(), []byte("012345678"))
+
+			switch level {
+			case StripNone:
+				if !bytes.Equal(out.Bytes(), data) {
+					t.Errorf("%s/%s: output differs from input", name, level)
+				}
+			case StripGPS:
+				if m.GPS != nil || m.Make != "Canon" || m.Orientation != 6 || m.Title != "Harbour" || !serial {
+					t.Errorf("%s/%s: GPS=%+v Make=%q Orientation=%d Title=%q serial=%v", name, level, m.GPS, m.Make, m.Orientation, m.Title, serial)
+				}
+			case StripSensitive:
+				if m.GPS != nil || m.LensModel == "" || m.Orientation != 6 || serial {
+					t.Errorf("%s/%s: GPS=%+v LensModel=%q Orientation=%d serial=%v", name, level, m.GPS, m.LensModel, m.Orientation, serial)
+				}
+			case StripAll:
+				if !m.IsEmpty() {
+					t.Errorf("%s/%s: metadata = %+v, want empty", name, level, m)
+				}
+			}
+		}
+	}
+
+	var out bytes.Buffer
+	if err := Strip(&out, bytes.NewReader(inputs["webp"]), StripAll); err != nil {
+		t.Fatal(err)
+	}
+	b := out.Bytes()
+	if size := binary.LittleEndian.Uint32(b[4:]); int(size) != len(b)-8 {
+		t.Errorf("webp RIFF size = %d, want %d", size, len(b)-8)
+	}
+	if b[20]&(0x08|0x04) != 0 {
+		t.Errorf("webp VP8X flags = %#x, metadata flags not cleared", b[20])
+	}
+}
+
+func TestParseStripLevel(t *testing.T) {
+	for l := StripNone; l <= StripAll; l++ {
+		if got, err := ParseStripLevel(l.String()); err != nil || got != l {
+			t.Errorf("ParseStripLevel(%q) = %v, %v", l, got, err)
+		}
+	}
+	if _, err := ParseStripLevel("exif"); err == nil {
+		t.Error("ParseStripLevel(exif): error = nil")
+	}
+}
+
+func TestOrient(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 3, 2))
+	// 左上角标记，方向 6 表示需要顺时针旋转 90 度
+	img.Pix[0] = 255
+	for o := 1; o <= 8; o++ {
+		b := Orient(img, o).Bounds()
+		if Swapped(o) != (b.Dx() == 2) {
+			t.Errorf("Orient(%d) bounds = %v", o, b)
+		}
+	}
+	r := Orient(img, 6).(*image.NRGBA)
+	if r.Pix[(0*r.Stride)+(1*4)] != 255 {
+		t.Error("Orient(6): top-left pixel should move to top-right")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	img, orientation, err := Decode(bytes.NewReader(testJPEG(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if orientation != 6 || img == nil {
+		t.Errorf("Decode() = %v, %d", img, orientation)
+	}
+}
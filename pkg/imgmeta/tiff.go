@@ -0,0 +1,282 @@
+package imgmeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 用到的 EXIF 标签
+const (
+	tagMake              = 0x010F
+	tagModel             = 0x0110
+	tagOrientation       = 0x0112
+	tagDateTime          = 0x0132
+	tagExifIFD           = 0x8769
+	tagGPSIFD            = 0x8825
+	tagExposureTime      = 0x829A
+	tagFNumber           = 0x829D
+	tagISO               = 0x8827
+	tagISOSpeed          = 0x8833
+	tagDateTimeOriginal  = 0x9003
+	tagDateTimeDigitized = 0x9004
+	tagOffsetTimeOrig    = 0x9011
+	tagFocalLength       = 0x920A
+	tagLensModel         = 0xA434
+
+	tagGPSLatitudeRef  = 0x01
+	tagGPSLatitude     = 0x02
+	tagGPSLongitudeRef = 0x03
+	tagGPSLongitude    = 0x04
+	tagGPSAltitudeRef  = 0x05
+	tagGPSAltitude     = 0x06
+)
+
+const maxIFDEntries = 512
+
+// 各数据类型的字节数，下标为 TIFF 类型编号
+var typeSizes = [...]int{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8}
+
+const (
+	typeShort     = 3
+	typeLong      = 4
+	typeRational  = 5
+	typeSRational = 10
+)
+
+type tiff struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count int
+	value []byte
+}
+
+// parseTIFF 解析 EXIF 数据（TIFF 结构）中的 IFD0、Exif IFD 与 GPS IFD
+func parseTIFF(data []byte, m *Metadata) error {
+	if len(data) < 8 {
+		return errTruncate
+	}
+	t := &tiff{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return errors.New("invalid tiff header")
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return errors.New("invalid tiff header")
+	}
+
+	ifd0, err := t.readIFD(t.order.Uint32(data[4:]))
+	if err != nil {
+		return err
+	}
+	m.Make = ifd0.str(tagMake)
+	m.Model = ifd0.str(tagModel)
+	if o, ok := ifd0.uint(t, tagOrientation); ok && o >= 1 && o <= 8 {
+		m.Orientation = int(o)
+	}
+	dateTime := ifd0.str(tagDateTime)
+
+	if off, ok := ifd0.uint(t, tagExifIFD); ok {
+		exif, e := t.readIFD(off)
+		if e != nil {
+			err = e
+		}
+		if v, ok := exif.rational(t, tagExposureTime, 0); ok && v.num > 0 && v.den > 0 {
+			m.ExposureTime = formatExposure(v)
+		}
+		if v, ok := exif.rational(t, tagFNumber, 0); ok {
+			m.FNumber = v.float()
+		}
+		if v, ok := exif.uint(t, tagISO); ok {
+			m.ISO = int(v)
+		} else if v, ok := exif.uint(t, tagISOSpeed); ok {
+			m.ISO = int(v)
+		}
+		if v, ok := exif.rational(t, tagFocalLength, 0); ok {
+			m.FocalLength = v.float()
+		}
+		m.LensModel = exif.str(tagLensModel)
+		for _, tag := range []uint16{tagDateTimeOriginal, tagDateTimeDigitized} {
+			if s := exif.str(tag); s != "" {
+				dateTime = s
+				break
+			}
+		}
+		m.DateTaken = parseExifTime(dateTime, exif.str(tagOffsetTimeOrig))
+	} else {
+		m.DateTaken = parseExifTime(dateTime, "")
+	}
+
+	if off, ok := ifd0.uint(t, tagGPSIFD); ok {
+		gps, e := t.readIFD(off)
+		if e != nil && err == nil {
+			err = e
+		}
+		m.GPS = parseGPS(t, gps)
+	}
+	return err
+}
+
+// readIFD 读取 offset 处的 IFD，越界或类型未知的条目被忽略
+func (t *tiff) readIFD(offset uint32) (ifd, error) {
+	entries := make(ifd)
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return entries, errTruncate
+	}
+	n := int(t.order.Uint16(t.data[offset:]))
+	if n > maxIFDEntries {
+		return entries, errors.New("too many ifd entries")
+	}
+	for i := 0; i < n; i++ {
+		base := int(offset) + 2 + i*12
+		if base+12 > len(t.data) {
+			return entries, errTruncate
+		}
+		e := t.data[base : base+12]
+		tag, typ, count := t.order.Uint16(e), t.order.Uint16(e[2:]), t.order.Uint32(e[4:])
+		if int(typ) >= len(typeSizes) || typeSizes[typ] == 0 {
+			continue
+		}
+		size := uint64(typeSizes[typ]) * uint64(count)
+		var value []byte
+		if size <= 4 {
+			value = e[8 : 8+size]
+		} else {
+			off := uint64(t.order.Uint32(e[8:]))
+			if off+size > uint64(len(t.data)) {
+				continue
+			}
+			value = t.data[off : off+size]
+		}
+		entries[tag] = ifdEntry{typ: typ, count: int(count), value: value}
+	}
+	return entries, nil
+}
+
+type ifd map[uint16]ifdEntry
+
+// str 读取 ASCII 值，去掉结尾的 NUL 与空白
+func (d ifd) str(tag uint16) string {
+	e, ok := d[tag]
+	if !ok {
+		return ""
+	}
+	v := e.value
+	if i := bytes.IndexByte(v, 0); i >= 0 {
+		v = v[:i]
+	}
+	return strings.TrimSpace(strings.ToValidUTF8(string(v), ""))
+}
+
+// uint 读取第一个 SHORT 或 LONG 值
+func (d ifd) uint(t *tiff, tag uint16) (uint32, bool) {
+	e, ok := d[tag]
+	if !ok || e.count == 0 {
+		return 0, false
+	}
+	switch e.typ {
+	case typeShort:
+		return uint32(t.order.Uint16(e.value)), true
+	case typeLong:
+		return t.order.Uint32(e.value), true
+	}
+	return 0, false
+}
+
+type rational struct {
+	num, den int64
+}
+
+func (r rational) float() float64 {
+	if r.den == 0 {
+		return 0
+	}
+	return math.Round(float64(r.num)/float64(r.den)*100) / 100
+}
+
+// rational 读取第 i 个 RATIONAL 或 SRATIONAL 值
+func (d ifd) rational(t *tiff, tag uint16, i int) (rational, bool) {
+	e, ok := d[tag]
+	if !ok || i >= e.count || (e.typ != typeRational && e.typ != typeSRational) {
+		return rational{}, false
+	}
+	v := e.value[i*8:]
+	if e.typ == typeSRational {
+		return rational{int64(int32(t.order.Uint32(v))), int64(int32(t.order.Uint32(v[4:])))}, true
+	}
+	return rational{int64(t.order.Uint32(v)), int64(t.order.Uint32(v[4:]))}, true
+}
+
+// formatExposure 快门时间小于 1 秒时写为 1/n
+func formatExposure(v rational) string {
+	if v.num < v.den {
+		return fmt.Sprintf("1/%d", int64(math.Round(float64(v.den)/float64(v.num))))
+	}
+	return fmt.Sprintf("%g", math.Round(float64(v.num)/float64(v.den)*10)/10)
+}
+
+// parseExifTime 解析 2006:01:02 15:04:05 格式的时间，offset 为空时按 UTC 处理
+func parseExifTime(s, offset string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	loc := time.UTC
+	if o, err := time.Parse("-07:00", offset); err == nil {
+		_, sec := o.Zone()
+		loc = time.FixedZone("", sec)
+	}
+	t, err := time.ParseInLocation("2006:01:02 15:04:05", s, loc)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// parseGPS 将度分秒转换为十进制坐标，坐标不完整或超出范围时返回 nil
+func parseGPS(t *tiff, gps ifd) *GPS {
+	lat, ok1 := dms(t, gps, tagGPSLatitude)
+	lon, ok2 := dms(t, gps, tagGPSLongitude)
+	if !ok1 || !ok2 || math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		return nil
+	}
+	if strings.EqualFold(gps.str(tagGPSLatitudeRef), "S") {
+		lat = -lat
+	}
+	if strings.EqualFold(gps.str(tagGPSLongitudeRef), "W") {
+		lon = -lon
+	}
+	g := &GPS{Latitude: lat, Longitude: lon}
+	if alt, ok := gps.rational(t, tagGPSAltitude, 0); ok && alt.den != 0 {
+		a := alt.float()
+		if e, ok := gps[tagGPSAltitudeRef]; ok && len(e.value) > 0 && e.value[0] == 1 {
+			a = -a
+		}
+		g.Altitude = &a
+	}
+	return g
+}
+
+func dms(t *tiff, gps ifd, tag uint16) (float64, bool) {
+	var parts [3]float64
+	for i := range parts {
+		v, ok := gps.rational(t, tag, i)
+		if !ok || v.den == 0 {
+			return 0, false
+		}
+		parts[i] = float64(v.num) / float64(v.den)
+	}
+	return parts[0] + parts[1]/60 + parts[2]/3600, true
+}
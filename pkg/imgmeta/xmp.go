@@ -0,0 +1,163 @@
+package imgmeta
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// XMP 命名空间
+const (
+	nsDC        = "http://purl.org/dc/elements/1.1/"
+	nsXMP       = "http://ns.adobe.com/xap/1.0/"
+	nsTIFF      = "http://ns.adobe.com/tiff/1.0/"
+	nsEXIF      = "http://ns.adobe.com/exif/1.0/"
+	nsEXIFEX    = "http://cipa.jp/exif/1.0/"
+	nsAux       = "http://ns.adobe.com/exif/1.0/aux/"
+	nsPhotoshop = "http://ns.adobe.com/photoshop/1.0/"
+	nsRDF       = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+)
+
+// xmpProps 读取的 XMP 属性，值可以是元素属性、元素文本或 rdf:li 列表
+var xmpProps = map[xml.Name]bool{
+	{Space: nsDC, Local: "title"}:                       true,
+	{Space: nsDC, Local: "description"}:                 true,
+	{Space: nsDC, Local: "creator"}:                     true,
+	{Space: nsDC, Local: "subject"}:                     true,
+	{Space: nsXMP, Local: "Rating"}:                     true,
+	{Space: nsXMP, Local: "CreateDate"}:                 true,
+	{Space: nsPhotoshop, Local: "DateCreated"}:          true,
+	{Space: nsEXIF, Local: "DateTimeOriginal"}:          true,
+	{Space: nsTIFF, Local: "Make"}:                      true,
+	{Space: nsTIFF, Local: "Model"}:                     true,
+	{Space: nsAux, Local: "Lens"}:                       true,
+	{Space: nsEXIFEX, Local: "LensModel"}:               true,
+	{Space: nsTIFF, Local: "Orientation"}:               true,
+	{Space: nsEXIF, Local: "ISOSpeedRatings"}:           true,
+	{Space: nsEXIFEX, Local: "PhotographicSensitivity"}: true,
+}
+
+var xmpTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// parseXMP 读取 XMP 中的描述信息，只填充 EXIF 中没有的字段
+func parseXMP(data []byte, m *Metadata) error {
+	values := make(map[xml.Name][]string)
+	d := xml.NewDecoder(bytes.NewReader(data))
+	d.Strict = false
+	var stack []xml.Name // 当前所在的属性元素
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fillXMP(values, m)
+			return errors.WithStack(err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			for _, attr := range tok.Attr {
+				if xmpProps[attr.Name] {
+					values[attr.Name] = append(values[attr.Name], attr.Value)
+				}
+			}
+			if xmpProps[tok.Name] {
+				stack = append(stack, tok.Name)
+			}
+			text.Reset()
+		case xml.CharData:
+			text.Write(tok)
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			prop := stack[len(stack)-1]
+			// 简单属性的文本或列表中 rdf:li 的文本
+			if tok.Name == prop || (tok.Name.Space == nsRDF && tok.Name.Local == "li") {
+				if s := strings.TrimSpace(text.String()); s != "" {
+					values[prop] = append(values[prop], s)
+				}
+			}
+			if tok.Name == prop {
+				stack = stack[:len(stack)-1]
+			}
+			text.Reset()
+		}
+	}
+	fillXMP(values, m)
+	return nil
+}
+
+func fillXMP(values map[xml.Name][]string, m *Metadata) {
+	first := func(space, local string) string {
+		if v := values[xml.Name{Space: space, Local: local}]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	m.Title = first(nsDC, "title")
+	m.Description = first(nsDC, "description")
+	m.Creator = strings.Join(values[xml.Name{Space: nsDC, Local: "creator"}], ", ")
+	m.Keywords = values[xml.Name{Space: nsDC, Local: "subject"}]
+	if r, err := strconv.Atoi(first(nsXMP, "Rating")); err == nil && r >= -1 && r <= 5 {
+		m.Rating = r
+	}
+
+	if m.Make == "" {
+		m.Make = first(nsTIFF, "Make")
+	}
+	if m.Model == "" {
+		m.Model = first(nsTIFF, "Model")
+	}
+	if m.LensModel == "" {
+		m.LensModel = first(nsEXIFEX, "LensModel")
+	}
+	if m.LensModel == "" {
+		m.LensModel = first(nsAux, "Lens")
+	}
+	if m.Orientation == 0 {
+		if o, err := strconv.Atoi(first(nsTIFF, "Orientation")); err == nil && o >= 1 && o <= 8 {
+			m.Orientation = o
+		}
+	}
+	if m.ISO == 0 {
+		for _, s := range []string{first(nsEXIFEX, "PhotographicSensitivity"), first(nsEXIF, "ISOSpeedRatings")} {
+			if iso, err := strconv.Atoi(s); err == nil && iso > 0 {
+				m.ISO = iso
+				break
+			}
+		}
+	}
+	if m.DateTaken.IsZero() {
+		for _, s := range []string{first(nsEXIF, "DateTimeOriginal"), first(nsPhotoshop, "DateCreated"), first(nsXMP, "CreateDate")} {
+			if t := parseXMPTime(s); !t.IsZero() {
+				m.DateTaken = t
+				break
+			}
+		}
+	}
+}
+
+func parseXMPTime(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range xmpTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,47 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量，取后 6 位
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := GenerateCode(rfcSecret, Counter(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("GenerateCode(%d) = %v, want %v", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, _ := GenerateCode(rfcSecret, Counter(now)-1)
+	counter, ok := Validate(rfcSecret, code, now)
+	if !ok || counter != Counter(now)-1 {
+		t.Errorf("Validate() = %v, %v, want previous step accepted", counter, ok)
+	}
+	code, _ = GenerateCode(rfcSecret, Counter(now)+2)
+	if _, ok := Validate(rfcSecret, code, now); ok {
+		t.Error("Validate() accepted a code outside the skew window")
+	}
+	if _, ok := Validate(rfcSecret, "12345", now); ok {
+		t.Error("Validate() accepted a short code")
+	}
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/response"
+	"github.com/FXAZfung/image-board/internal/op"
+)
+
+// createAPIKey 使用登录令牌创建指定作用域的 API 密钥
+func createAPIKey(t *testing.T, token string, scopes ...string) response.CreateAPIKeyResponse {
+	t.Helper()
+	resp := call(t, http.MethodPost, "/api/auth/apikeys", token, map[string]any{"name": "test", "scopes": scopes})
+	expectCode(t, resp, http.StatusOK)
+	var key response.CreateAPIKeyResponse
+	resp.decode(t, &key)
+	return key
+}
+
+func expectScopeDenied(t *testing.T, resp *testResp) {
+	t.Helper()
+	expectCode(t, resp, http.StatusForbidden)
+	if resp.Message != errs.ErrAPIKeyScope.Error() {
+		t.Fatalf("message = %q, want %q", resp.Message, errs.ErrAPIKeyScope)
+	}
+}
+
+// TestAPIKeyScopes API 密钥只能访问作用域允许的接口，且不能管理账户
+func TestAPIKeyScopes(t *testing.T) {
+	createTestUser(t, "key_user", model.DefaultPermission|model.PermManageTags)
+	token := login(t, "key_user", "key_user").Token
+	img := uploadTestImage(t, token)
+	setImagePublic(t, img, false)
+
+	read := createAPIKey(t, token, "read").Key
+	upload := createAPIKey(t, token, "upload").Key
+	tags := createAPIKey(t, token, "tags").Key
+	list := func(key string) *testResp {
+		return call(t, http.MethodPost, "/api/image/list", key, model.PageReq{Page: 1, PerPage: 100})
+	}
+	addTag := func(key string) *testResp {
+		return call(t, http.MethodPost, "/api/image/tag/add", key, map[string]any{"id": img.ID, "tag": "key_tag"})
+	}
+
+	t.Run("read", func(t *testing.T) {
+		resp := list(read)
+		expectCode(t, resp, http.StatusOK)
+		var page testPage
+		resp.decode(t, &page)
+		if !containsImage(page.Content, img.ID) {
+			t.Fatal("read key can't see own private image")
+		}
+		expectCode(t, call(t, http.MethodGet, "/images/image/"+img.FileName, read, nil), http.StatusOK)
+		expectScopeDenied(t, serve(t, testMultipartImage(t), read))
+		expectScopeDenied(t, addTag(read))
+		// 删除不属于任何作用域
+		expectCode(t, call(t, http.MethodPost, "/api/image/delete", read, map[string]any{"id": img.ID}), http.StatusForbidden)
+	})
+	t.Run("upload", func(t *testing.T) {
+		expectCode(t, serve(t, testMultipartImage(t), upload), http.StatusOK)
+		expectScopeDenied(t, list(upload))
+		expectScopeDenied(t, addTag(upload))
+	})
+	t.Run("tags", func(t *testing.T) {
+		expectCode(t, addTag(tags), http.StatusOK)
+		expectScopeDenied(t, serve(t, testMultipartImage(t), tags))
+	})
+	t.Run("account", func(t *testing.T) {
+		for _, key := range []string{read, upload, tags} {
+			expectScopeDenied(t, call(t, http.MethodGet, "/api/auth/apikeys", key, nil))
+			expectScopeDenied(t, call(t, http.MethodPost, "/api/auth/apikeys", key, map[string]any{"name": "escalate", "scopes": []string{"read", "upload", "tags"}}))
+			expectScopeDenied(t, call(t, http.MethodGet, "/api/auth/sessions", key, nil))
+			expectScopeDenied(t, call(t, http.MethodPost, "/api/auth/me/password", key, map[string]string{"old_password": "key_user", "new_password": "changed"}))
+		}
+	})
+	t.Run("unknown scope", func(t *testing.T) {
+		expectCode(t, call(t, http.MethodPost, "/api/auth/apikeys", token, map[string]any{"name": "bad", "scopes": []string{"admin"}}), http.StatusBadRequest)
+	})
+	t.Run("delete", func(t *testing.T) {
+		key := createAPIKey(t, token, "read")
+		expectSession(t, key.Key, http.StatusOK)
+		expectCode(t, call(t, http.MethodDelete, "/api/auth/apikeys/"+itoa(key.ID), token, nil), http.StatusOK)
+		expectSession(t, key.Key, http.StatusUnauthorized)
+		expectSession(t, model.APIKeyPrefix+"invalid", http.StatusUnauthorized)
+	})
+}
+
+// TestAdminAPIKeyScope 管理员的只读密钥不具备管理员的其他权限
+func TestAdminAPIKeyScope(t *testing.T) {
+	createTestUser(t, "admin_key_owner", model.DefaultPermission)
+	img := uploadTestImage(t, login(t, "admin_key_owner", "admin_key_owner").Token)
+	setImagePublic(t, img, false)
+	key := createAPIKey(t, loginAdmin(t), "read").Key
+
+	t.Run("read", func(t *testing.T) {
+		expectCode(t, call(t, http.MethodGet, "/api/image/info/"+itoa(img.ID), key, nil), http.StatusOK)
+	})
+	t.Run("denied", func(t *testing.T) {
+		expectCode(t, call(t, http.MethodGet, "/api/auth/users", key, nil), http.StatusForbidden)
+		expectCode(t, call(t, http.MethodPut, "/api/auth/users/"+itoa(img.UserID), key, map[string]any{"password": "taken"}), http.StatusForbidden)
+		expectCode(t, call(t, http.MethodGet, "/api/setting/list", key, nil), http.StatusForbidden)
+		expectCode(t, call(t, http.MethodGet, "/api/image/duplicates", key, nil), http.StatusForbidden)
+		expectCode(t, call(t, http.MethodPost, "/api/image/delete", key, map[string]any{"id": img.ID}), http.StatusForbidden)
+		expectScopeDenied(t, serve(t, testMultipartImage(t), key))
+		expectScopeDenied(t, call(t, http.MethodGet, "/api/auth/apikeys", key, nil))
+	})
+	if _, err := op.GetImageByID(img.ID); err != nil {
+		t.Fatalf("image deleted by a read-only key: %v", err)
+	}
+}
@@ -1,9 +1,12 @@
 package common
 
 import (
-	"github.com/FXAZfung/go-cache"
 	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
 	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/pkg/random"
+	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/pkg/errors"
 	"time"
@@ -12,54 +15,162 @@ import (
 var SecretKey []byte
 
 type UserClaims struct {
-	Username string `json:"username"`
+	Username   string `json:"username"`
+	Refresh    bool   `json:"refresh,omitempty"` // 是否为刷新令牌
+	RefreshGen int64  `json:"gen,omitempty"`     // 刷新令牌代数
 	jwt.RegisteredClaims
 }
 
-var validTokenCache = cache.NewMemCache[bool]()
+// TokenPair 访问令牌与刷新令牌
+type TokenPair struct {
+	Token         string    `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`         // 访问令牌
+	Expire        time.Time `json:"expire" example:"2023-10-01T12:00:00Z"`                           // 访问令牌过期时间
+	RefreshToken  string    `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."` // 刷新令牌，每次刷新后更换
+	RefreshExpire time.Time `json:"refresh_expire" example:"2023-10-03T12:00:00Z"`                   // 刷新令牌过期时间
+}
+
+func accessTokenTTL() time.Duration {
+	if conf.Conf.AccessTokenExpiresIn <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(conf.Conf.AccessTokenExpiresIn) * time.Minute
+}
+
+func sessionTTL() time.Duration {
+	return time.Duration(conf.Conf.TokenExpiresIn) * time.Hour
+}
 
-func GenerateToken(user *model.User) (tokenString string, err error) {
-	claim := UserClaims{
-		Username: user.Username,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(conf.Conf.TokenExpiresIn) * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		}}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claim)
-	tokenString, err = token.SignedString(SecretKey)
+// GenerateToken 创建会话并签发令牌，会话记录请求的 UA 与 IP
+func GenerateToken(c *gin.Context, user *model.User) (*TokenPair, error) {
+	now := time.Now()
+	session := &model.Session{
+		ID:         random.UUID(),
+		UserID:     user.ID,
+		IssuedAt:   now,
+		ExpiresAt:  now.Add(sessionTTL()),
+		LastSeen:   now,
+		UserAgent:  c.Request.UserAgent(),
+		IP:         c.ClientIP(),
+		RefreshGen: 1,
+	}
+	pair, err := signTokenPair(user, session, now)
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	if err := op.CreateSession(session); err != nil {
+		return nil, err
 	}
-	validTokenCache.Set(tokenString, true)
-	return tokenString, err
+	return pair, nil
 }
 
+// RefreshToken 使用刷新令牌换取新的令牌，旧的刷新令牌随即失效
+func RefreshToken(refreshToken string) (*TokenPair, error) {
+	claims, err := parseClaims(refreshToken)
+	if err != nil || !claims.Refresh {
+		return nil, errors.WithStack(errs.ErrSessionInvalid)
+	}
+	user, err := op.GetUserByName(claims.Username)
+	if err != nil {
+		return nil, errors.WithStack(errs.ErrSessionInvalid)
+	}
+	if user.Disabled {
+		return nil, errors.New("Current user is disabled, replace please")
+	}
+	now := time.Now()
+	session, err := op.RotateSession(claims.ID, claims.RefreshGen, now.Add(sessionTTL()))
+	if err != nil {
+		return nil, err
+	}
+	return signTokenPair(user, session, now)
+}
+
+// signTokenPair 签发会话的访问令牌与当前代数的刷新令牌，jti 均为会话 ID
+func signTokenPair(user *model.User, session *model.Session, now time.Time) (*TokenPair, error) {
+	expire := now.Add(accessTokenTTL())
+	if expire.After(session.ExpiresAt) {
+		expire = session.ExpiresAt
+	}
+	token, err := signClaims(UserClaims{
+		Username:         user.Username,
+		RegisteredClaims: registeredClaims(session.ID, now, expire),
+	})
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := signClaims(UserClaims{
+		Username:         user.Username,
+		Refresh:          true,
+		RefreshGen:       session.RefreshGen,
+		RegisteredClaims: registeredClaims(session.ID, now, session.ExpiresAt),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		Token:         token,
+		Expire:        expire,
+		RefreshToken:  refreshToken,
+		RefreshExpire: session.ExpiresAt,
+	}, nil
+}
+
+func registeredClaims(id string, now, expire time.Time) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		ID:        id,
+		ExpiresAt: jwt.NewNumericDate(expire),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+}
+
+func signClaims(claims UserClaims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(SecretKey)
+}
+
+// ParseToken 解析令牌，会话已撤销或过期时返回错误
 func ParseToken(tokenString string) (*UserClaims, error) {
-	if IsTokenInvalidated(tokenString) {
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Refresh {
+		return nil, errors.New("refresh token can't be used for authentication")
+	}
+	session, err := op.GetSession(claims.ID)
+	if err != nil {
 		return nil, errors.New("token is invalidated")
 	}
+	op.TouchSession(session)
+	return claims, nil
+}
+
+func parseClaims(tokenString string) (*UserClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &UserClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return SecretKey, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil {
 		return nil, err
 	}
-	if claims, ok := token.Claims.(*UserClaims); ok && token.Valid {
+	if claims, ok := token.Claims.(*UserClaims); ok && token.Valid && claims.ID != "" {
 		return claims, nil
 	}
 	return nil, errors.New("couldn't handle this token")
 }
 
-func InvalidateToken(tokenString string) error {
+// InvalidateToken 撤销令牌对应的会话
+func InvalidateToken(user *model.User, tokenString string) error {
 	if tokenString == "" {
 		return nil // don't invalidate empty guest token
 	}
-	validTokenCache.Del(tokenString)
-	return nil
+	claims, err := parseClaims(tokenString)
+	if err != nil {
+		return nil
+	}
+	_, err = op.RevokeSession(user.ID, claims.ID)
+	return err
 }
 
-func IsTokenInvalidated(tokenString string) bool {
-	_, ok := validTokenCache.Get(tokenString)
-	return !ok
+// GetSessionID 获取当前请求使用的会话 ID，管理员 Token 与游客没有会话
+func GetSessionID(c *gin.Context) string {
+	return c.GetString("session_id")
 }
@@ -0,0 +1,26 @@
+package common
+
+import (
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// GetUser 获取中间件注入的当前用户，未登录时返回 nil
+func GetUser(c *gin.Context) *model.User {
+	user, ok := c.Get("user")
+	if !ok {
+		return nil
+	}
+	u, _ := user.(*model.User)
+	return u
+}
+
+// GetAPIKey 获取当前请求使用的 API 密钥，未使用时返回 nil
+func GetAPIKey(c *gin.Context) *model.APIKey {
+	key, ok := c.Get("api_key")
+	if !ok {
+		return nil
+	}
+	k, _ := key.(*model.APIKey)
+	return k
+}
@@ -0,0 +1,129 @@
+package handles
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/response"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type CreateAPIKeyReq struct {
+	Name      string   `json:"name" binding:"required,max=64" example:"ci-uploader"`  // 密钥名称
+	Scopes    []string `json:"scopes" binding:"required,min=1" example:"upload,read"` // 作用域：read、upload、tags
+	ExpiresIn int      `json:"expires_in" binding:"min=0" example:"720"`              // 有效期（小时），0 表示永不过期
+}
+
+func apiKeyResp(key *model.APIKey) response.APIKeyResponse {
+	return response.APIKeyResponse{APIKey: key, Scopes: key.Scopes()}
+}
+
+// ListAPIKeys 列出当前用户的 API 密钥
+// @Summary 获取 API 密钥列表
+// @Description 列出当前用户的全部 API 密钥，不包含密钥明文
+// @Tags 认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Success 200 {object} common.Resp{data=[]response.APIKeyResponse} "密钥列表"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 403 {object} common.Resp "不能使用 API 密钥访问"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/auth/apikeys [get]
+func ListAPIKeys(c *gin.Context) {
+	keys, err := op.GetAPIKeysByUser(common.GetUser(c).ID)
+	if err != nil {
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	resp := make([]response.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, apiKeyResp(key))
+	}
+	common.SuccessResp(c, resp)
+}
+
+// CreateAPIKey 创建 API 密钥
+// @Summary 创建 API 密钥
+// @Description 为当前用户创建 API 密钥，密钥明文只在创建时返回一次。使用密钥访问时权限为用户权限与作用域的交集
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param request body CreateAPIKeyReq true "密钥信息"
+// @Success 200 {object} common.Resp{data=response.CreateAPIKeyResponse} "创建的密钥"
+// @Failure 400 {object} common.Resp "参数错误"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 403 {object} common.Resp "游客或使用 API 密钥访问"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/auth/apikeys [post]
+func CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	user := common.GetUser(c)
+	if user.IsGuest() {
+		common.ErrorResp(c, http.StatusForbidden, errs.ErrGuestWriteDenied)
+		return
+	}
+	scope, err := model.ParseScopes(req.Scopes)
+	if err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	var expiresAt *time.Time
+	if req.ExpiresIn > 0 {
+		t := time.Now().Add(time.Duration(req.ExpiresIn) * time.Hour)
+		expiresAt = &t
+	}
+
+	secret, key, err := op.CreateAPIKey(user.ID, req.Name, scope, expiresAt)
+	if err != nil {
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.SuccessResp(c, response.CreateAPIKeyResponse{
+		APIKeyResponse: apiKeyResp(key),
+		Key:            secret,
+	})
+}
+
+// DeleteAPIKey 删除 API 密钥
+// @Summary 删除 API 密钥
+// @Description 删除当前用户的指定 API 密钥，密钥立即失效
+// @Tags 认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param id path int true "密钥ID"
+// @Success 200 {object} common.Resp "删除成功"
+// @Failure 400 {object} common.Resp "ID格式错误"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 404 {object} common.Resp "密钥不存在"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/auth/apikeys/{id} [delete]
+func DeleteAPIKey(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ErrorStrResp(c, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+	if err := op.DeleteAPIKey(common.GetUser(c).ID, uint(id)); err != nil {
+		if errors.Is(err, errs.ErrAPIKeyNotFound) {
+			common.ErrorResp(c, http.StatusNotFound, err)
+			return
+		}
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.SuccessResp(c)
+}
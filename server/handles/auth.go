@@ -2,14 +2,18 @@ package handles
 
 import (
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/FXAZfung/go-cache"
-	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
 	"github.com/FXAZfung/image-board/internal/model"
 	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/service"
+	"github.com/FXAZfung/image-board/pkg/random"
 	"github.com/FXAZfung/image-board/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 )
 
 type LoginReq struct {
@@ -18,9 +22,23 @@ type LoginReq struct {
 }
 
 type LoginResp struct {
-	Token  string      `json:"token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."` // JWT Token
-	Expire time.Time   `json:"expire" example:"2023-10-01T12:00:00Z"`                   // Token 过期时间
-	User   *model.User `json:"user"`
+	common.TokenPair
+	User *model.User `json:"user"`
+}
+
+// LoginOtpResp 开启两步验证的用户密码校验通过后的响应，需使用 otp_token 完成第二步
+type LoginOtpResp struct {
+	OtpRequired bool   `json:"otp_required" example:"true"`
+	OtpToken    string `json:"otp_token" example:"Xk2...9a"` // 第二步验证凭据，5 分钟内有效，最多尝试 5 次
+}
+
+type LoginOtpReq struct {
+	OtpToken string `json:"otp_token" binding:"required"`             // 第一步返回的验证凭据
+	Code     string `json:"code" example:"123456" binding:"required"` // TOTP 验证码或恢复码
+}
+
+type RefreshReq struct {
+	RefreshToken string `json:"refresh_token" binding:"required"` // 刷新令牌
 }
 
 var loginCache = cache.NewMemCache[int]()
@@ -29,9 +47,23 @@ var (
 	loginTimes    = 10
 )
 
+// otpChallenge 第二步验证凭据对应的用户与已尝试的次数
+type otpChallenge struct {
+	userID   uint
+	attempts atomic.Int32
+}
+
+// otpCache 第二步验证凭据与验证状态的对应关系
+var otpCache = cache.NewMemCache[*otpChallenge]()
+
+const (
+	otpChallengeDuration = time.Minute * 5
+	otpMaxAttempts       = 5 // 每个验证凭据最多尝试的次数，用尽后需重新登录
+)
+
 // Login 登录
 // @Summary 用户登录
-// @Description 通过用户名和密码登录系统并获取令牌
+// @Description 通过用户名和密码登录系统并获取令牌，启用 LDAP 后除管理员外通过 LDAP 认证。开启两步验证的用户返回 LoginOtpResp，需调用 /api/auth/login/otp 完成登录
 // @Tags 认证
 // @Accept json
 // @Produce json
@@ -50,41 +82,143 @@ func Login(c *gin.Context) {
 	loginHash(c, &req)
 }
 
-func loginHash(c *gin.Context, req *LoginReq) {
-	// check count of login
+// checkLoginTimes 检查当前 IP 的失败次数，超过限制时返回 false
+func checkLoginTimes(c *gin.Context) (int, bool) {
 	ip := c.ClientIP()
 	count, ok := loginCache.Get(ip)
 	if ok && count >= loginTimes {
 		common.ErrorStrResp(c, http.StatusTooManyRequests, "Too many unsuccessful sign-in attempts have been made using an incorrect username or password, Try again later.")
 		loginCache.Expire(ip, loginDuration)
+		return count, false
+	}
+	return count, true
+}
+
+func loginHash(c *gin.Context, req *LoginReq) {
+	// check count of login
+	ip := c.ClientIP()
+	count, ok := checkLoginTimes(c)
+	if !ok {
 		return
 	}
-	// check username
-	user, err := op.GetUserByName(req.Username)
+	// validate username and password, against ldap if enabled
+	user, err := service.Authenticate(req.Username, req.Password)
 	if err != nil {
 		common.ErrorResp(c, http.StatusBadRequest, err)
 		loginCache.Set(ip, count+1)
 		return
 	}
-	// validate password hash
-	if err := user.ValidatePwdStaticHash(req.Password); err != nil {
-		common.ErrorResp(c, http.StatusBadRequest, err)
-		loginCache.Set(ip, count+1)
+	completeLogin(c, user)
+}
+
+// completeLogin 第一步认证通过后，开启两步验证的用户返回验证凭据，否则直接签发令牌
+func completeLogin(c *gin.Context, user *model.User) {
+	if user.OtpEnabled {
+		otpToken := random.String(32)
+		otpCache.Set(otpToken, &otpChallenge{userID: user.ID}, cache.WithEx[*otpChallenge](otpChallengeDuration))
+		common.SuccessResp(c, &LoginOtpResp{
+			OtpRequired: true,
+			OtpToken:    otpToken,
+		})
 		return
 	}
-	// generate token
-	token, err := common.GenerateToken(user)
+	issueToken(c, user)
+}
+
+// LoginOtp 两步验证登录
+// @Summary 两步验证登录
+// @Description 使用登录返回的 otp_token 与 TOTP 验证码或恢复码完成登录，每个恢复码只能使用一次。每个验证凭据最多尝试 5 次，之后需重新登录
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body LoginOtpReq true "验证信息"
+// @Success 200 {object} common.Resp{data=LoginResp} "登录成功，返回令牌信息"
+// @Failure 400 {object} common.Resp "验证码错误"
+// @Failure 401 {object} common.Resp "验证凭据无效、已过期或尝试次数过多"
+// @Failure 429 {object} common.Resp "尝试次数过多，请稍后再试"
+// @Router /api/auth/login/otp [post]
+func LoginOtp(c *gin.Context) {
+	var req LoginOtpReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorStrResp(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+	ip := c.ClientIP()
+	count, ok := checkLoginTimes(c)
+	if !ok {
+		return
+	}
+	challenge, ok := otpCache.Get(req.OtpToken)
+	if !ok {
+		common.ErrorResp(c, http.StatusUnauthorized, errs.ErrOtpChallenge)
+		return
+	}
+	// 按凭据计数，验证前先占用一次尝试，并发请求也不能超过次数限制
+	if challenge.attempts.Add(1) > otpMaxAttempts {
+		otpCache.Del(req.OtpToken)
+		common.ErrorResp(c, http.StatusUnauthorized, errs.ErrOtpChallenge)
+		return
+	}
+	user, err := op.GetUserById(challenge.userID)
+	if err != nil || !user.OtpEnabled {
+		otpCache.Del(req.OtpToken)
+		common.ErrorResp(c, http.StatusUnauthorized, errs.ErrOtpChallenge)
+		return
+	}
+	if err := service.VerifyTwoFactor(user, req.Code); err != nil {
+		if errors.Is(err, errs.ErrOtpCode) {
+			if challenge.attempts.Load() >= otpMaxAttempts {
+				otpCache.Del(req.OtpToken)
+			}
+			common.ErrorResp(c, http.StatusBadRequest, err)
+			loginCache.Set(ip, count+1)
+			return
+		}
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	otpCache.Del(req.OtpToken)
+	issueToken(c, user)
+}
+
+// issueToken 创建会话并返回令牌，同时清除登录失败计数
+func issueToken(c *gin.Context, user *model.User) {
+	pair, err := common.GenerateToken(c, user)
 	if err != nil {
 		common.ErrorResp(c, http.StatusBadRequest, err)
 		return
 	}
 	resp := &LoginResp{
-		Token:  token,
-		Expire: time.Now().Add(time.Duration(conf.Conf.TokenExpiresIn) * time.Hour),
-		User:   user,
+		TokenPair: *pair,
+		User:      user,
 	}
 	common.SuccessResp(c, resp)
-	loginCache.Del(ip)
+	loginCache.Del(c.ClientIP())
+}
+
+// Refresh 刷新令牌
+// @Summary 刷新令牌
+// @Description 使用刷新令牌换取新的访问令牌与刷新令牌并顺延会话有效期，旧的刷新令牌被重复使用时整个会话将被撤销
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param request body RefreshReq true "刷新令牌"
+// @Success 200 {object} common.Resp{data=common.TokenPair} "新的令牌"
+// @Failure 400 {object} common.Resp "请求格式错误"
+// @Failure 401 {object} common.Resp "刷新令牌无效、已过期或被重复使用"
+// @Router /api/auth/refresh [post]
+func Refresh(c *gin.Context) {
+	var req RefreshReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorStrResp(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+	pair, err := common.RefreshToken(req.RefreshToken)
+	if err != nil {
+		common.ErrorResp(c, http.StatusUnauthorized, err)
+		return
+	}
+	common.SuccessResp(c, pair)
 }
 
 // Logout 登出
@@ -100,7 +234,7 @@ func loginHash(c *gin.Context, req *LoginReq) {
 // @Failure 500 {object} common.Resp "令牌失效操作失败"
 // @Router /api/auth/logout [post]
 func Logout(c *gin.Context) {
-	err := common.InvalidateToken(c.GetHeader("Authorization"))
+	err := common.InvalidateToken(common.GetUser(c), c.GetHeader("Authorization"))
 	if err != nil {
 		common.ErrorResp(c, http.StatusInternalServerError, err)
 		return
@@ -0,0 +1,37 @@
+package handles
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 图片内容不会变化，但公开图片可能被改为私有或删除，共享缓存只保留较短时间，过期后凭 ETag 重新验证
+const publicCacheControl = "public, max-age=300, must-revalidate"
+
+// setCacheHeaders 设置强 ETag，调用方未指定缓存策略时使用公开图片的缓存策略
+func setCacheHeaders(c *gin.Context, etag string) {
+	c.Header("ETag", `"`+etag+`"`)
+	if c.Writer.Header().Get("Cache-Control") == "" {
+		c.Header("Cache-Control", publicCacheControl)
+	}
+}
+
+// notModified If-None-Match 命中时直接返回 304，避免读取存储后端
+func notModified(c *gin.Context, etag string) bool {
+	inm := c.GetHeader("If-None-Match")
+	if inm == "" {
+		return false
+	}
+	for _, tag := range strings.Split(inm, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == `"`+etag+`"` {
+			setCacheHeaders(c, etag)
+			c.Status(http.StatusNotModified)
+			c.Abort()
+			return true
+		}
+	}
+	return false
+}
@@ -1,17 +1,27 @@
 package handles
 
 import (
+	"errors"
 	"net/http"
+	"path"
+	"strings"
 	"time"
 
 	"github.com/FXAZfung/go-cache"
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/fetch"
 	"github.com/FXAZfung/image-board/internal/model"
 	"github.com/FXAZfung/image-board/internal/model/request"
 	"github.com/FXAZfung/image-board/internal/op"
 	"github.com/FXAZfung/image-board/internal/service"
+	"github.com/FXAZfung/image-board/internal/setting"
+	"github.com/FXAZfung/image-board/internal/sign"
+	"github.com/FXAZfung/image-board/internal/storage"
 	"github.com/FXAZfung/image-board/pkg/utils"
 	"github.com/FXAZfung/image-board/server/common"
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 
 	_ "image/gif"
 	_ "image/jpeg"
@@ -54,22 +64,159 @@ var (
 
 // GetImageByName 根据文件名获取图片
 // @Summary 获取原始图片文件
-// @Description 根据文件名直接返回图片二进制内容
+// @Description 根据文件名直接返回图片二进制内容，客户端支持时返回 WebP 格式，携带处理参数时返回按预设实时生成的图片
 // @Tags 图片
 // @Produce image/*
 // @Param name path string true "文件名" example("example.jpg")
+// @Param preset query string false "预设名称"
+// @Param w query int false "宽度"
+// @Param h query int false "高度"
+// @Param fit query string false "缩放方式" Enums(cover, contain)
+// @Param q query int false "质量"
+// @Param fmt query string false "输出格式" Enums(webp, jpeg, png)
+// @Param v query int false "衍生文件版本，即图片的 variant_version，重新生成缩略图与 WebP 后更换链接，不必等待缓存过期"
+// @Param sign query string false "签名，用于访问私有图片"
 // @Success 200 {file} binary "图片文件"
+// @Failure 400 {object} common.Resp "处理参数不在允许的预设中"
+// @Failure 403 {object} common.Resp "签名无效或已过期"
 // @Failure 404 {object} common.Resp "图片不存在"
 // @Router /images/image/{name} [get]
 func GetImageByName(c *gin.Context) {
 	name := c.Param("name")
 
+	var req request.ImageTransformReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+
+	// 处理参数需先解析，签名与具体的处理参数绑定
+	var opts *service.TransformOptions
+	if !req.IsEmpty() {
+		o, err := service.ResolveTransform(req)
+		if err != nil {
+			common.ErrorResp(c, http.StatusBadRequest, err)
+			return
+		}
+		opts = &o
+	}
+
+	imageData, ok := getVisibleImage(c, name, signPath(signKindImage, name, opts))
+	if !ok {
+		return
+	}
+
+	if opts != nil {
+		serveVariant(c, imageData, *opts)
+		return
+	}
+	key, etag := negotiateOriginal(c, imageData)
+	if notModified(c, etag) {
+		return
+	}
+	if redirectPresigned(c, key) {
+		return
+	}
+	serveObject(c, key, etag)
+}
+
+// getVisibleImage 获取当前访问者可见的图片，不可见时与不存在一样返回 404
+// 携带有效签名时允许访问不可见的图片，私有图片不允许共享缓存
+func getVisibleImage(c *gin.Context, name, signData string) (*model.Image, bool) {
 	imageData, err := op.GetImageByFileName(name)
 	if err != nil || imageData == nil {
 		common.ErrorStrResp(c, http.StatusNotFound, "Image not found")
+		return nil, false
+	}
+	if !imageData.CanView(common.GetUser(c)) {
+		s := c.Query("sign")
+		if s == "" {
+			common.ErrorStrResp(c, http.StatusNotFound, "Image not found")
+			return nil, false
+		}
+		if err := sign.Verify(signData, s); err != nil {
+			common.ErrorResp(c, http.StatusForbidden, err)
+			return nil, false
+		}
+	}
+	if !imageData.IsPublic {
+		c.Header("Cache-Control", "private, no-cache")
+	}
+	return imageData, true
+}
+
+// serveVariant 返回按预设处理后的图片
+func serveVariant(c *gin.Context, imageData *model.Image, opts service.TransformOptions) {
+	etag := imageData.Hash + imageData.VariantTag() + "-" + opts.Tag()
+	if notModified(c, etag) {
+		return
+	}
+	variantPath, err := service.GetImageVariant(imageData, opts)
+	if err != nil {
+		common.ErrorResp(c, http.StatusInternalServerError, err, true)
 		return
 	}
-	c.File(imageData.Path)
+	serveFile(c, variantPath, etag)
+}
+
+// redirectPresigned 存储后端支持预签名且已开启时，302 跳转到预签名链接
+func redirectPresigned(c *gin.Context, key string) bool {
+	if !setting.GetBool(conf.S3PresignRedirect) {
+		return false
+	}
+	presigner, ok := storage.GetStorage().(storage.Presigner)
+	if !ok {
+		return false
+	}
+	expire := time.Duration(setting.GetInt(conf.S3PresignExpire, 60)) * time.Minute
+	u, err := presigner.PresignGet(c.Request.Context(), key, expire)
+	if err != nil {
+		log.Warnf("failed to presign %s: %v", key, err)
+		return false
+	}
+	c.Redirect(http.StatusFound, u)
+	return true
+}
+
+// serveObject 从存储后端读取对象并返回给客户端，支持 Range 与条件请求
+func serveObject(c *gin.Context, key, etag string) {
+	reader, obj, err := storage.GetStorage().Get(c.Request.Context(), key)
+	if err != nil {
+		common.ErrorStrResp(c, http.StatusNotFound, "Image not found")
+		return
+	}
+	defer reader.Close()
+
+	if obj.ContentType != "" {
+		c.Header("Content-Type", obj.ContentType)
+	}
+	setCacheHeaders(c, etag)
+	http.ServeContent(c.Writer, c.Request, path.Base(key), obj.ModTime, reader)
+}
+
+// serveFile 返回本地缓存文件
+func serveFile(c *gin.Context, filePath, etag string) {
+	setCacheHeaders(c, etag)
+	c.File(filePath)
+}
+
+// writeErrorCode 写操作错误对应的状态码
+func writeErrorCode(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrGuestWriteDenied), errors.Is(err, errs.ErrImageModifyDenied),
+		errors.Is(err, errs.ErrPermissionDenied):
+		return http.StatusForbidden
+	case errors.Is(err, errs.ImageNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrInvalidStripLevel):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrDuplicateImage), errors.Is(err, errs.ErrNearDuplicate):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrTooManyPixels):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 // GetRandomImage 随机获取图片
@@ -96,7 +243,7 @@ func GetRandomImage(c *gin.Context) {
 	c.Header("Pragma", "no-cache")
 	c.Header("Expires", "0")
 
-	imageData, err := op.GetRandomImage()
+	imageData, err := op.GetRandomImage(common.GetUser(c))
 	if err != nil || imageData == nil {
 		common.ErrorStrResp(c, http.StatusNotFound, "Image not found")
 		return
@@ -109,7 +256,7 @@ func GetRandomImage(c *gin.Context) {
 		imageCache.Set(ip, 1, cache.WithEx[int](imageDuration))
 	}
 
-	c.File(imageData.Path)
+	serveObject(c, imageData.Path, imageData.Hash)
 }
 
 // ListImages 分页列出图片
@@ -131,7 +278,7 @@ func ListImages(c *gin.Context) {
 	}
 
 	req.Validate()
-	images, total, err := op.GetImagesByPage(req.Page, req.PerPage)
+	images, total, err := op.GetImagesByPage(req.Page, req.PerPage, common.GetUser(c))
 	if err != nil {
 		common.ErrorResp(c, http.StatusInternalServerError, err)
 		return
@@ -152,10 +299,13 @@ func ListImages(c *gin.Context) {
 // @Security ApiKeyAuth
 // @Param Authorization header string true "用户令牌"
 // @Param image formData file true "图片文件（支持PNG/JPEG/GIF）"
+// @Param strip_metadata formData string false "元数据去除程度，只能比站点设置 metadata_strip_policy 更严格，按去除后的内容去重" Enums(none, gps, sensitive, all)
 // @Success 200 {object} common.Resp{data=model.Image} "上传成功"
 // @Failure 400 {object} common.Resp "文件无效/参数错误"
 // @Failure 401 {object} common.Resp "未授权"
-// @Failure 413 {object} common.Resp "文件过大"
+// @Failure 403 {object} common.Resp "无上传权限"
+// @Failure 409 {object} common.Resp "图片已存在或与已有图片相似（near_duplicate_policy 为 reject 时）"
+// @Failure 413 {object} common.Resp "文件过大或像素数超出限制"
 // @Failure 500 {object} common.Resp "上传失败"
 // @Router /api/image/upload [post]
 func UploadImage(c *gin.Context) {
@@ -182,9 +332,9 @@ func UploadImage(c *gin.Context) {
 	}
 
 	// Call service to upload image
-	image, err := service.UploadImage(file, user.(*model.User))
+	image, err := service.UploadImage(file, req, user.(*model.User))
 	if err != nil {
-		common.ErrorResp(c, http.StatusInternalServerError, err)
+		common.ErrorResp(c, writeErrorCode(err), err)
 		return
 	}
 
@@ -192,6 +342,209 @@ func UploadImage(c *gin.Context) {
 	common.SuccessResp(c, image)
 }
 
+// BatchUploadImages 批量上传图片
+// @Summary 批量上传图片
+// @Description 一次上传多个图片文件或 zip 压缩包（压缩包中的图片会被展开），按文件返回新建、重复或拒绝的结果。标签与描述应用到本次新建的全部图片（需要登录）
+// @Tags 图片
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "用户令牌"
+// @Param images formData file true "图片文件或 zip 压缩包，可重复传递"
+// @Param tags formData []string false "标签，可重复传递或使用逗号分隔" collectionFormat(multi)
+// @Param description formData string false "描述"
+// @Param strip_metadata formData string false "元数据去除程度，只能比站点设置 metadata_strip_policy 更严格，按去除后的内容去重" Enums(none, gps, sensitive, all)
+// @Success 200 {object} common.Resp{data=response.BatchUploadResponse} "处理结果"
+// @Failure 400 {object} common.Resp "缺少文件/文件数超出上限/参数错误"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 403 {object} common.Resp "无上传权限"
+// @Router /api/image/upload/batch [post]
+func BatchUploadImages(c *gin.Context) {
+	var req request.BatchUploadReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	req.Tags = splitTags(req.Tags)
+	if key := common.GetAPIKey(c); key != nil && len(req.Tags) > 0 && !key.HasScope(model.ScopeTags) {
+		common.ErrorResp(c, http.StatusForbidden, errs.ErrAPIKeyScope)
+		return
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	files := append(form.File["images"], form.File["image"]...)
+	if len(files) == 0 {
+		common.ErrorStrResp(c, http.StatusBadRequest, "Missing image file")
+		return
+	}
+
+	resp, err := service.BatchUploadImages(files, req, common.GetUser(c))
+	if err != nil {
+		if errors.Is(err, errs.ErrImageBatchLimit) || errors.Is(err, errs.ErrTooManyTags) {
+			common.ErrorResp(c, http.StatusBadRequest, err)
+			return
+		}
+		common.ErrorResp(c, writeErrorCode(err), err)
+		return
+	}
+	common.SuccessResp(c, resp)
+}
+
+// ImportImage 通过链接导入图片
+// @Summary 通过链接导入图片
+// @Description 服务器下载链接指向的图片并按普通上传处理，结果与 /api/image/upload 一致。默认不允许访问回环、私有、链路本地等地址（需要登录）
+// @Tags 图片
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "用户令牌"
+// @Param import body request.ImportImageReq true "图片链接"
+// @Success 200 {object} common.Resp{data=model.Image} "导入成功"
+// @Failure 400 {object} common.Resp "链接无效/地址不允许访问/内容不是图片"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 403 {object} common.Resp "无上传权限或未启用链接导入"
+// @Failure 413 {object} common.Resp "文件过大或像素数超出限制"
+// @Failure 500 {object} common.Resp "下载或处理失败"
+// @Router /api/image/upload/url [post]
+func ImportImage(c *gin.Context) {
+	var req request.ImportImageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	image, err := service.ImportImage(c.Request.Context(), common.GetUser(c), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, fetch.ErrInvalidURL), errors.Is(err, fetch.ErrBlockedAddress), errors.Is(err, fetch.ErrNotImage):
+			common.ErrorResp(c, http.StatusBadRequest, err)
+		case errors.Is(err, fetch.ErrTooLarge), errors.Is(err, errs.ErrFileTooLarge):
+			common.ErrorResp(c, http.StatusRequestEntityTooLarge, err)
+		case errors.Is(err, errs.ErrURLImportDisabled):
+			common.ErrorResp(c, http.StatusForbidden, err)
+		default:
+			common.ErrorResp(c, writeErrorCode(err), err)
+		}
+		return
+	}
+	common.SuccessResp(c, image)
+}
+
+// ListDuplicateClusters 列出相似图片分组
+// @Summary 列出相似图片分组
+// @Description 按感知哈希将汉明距离不超过阈值的图片归为一组，按组内图片数从多到少分页返回（需要 delete_any 与 view_private 权限）
+// @Tags 图片
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "用户令牌"
+// @Param threshold query int false "汉明距离阈值（0-64），默认使用设置中的值"
+// @Param page query int false "页码" default(1) minimum(1)
+// @Param per_page query int false "每页分组数" default(20) minimum(1) maximum(100)
+// @Success 200 {object} common.Resp{data=common.PageResp{content=[]response.DuplicateCluster}} "分页结果"
+// @Failure 400 {object} common.Resp "参数错误"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 403 {object} common.Resp "权限不足"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/image/duplicates [get]
+func ListDuplicateClusters(c *gin.Context) {
+	var req request.DuplicateClustersReq
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	if req.PerPage < 1 || req.PerPage > 100 {
+		req.PerPage = 20
+	}
+	req.Validate()
+	threshold := service.NearDuplicateThreshold()
+	if req.Threshold != nil {
+		if *req.Threshold < 0 || *req.Threshold > 64 {
+			common.ErrorStrResp(c, http.StatusBadRequest, "threshold must be between 0 and 64")
+			return
+		}
+		threshold = *req.Threshold
+	}
+
+	clusters, total, err := service.ListDuplicateClusters(threshold, req.Page, req.PerPage, common.GetUser(c))
+	if err != nil {
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: clusters,
+		Total:   total,
+	})
+}
+
+// SearchSimilarImages 以图搜图
+// @Summary 以图搜图
+// @Description 上传一张图片（不会保存），按感知哈希距离返回图库中最相似的图片，可选结合颜色直方图排序（需要登录）
+// @Tags 图片
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "用户令牌"
+// @Param image formData file true "查询图片"
+// @Param limit formData int false "返回数量" default(20) minimum(1) maximum(100)
+// @Param max_distance formData int false "感知哈希的最大汉明距离（0-64）" default(16)
+// @Param color formData bool false "是否结合颜色直方图排序"
+// @Success 200 {object} common.Resp{data=[]response.SimilarImage} "按相似度排序的图片"
+// @Failure 400 {object} common.Resp "文件无效/参数错误"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 413 {object} common.Resp "文件过大或像素数超出限制"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/image/search/similar [post]
+func SearchSimilarImages(c *gin.Context) {
+	var req request.SimilarImageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	if req.MaxDistance != nil && (*req.MaxDistance < 0 || *req.MaxDistance > 64) {
+		common.ErrorStrResp(c, http.StatusBadRequest, "max_distance must be between 0 and 64")
+		return
+	}
+	file, err := c.FormFile("image")
+	if err != nil {
+		common.ErrorStrResp(c, http.StatusBadRequest, "Missing image file")
+		return
+	}
+
+	results, err := service.SearchSimilarImages(file, req, common.GetUser(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrCorruptedFile):
+			common.ErrorResp(c, http.StatusBadRequest, err)
+		case errors.Is(err, errs.ErrFileTooLarge), errors.Is(err, errs.ErrTooManyPixels):
+			common.ErrorResp(c, http.StatusRequestEntityTooLarge, err)
+		default:
+			common.ErrorResp(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	common.SuccessResp(c, results)
+}
+
+// splitTags 拆分逗号分隔的标签并去除空白与重复项
+func splitTags(values []string) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, value := range values {
+		for _, tag := range strings.Split(value, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "" || seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 //// UpdateImage 更新图片信息
 //// @Summary 修改图片信息
 //// @Description 更新图片描述、可见性等元数据（需要登录）
@@ -255,9 +608,9 @@ func DeleteImage(c *gin.Context) {
 		return
 	}
 
-	resp, err := service.DeleteImage(req.ID)
+	resp, err := service.DeleteImage(req.ID, common.GetUser(c))
 	if err != nil {
-		common.ErrorResp(c, http.StatusInternalServerError, err)
+		common.ErrorResp(c, writeErrorCode(err), err)
 		return
 	}
 
@@ -276,6 +629,7 @@ func DeleteImage(c *gin.Context) {
 // @Success 200 {object} common.Resp{data=response.ImageTagResponse} "操作结果"
 // @Failure 400 {object} common.Resp "请求参数错误"
 // @Failure 401 {object} common.Resp "未授权，需要登录"
+// @Failure 403 {object} common.Resp "无修改权限"
 // @Failure 404 {object} common.Resp "图片或标签不存在"
 // @Failure 500 {object} common.Resp "服务器错误"
 // @Router /api/image/tag/remove [post]
@@ -291,9 +645,9 @@ func RemoveTagFromImage(c *gin.Context) {
 		return
 	}
 
-	resp, err := service.RemoveTagFromImage(req.ImageID, req.TagID)
+	resp, err := service.RemoveTagFromImage(req.ImageID, req.TagID, common.GetUser(c))
 	if err != nil {
-		common.ErrorResp(c, http.StatusInternalServerError, err)
+		common.ErrorResp(c, writeErrorCode(err), err)
 		return
 	}
 
@@ -312,6 +666,7 @@ func RemoveTagFromImage(c *gin.Context) {
 // @Success 200 {object} common.Resp{data=model.Tag} "添加成功"
 // @Failure 400 {object} common.Resp "请求格式无效"
 // @Failure 401 {object} common.Resp "未授权，需要登录"
+// @Failure 403 {object} common.Resp "无修改权限"
 // @Failure 404 {object} common.Resp "图片不存在"
 // @Failure 500 {object} common.Resp "服务器错误"
 // @Router /api/image/tag/add [post]
@@ -338,9 +693,9 @@ func AddTagToImage(c *gin.Context) {
 	}
 
 	// Add tag to image
-	tag, err := op.AddTagToImage(req.ID, req.Tag)
+	tag, err := service.AddTagToImage(req.ID, req.Tag, common.GetUser(c))
 	if err != nil {
-		common.ErrorResp(c, http.StatusInternalServerError, err)
+		common.ErrorResp(c, writeErrorCode(err), err)
 		return
 	}
 
@@ -375,7 +730,7 @@ func GetImagesByTag(c *gin.Context) {
 	}
 	req.Validate()
 
-	images, count, err := op.GetImagesByTag(tagName, req.Page, req.PerPage)
+	images, count, err := op.GetImagesByTag(tagName, req.Page, req.PerPage, common.GetUser(c))
 	if err != nil {
 		common.ErrorResp(c, 500, err)
 		return
@@ -396,7 +751,7 @@ func GetImagesByTag(c *gin.Context) {
 // @Failure 500 {object} common.Resp "统计失败"
 // @Router /api/image/count [get]
 func GetImageCount(c *gin.Context) {
-	count, err := op.GetImageCount()
+	count, err := op.GetImageCount(common.GetUser(c))
 	if err != nil {
 		common.ErrorResp(c, http.StatusInternalServerError, err)
 		return
@@ -407,30 +762,50 @@ func GetImageCount(c *gin.Context) {
 
 // GetThumbnailByName 获取缩略图
 // @Summary 获取图片缩略图
-// @Description 获取指定文件的缩略图（自动降级返回原图）
+// @Description 获取指定文件的缩略图（自动降级返回原图），客户端支持时返回 WebP 格式
 // @Tags 图片
 // @Produce image/*
 // @Param name path string true "文件名" example("example_thumb.jpg")
+// @Param v query int false "衍生文件版本，即图片的 variant_version，重新生成缩略图与 WebP 后更换链接，不必等待缓存过期"
+// @Param sign query string false "签名，用于访问私有图片"
 // @Success 200 {file} binary "缩略图文件"
+// @Failure 403 {object} common.Resp "签名无效或已过期"
 // @Failure 404 {object} common.Resp "文件不存在"
 // @Router /images/thumbnail/{name} [get]
 func GetThumbnailByName(c *gin.Context) {
 	name := c.Param("name")
 
-	imageData, err := op.GetImageByFileName(name)
-	if err != nil || imageData == nil {
-		common.ErrorStrResp(c, http.StatusNotFound, "Image not found")
+	imageData, ok := getVisibleImage(c, name, signPath(signKindThumbnail, name, nil))
+	if !ok {
 		return
 	}
 
+	// 客户端支持时返回其他格式的缩略图
+	if format := preferredFormat(c, imageData); format != nil && format.thumbnail != "" {
+		etag := imageData.Hash + imageData.VariantTag() + "-thumb-" + format.name
+		if notModified(c, etag) {
+			return
+		}
+		variantPath, err := service.GetImageVariant(imageData, service.ThumbnailOptions(format.thumbnail))
+		if err == nil {
+			serveFile(c, variantPath, etag)
+			return
+		}
+	}
+
 	// 构建缩略图路径
-	thumbnailPath := service.GetThumbnailPath(imageData.Path)
+	thumbnailPath := imageData.ThumbnailPath
+	if thumbnailPath == "" {
+		thumbnailPath = service.GetThumbnailPath(imageData.Path)
+	}
 
-	// 如果缩略图不存在，则重定向到原图
-	if !utils.IsExist(thumbnailPath) {
-		c.File(imageData.Path)
+	// 如果缩略图不存在，则返回原图
+	key, etag := thumbnailPath, imageData.Hash+imageData.VariantTag()+"-thumb"
+	if _, err := storage.GetStorage().Stat(c.Request.Context(), thumbnailPath); err != nil {
+		key, etag = imageData.Path, imageData.Hash
+	}
+	if notModified(c, etag) {
 		return
 	}
-
-	c.File(thumbnailPath)
+	serveObject(c, key, etag)
 }
@@ -0,0 +1,81 @@
+package handles
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/FXAZfung/image-board/internal/model/request"
+	"github.com/FXAZfung/image-board/internal/service"
+	"github.com/FXAZfung/image-board/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// GetImageDetail 获取图片详情
+// @Summary 获取图片详情
+// @Description 获取图片信息、标签与 EXIF/XMP 元数据。拍摄位置只对上传者与可以查看私有图片的用户返回
+// @Tags 图片
+// @Produce json
+// @Param id path int true "图片ID" minimum(1)
+// @Success 200 {object} common.Resp{data=model.Image} "图片详情"
+// @Failure 400 {object} common.Resp "ID格式错误"
+// @Failure 404 {object} common.Resp "图片不存在"
+// @Router /api/image/info/{id} [get]
+func GetImageDetail(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		common.ErrorStrResp(c, http.StatusBadRequest, "Invalid ID format")
+		return
+	}
+	image, err := service.GetImageDetail(uint(id), common.GetUser(c))
+	if err != nil {
+		common.ErrorResp(c, writeErrorCode(err), err)
+		return
+	}
+	common.SuccessResp(c, image)
+}
+
+// SearchImagesByMetadata 按元数据搜索图片
+// @Summary 按元数据搜索图片
+// @Description 按相机厂商、型号、镜头与拍摄时间筛选图片，只返回带有元数据的图片，按拍摄时间从新到旧排序
+// @Tags 图片
+// @Accept json
+// @Produce json
+// @Param search body request.MetadataSearchReq true "筛选条件与分页参数"
+// @Success 200 {object} common.Resp{data=common.PageResp{content=[]model.Image}} "分页结果"
+// @Failure 400 {object} common.Resp "参数校验失败"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/image/metadata/search [post]
+func SearchImagesByMetadata(c *gin.Context) {
+	var req request.MetadataSearchReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	req.Validate()
+	images, total, err := service.SearchImagesByMetadata(req, common.GetUser(c))
+	if err != nil {
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: images,
+		Total:   total,
+	})
+}
+
+// GetMetadataFacets 获取元数据分面统计
+// @Summary 获取相机与拍摄月份统计
+// @Description 统计可见图片中各相机型号与各拍摄月份的图片数，可作为 /api/image/metadata/search 的筛选项
+// @Tags 图片
+// @Produce json
+// @Success 200 {object} common.Resp{data=response.MetadataFacets} "统计结果"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/image/metadata/facets [get]
+func GetMetadataFacets(c *gin.Context) {
+	facets, err := service.GetMetadataFacets(common.GetUser(c))
+	if err != nil {
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.SuccessResp(c, facets)
+}
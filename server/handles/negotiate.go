@@ -0,0 +1,83 @@
+package handles
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/service"
+	"github.com/FXAZfung/image-board/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// negotiableFormat 可以根据 Accept 头替代原图返回的格式，按优先级排列
+type negotiableFormat struct {
+	name string
+	mime string
+	// original 返回原图对应格式的存储 key，为空表示没有预先生成
+	original func(image *model.Image) string
+	// thumbnail 缩略图对应格式的实时处理格式，为空表示不支持
+	thumbnail string
+}
+
+var negotiableFormats = []negotiableFormat{
+	{
+		name:      "webp",
+		mime:      "image/webp",
+		original:  func(image *model.Image) string { return image.WebpPath },
+		thumbnail: service.FormatWebP,
+	},
+}
+
+// acceptsMime 判断 Accept 头是否显式接受 mime，通配符不算
+func acceptsMime(accept, mime string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		if !strings.EqualFold(strings.TrimSpace(fields[0]), mime) {
+			continue
+		}
+		for _, param := range fields[1:] {
+			k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(k, "q") {
+				if q, err := strconv.ParseFloat(v, 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// preferredFormat 返回客户端可接受的替代格式，没有时返回 nil
+// 动图转换后会丢失动画，已是目标格式的图片也无需转换
+func preferredFormat(c *gin.Context, image *model.Image) *negotiableFormat {
+	c.Header("Vary", "Accept")
+	if image.ContentType == "image/gif" {
+		return nil
+	}
+	accept := c.GetHeader("Accept")
+	for i := range negotiableFormats {
+		format := &negotiableFormats[i]
+		if image.ContentType != format.mime && acceptsMime(accept, format.mime) {
+			return format
+		}
+	}
+	return nil
+}
+
+// negotiateOriginal 根据 Accept 头选择原图或其预先生成的其他格式版本，返回存储 key 与 ETag
+func negotiateOriginal(c *gin.Context, image *model.Image) (string, string) {
+	format := preferredFormat(c, image)
+	if format == nil {
+		return image.Path, image.Hash
+	}
+	key := format.original(image)
+	if key == "" {
+		return image.Path, image.Hash
+	}
+	if _, err := storage.GetStorage().Stat(c.Request.Context(), key); err != nil {
+		return image.Path, image.Hash
+	}
+	return key, image.Hash + image.VariantTag() + "-" + format.name
+}
@@ -0,0 +1,187 @@
+package handles
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/request"
+	"github.com/FXAZfung/image-board/internal/model/response"
+	"github.com/FXAZfung/image-board/internal/service"
+	"github.com/FXAZfung/image-board/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+func uploadSessionResp(c *gin.Context, session *model.UploadSession) response.UploadSessionResponse {
+	c.Header("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(session.Size, 10))
+	c.Header("Cache-Control", "no-store")
+	return response.UploadSessionResponse{
+		ID:        session.ID,
+		FileName:  session.FileName,
+		Size:      session.Size,
+		Offset:    session.Offset,
+		ExpiresAt: session.ExpiresAt,
+	}
+}
+
+func uploadErrorCode(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrUploadNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrUploadOffset):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrFileTooLarge), errors.Is(err, errs.ErrUploadOverflow):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, errs.ErrInvalidFileExt), errors.Is(err, errs.ErrUploadIncomplete):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrUploadTooMany):
+		return http.StatusTooManyRequests
+	default:
+		return writeErrorCode(err)
+	}
+}
+
+// CreateUpload 创建断点续传上传
+// @Summary 创建断点续传上传
+// @Description 声明文件名与大小，返回上传 ID。之后使用 PATCH 按顺序上传分片，全部上传后调用 finish 完成。24 小时内没有写入的上传会被清理（需要登录）
+// @Tags 图片
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "用户令牌"
+// @Param upload body request.CreateUploadReq true "文件信息"
+// @Success 200 {object} common.Resp{data=response.UploadSessionResponse} "上传会话"
+// @Failure 400 {object} common.Resp "参数错误/文件扩展名不允许"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 403 {object} common.Resp "无上传权限"
+// @Failure 413 {object} common.Resp "文件过大"
+// @Failure 429 {object} common.Resp "未完成的上传过多"
+// @Router /api/image/upload/resumable [post]
+func CreateUpload(c *gin.Context) {
+	var req request.CreateUploadReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	session, err := service.CreateUpload(common.GetUser(c), req)
+	if err != nil {
+		common.ErrorResp(c, uploadErrorCode(err), err)
+		return
+	}
+	c.Header("Location", c.Request.URL.Path+"/"+session.ID)
+	common.SuccessResp(c, uploadSessionResp(c, session))
+}
+
+// GetUpload 获取断点续传上传进度
+// @Summary 获取上传进度
+// @Description 返回已接收的字节数，中断后从 offset 处继续上传。HEAD 请求只返回 Upload-Offset 与 Upload-Length 响应头
+// @Tags 图片
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "用户令牌"
+// @Param id path string true "上传ID"
+// @Success 200 {object} common.Resp{data=response.UploadSessionResponse} "上传会话"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 404 {object} common.Resp "上传不存在或已过期"
+// @Router /api/image/upload/resumable/{id} [get]
+func GetUpload(c *gin.Context) {
+	session, err := service.GetUpload(common.GetUser(c), c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, uploadErrorCode(err), err)
+		return
+	}
+	common.SuccessResp(c, uploadSessionResp(c, session))
+}
+
+// HeadUpload HEAD 请求没有响应体，使用状态码表示结果
+func HeadUpload(c *gin.Context) {
+	session, err := service.GetUpload(common.GetUser(c), c.Param("id"))
+	if err != nil {
+		c.Status(uploadErrorCode(err))
+		return
+	}
+	uploadSessionResp(c, session)
+	c.Status(http.StatusOK)
+}
+
+// PatchUpload 上传分片
+// @Summary 上传分片
+// @Description 请求体为分片的原始数据，Upload-Offset 必须等于已接收的字节数。连接中断时已接收的部分会被保留
+// @Tags 图片
+// @Accept application/offset+octet-stream
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "用户令牌"
+// @Param Upload-Offset header int true "分片在文件中的起始位置"
+// @Param id path string true "上传ID"
+// @Success 200 {object} common.Resp{data=response.UploadSessionResponse} "上传会话"
+// @Failure 400 {object} common.Resp "参数错误"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 404 {object} common.Resp "上传不存在或已过期"
+// @Failure 409 {object} common.Resp "起始位置与已接收的字节数不一致"
+// @Failure 413 {object} common.Resp "分片超出声明的文件大小"
+// @Router /api/image/upload/resumable/{id} [patch]
+func PatchUpload(c *gin.Context) {
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		common.ErrorStrResp(c, http.StatusBadRequest, "Invalid Upload-Offset header")
+		return
+	}
+	session, err := service.WriteUploadChunk(common.GetUser(c), c.Param("id"), offset, c.Request.Body)
+	if session != nil {
+		resp := uploadSessionResp(c, session)
+		if err == nil {
+			common.SuccessResp(c, resp)
+			return
+		}
+	}
+	common.ErrorResp(c, uploadErrorCode(err), err)
+}
+
+// FinishUpload 完成断点续传上传
+// @Summary 完成上传
+// @Description 全部数据上传后创建图片，结果与 /api/image/upload 一致。无论成功与否上传会话都会被删除
+// @Tags 图片
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "用户令牌"
+// @Param id path string true "上传ID"
+// @Success 200 {object} common.Resp{data=model.Image} "上传成功"
+// @Failure 400 {object} common.Resp "数据未上传完成"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 403 {object} common.Resp "无上传权限"
+// @Failure 404 {object} common.Resp "上传不存在或已过期"
+// @Failure 413 {object} common.Resp "像素数超出限制"
+// @Failure 500 {object} common.Resp "上传失败"
+// @Router /api/image/upload/resumable/{id}/finish [post]
+func FinishUpload(c *gin.Context) {
+	image, err := service.FinishUpload(common.GetUser(c), c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, uploadErrorCode(err), err)
+		return
+	}
+	common.SuccessResp(c, image)
+}
+
+// AbortUpload 取消断点续传上传
+// @Summary 取消上传
+// @Description 删除上传会话与已接收的数据
+// @Tags 图片
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "用户令牌"
+// @Param id path string true "上传ID"
+// @Success 200 {object} common.Resp "取消成功"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 404 {object} common.Resp "上传不存在或已过期"
+// @Router /api/image/upload/resumable/{id} [delete]
+func AbortUpload(c *gin.Context) {
+	if err := service.AbortUpload(common.GetUser(c), c.Param("id")); err != nil {
+		common.ErrorResp(c, uploadErrorCode(err), err)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -0,0 +1,83 @@
+package handles
+
+import (
+	"net/http"
+
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// ListSessions 列出当前用户的登录会话
+// @Summary 获取登录会话列表
+// @Description 列出当前用户所有未过期的登录会话，current 标记当前请求使用的会话
+// @Tags 认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Success 200 {object} common.Resp{data=[]model.Session} "会话列表"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/auth/sessions [get]
+func ListSessions(c *gin.Context) {
+	sessions, err := op.GetSessionsByUser(common.GetUser(c).ID)
+	if err != nil {
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	current := common.GetSessionID(c)
+	for _, s := range sessions {
+		s.Current = s.ID == current
+	}
+	common.SuccessResp(c, sessions)
+}
+
+// RevokeSession 撤销指定会话
+// @Summary 撤销登录会话
+// @Description 撤销当前用户的指定会话，对应的令牌立即失效
+// @Tags 认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param id path string true "会话ID"
+// @Success 200 {object} common.Resp "撤销成功"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 404 {object} common.Resp "会话不存在"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/auth/sessions/{id} [delete]
+func RevokeSession(c *gin.Context) {
+	ok, err := op.RevokeSession(common.GetUser(c).ID, c.Param("id"))
+	if err != nil {
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	if !ok {
+		common.ErrorStrResp(c, http.StatusNotFound, "Session not found")
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RevokeAllSessions 撤销全部会话
+// @Summary 撤销全部登录会话
+// @Description 撤销当前用户的全部会话，keep_current 为 true 时保留当前会话
+// @Tags 认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param keep_current query bool false "是否保留当前会话"
+// @Success 200 {object} common.Resp "撤销成功"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/auth/sessions [delete]
+func RevokeAllSessions(c *gin.Context) {
+	except := ""
+	if c.Query("keep_current") == "true" {
+		except = common.GetSessionID(c)
+	}
+	if err := op.RevokeUserSessions(common.GetUser(c).ID, except); err != nil {
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.SuccessResp(c)
+}
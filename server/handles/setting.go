@@ -8,7 +8,6 @@ import (
 	conf "github.com/FXAZfung/image-board/internal/config"
 	"github.com/FXAZfung/image-board/internal/model"
 	"github.com/FXAZfung/image-board/internal/op"
-	"github.com/FXAZfung/image-board/internal/sign"
 	"github.com/FXAZfung/image-board/pkg/random"
 	"github.com/FXAZfung/image-board/server/common"
 	"github.com/FXAZfung/image-board/server/static"
@@ -31,10 +30,31 @@ func ResetToken(c *gin.Context) {
 		common.ErrorResp(c, http.StatusInternalServerError, err)
 		return
 	}
-	sign.Instance()
 	common.SuccessResp(c, token)
 }
 
+// ResetSignSecret 重置签名密钥
+// @Summary 重置签名密钥
+// @Description 重置用于签名链接的密钥，已签发的签名链接全部失效，不影响管理员 Token
+// @Tags 设置
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Success 200 {object} common.Resp "重置成功"
+// @Failure 401 {object} common.Resp "未授权，需要登录"
+// @Failure 403 {object} common.Resp "需要 manage_settings 权限"
+// @Failure 500 {object} common.Resp "保存失败"
+// @Router /api/setting/sign_secret [post]
+func ResetSignSecret(c *gin.Context) {
+	item := model.SettingItem{Key: conf.SignSecret, Value: random.SecretKey(), Type: conf.TypeString, Group: model.SINGLE, Flag: model.PRIVATE}
+	if err := op.SaveSettingItem(&item); err != nil {
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
 // GetSetting 获取设置
 // @Summary 获取设置
 // @Description 获取设置
@@ -78,6 +98,7 @@ func GetSetting(c *gin.Context) {
 // @Success 200 {object} common.Resp "保存成功"
 // @Failure 400 {object} common.Resp "请求格式错误"
 // @Failure 401 {object} common.Resp "未授权，需要登录"
+// @Failure 403 {object} common.Resp "需要 manage_settings 权限"
 // @Failure 500 {object} common.Resp "保存失败"
 // @Router /api/setting/save [post]
 func SaveSettings(c *gin.Context) {
@@ -107,6 +128,7 @@ func SaveSettings(c *gin.Context) {
 // @Success 200 {object} common.Resp{data=[]model.SettingItem} "设置项列表"
 // @Failure 400 {object} common.Resp "参数格式错误"
 // @Failure 401 {object} common.Resp "未授权，需要登录"
+// @Failure 403 {object} common.Resp "需要 manage_settings 权限"
 // @Failure 500 {object} common.Resp "查询失败"
 // @Router /api/setting/list [get]
 func ListSettings(c *gin.Context) {
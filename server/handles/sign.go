@@ -0,0 +1,122 @@
+package handles
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/FXAZfung/image-board/internal/model/request"
+	"github.com/FXAZfung/image-board/internal/model/response"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/service"
+	"github.com/FXAZfung/image-board/internal/sign"
+	"github.com/FXAZfung/image-board/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	signKindImage     = "image"
+	signKindThumbnail = "thumbnail"
+)
+
+// signPath 签名内容，绑定访问路径与处理参数，签名不能用于其他尺寸或格式
+func signPath(kind, name string, opts *service.TransformOptions) string {
+	p := path.Join("/images", kind, name)
+	if opts != nil {
+		p += "?" + opts.Tag()
+	}
+	return p
+}
+
+// transformQuery 将处理参数还原为链接中的查询参数
+func transformQuery(req request.ImageTransformReq) url.Values {
+	q := url.Values{}
+	if req.Preset != "" {
+		q.Set("preset", req.Preset)
+	}
+	if req.Width != 0 {
+		q.Set("w", strconv.Itoa(req.Width))
+	}
+	if req.Height != 0 {
+		q.Set("h", strconv.Itoa(req.Height))
+	}
+	if req.Fit != "" {
+		q.Set("fit", req.Fit)
+	}
+	if req.Quality != 0 {
+		q.Set("q", strconv.Itoa(req.Quality))
+	}
+	if req.Format != "" {
+		q.Set("fmt", req.Format)
+	}
+	return q
+}
+
+// SignImage 生成图片签名链接
+// @Summary 生成图片签名链接
+// @Description 为当前用户可见的图片（或其缩略图、预设处理结果）生成带有效期的签名链接，持有链接即可访问私有图片（需要登录）
+// @Tags 图片
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "用户令牌"
+// @Param request body request.SignImageReq true "签名参数"
+// @Success 200 {object} common.Resp{data=response.SignImageResponse} "签名链接"
+// @Failure 400 {object} common.Resp "参数错误/处理参数不在允许的预设中"
+// @Failure 401 {object} common.Resp "未授权，需要登录"
+// @Failure 404 {object} common.Resp "图片不存在"
+// @Router /api/image/sign [post]
+func SignImage(c *gin.Context) {
+	var req request.SignImageReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	if req.Expire < 0 {
+		common.ErrorStrResp(c, http.StatusBadRequest, "Invalid expire")
+		return
+	}
+
+	imageData, err := op.GetImageByFileName(req.Name)
+	if err != nil || imageData == nil || !imageData.CanView(common.GetUser(c)) {
+		common.ErrorStrResp(c, http.StatusNotFound, "Image not found")
+		return
+	}
+
+	kind := signKindImage
+	var opts *service.TransformOptions
+	if req.Thumbnail {
+		if !req.ImageTransformReq.IsEmpty() {
+			common.ErrorStrResp(c, http.StatusBadRequest, "Thumbnail links do not accept transform parameters")
+			return
+		}
+		kind = signKindThumbnail
+	} else if !req.ImageTransformReq.IsEmpty() {
+		o, err := service.ResolveTransform(req.ImageTransformReq)
+		if err != nil {
+			common.ErrorResp(c, http.StatusBadRequest, err)
+			return
+		}
+		opts = &o
+	}
+
+	data := signPath(kind, imageData.FileName, opts)
+	var s string
+	if req.Expire == 0 {
+		s = sign.Sign(data)
+	} else {
+		s = sign.WithDuration(data, time.Duration(req.Expire)*time.Second)
+	}
+
+	query := transformQuery(req.ImageTransformReq)
+	if imageData.VariantVersion != 0 {
+		query.Set("v", strconv.Itoa(imageData.VariantVersion))
+	}
+	query.Set("sign", s)
+	common.SuccessResp(c, response.SignImageResponse{
+		URL:       path.Join("/images", kind, imageData.FileName) + "?" + query.Encode(),
+		ExpiresAt: sign.ExpireAt(s),
+	})
+}
@@ -0,0 +1,139 @@
+package handles
+
+import (
+	"net/http"
+	"strings"
+
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/service"
+	"github.com/FXAZfung/image-board/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type SSOURLResp struct {
+	URL string `json:"url" example:"https://accounts.example.com/authorize?client_id=..."` // 提供方授权链接
+}
+
+const (
+	ssoCookieName = "sso_state"
+	ssoCookiePath = "/api/auth/sso"
+	ssoCookieAge  = 10 * 60 // 与授权状态的有效期一致
+)
+
+// setSSOCookie 将授权状态绑定到发起授权的浏览器，回调时校验
+func setSSOCookie(c *gin.Context, value string, maxAge int) {
+	secure := c.Request.TLS != nil || strings.HasPrefix(conf.Conf.SiteURL, "https://")
+	// 提供方回调为顶级跳转，Lax 模式下 cookie 仍会携带
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(ssoCookieName, value, maxAge, ssoCookiePath, "", secure, true)
+}
+
+func ssoAuthURL(c *gin.Context, bindUser *model.User) {
+	url, browser, err := service.SSOAuthURL(c.Request.Context(), bindUser)
+	if err != nil {
+		ssoError(c, err)
+		return
+	}
+	setSSOCookie(c, browser, ssoCookieAge)
+	common.SuccessResp(c, SSOURLResp{URL: url})
+}
+
+func ssoError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errs.ErrSSODisabled):
+		common.ErrorResp(c, http.StatusForbidden, err)
+	case errors.Is(err, errs.ErrSSOState), errors.Is(err, errs.ErrSSONotBound):
+		common.ErrorResp(c, http.StatusUnauthorized, err)
+	case errors.Is(err, errs.ErrSSODomain), errors.Is(err, errs.ErrSSOAlreadyBound), errors.Is(err, errs.ErrPermissionDenied):
+		common.ErrorResp(c, http.StatusForbidden, err)
+	default:
+		common.ErrorResp(c, http.StatusBadRequest, err, true)
+	}
+}
+
+// SSOLogin 发起 SSO 登录
+// @Summary SSO 登录
+// @Description 返回 OpenID Connect 提供方的授权链接，用户在提供方登录后回调 /api/auth/sso/callback。同时设置 sso_state cookie，回调必须在同一浏览器中完成。回调地址为 sso_redirect_uri，未配置时使用配置文件中的 site_url
+// @Tags 认证
+// @Produce json
+// @Success 200 {object} common.Resp{data=SSOURLResp} "授权链接"
+// @Failure 400 {object} common.Resp "提供方配置错误"
+// @Failure 403 {object} common.Resp "未启用 SSO 登录"
+// @Router /api/auth/sso/login [get]
+func SSOLogin(c *gin.Context) {
+	ssoAuthURL(c, nil)
+}
+
+// SSOBind 绑定 SSO 账户
+// @Summary 绑定 SSO 账户
+// @Description 返回 OpenID Connect 提供方的授权链接，回调后将提供方账户绑定到当前用户，之后可通过 SSO 登录该用户。同时设置 sso_state cookie，回调必须在同一浏览器中完成
+// @Tags 认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Success 200 {object} common.Resp{data=SSOURLResp} "授权链接"
+// @Failure 400 {object} common.Resp "提供方配置错误"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 403 {object} common.Resp "未启用 SSO 登录或游客"
+// @Router /api/auth/sso/bind [get]
+func SSOBind(c *gin.Context) {
+	ssoAuthURL(c, common.GetUser(c))
+}
+
+// SSOUnbind 解除绑定 SSO 账户
+// @Summary 解除绑定 SSO 账户
+// @Description 解除当前用户绑定的 SSO 账户
+// @Tags 认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Success 200 {object} common.Resp "解除成功"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/auth/sso/bind [delete]
+func SSOUnbind(c *gin.Context) {
+	if err := service.UnbindSSO(common.GetUser(c)); err != nil {
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// SSOCallback SSO 回调
+// @Summary SSO 回调
+// @Description 校验提供方返回的授权码并登录，首次登录时按配置创建用户。绑定操作返回绑定的用户，登录操作与 /api/auth/login 的响应一致
+// @Tags 认证
+// @Produce json
+// @Param code query string true "授权码"
+// @Param state query string true "状态"
+// @Success 200 {object} common.Resp{data=LoginResp} "登录成功，返回令牌信息"
+// @Failure 400 {object} common.Resp "授权失败"
+// @Failure 401 {object} common.Resp "状态无效、不是发起授权的浏览器或账户未绑定"
+// @Failure 403 {object} common.Resp "邮箱域名不允许或账户已被绑定"
+// @Router /api/auth/sso/callback [get]
+func SSOCallback(c *gin.Context) {
+	if e := c.Query("error"); e != "" {
+		common.ErrorStrResp(c, http.StatusBadRequest, e+": "+c.Query("error_description"))
+		return
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		common.ErrorStrResp(c, http.StatusBadRequest, "Bad request")
+		return
+	}
+	browser, _ := c.Cookie(ssoCookieName)
+	setSSOCookie(c, "", -1)
+	user, bind, err := service.SSOCallback(c.Request.Context(), state, browser, code)
+	if err != nil {
+		ssoError(c, err)
+		return
+	}
+	if bind {
+		common.SuccessResp(c, user)
+		return
+	}
+	completeLogin(c, user)
+}
@@ -169,6 +169,7 @@ func SearchTags(c *gin.Context) {
 // @Success 200 {object} common.Resp{data=model.Tag} "删除结果"
 // @Failure 400 {object} common.Resp "ID格式错误"
 // @Failure 401 {object} common.Resp "未授权"
+// @Failure 403 {object} common.Resp "需要 manage_tags 权限"
 // @Failure 404 {object} common.Resp "标签不存在"
 // @Failure 500 {object} common.Resp "服务器内部错误"
 // @Router /api/tag/delete/{id} [delete]
@@ -216,8 +217,8 @@ func GetTagsByImage(c *gin.Context) {
 	}
 
 	// First check if image exists
-	_, err = op.GetImageByID(uint(imageID))
-	if err != nil {
+	image, err := op.GetImageByID(uint(imageID))
+	if err != nil || !image.CanView(common.GetUser(c)) {
 		common.ErrorResp(c, http.StatusNotFound, errs.ImageNotFound)
 		return
 	}
@@ -0,0 +1,166 @@
+package handles
+
+import (
+	"net/http"
+
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model/response"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/service"
+	"github.com/FXAZfung/image-board/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type OtpCodeReq struct {
+	Code string `json:"code" example:"123456" binding:"required"` // TOTP 验证码或恢复码
+}
+
+type DisableOtpReq struct {
+	Password string `json:"password"`                                 // 当前密码，没有本地密码的 SSO 用户可不填
+	Code     string `json:"code" example:"123456" binding:"required"` // TOTP 验证码或恢复码
+}
+
+// twoFactorError 将两步验证相关错误映射为响应码
+func twoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errs.ErrPermissionDenied):
+		common.ErrorResp(c, http.StatusForbidden, err)
+	case errors.Is(err, errs.ErrOtpCode), errors.Is(err, errs.ErrOtpAlreadyEnabled),
+		errors.Is(err, errs.ErrOtpNotEnrolled), errors.Is(err, errs.ErrOtpNotEnabled),
+		errors.Is(err, errs.ErrUsernameOrPassword):
+		common.ErrorResp(c, http.StatusBadRequest, err)
+	default:
+		common.ErrorResp(c, http.StatusInternalServerError, err)
+	}
+}
+
+// GetTwoFactorStatus 获取两步验证状态
+// @Summary 获取两步验证状态
+// @Description 返回当前用户是否启用两步验证及剩余恢复码数量
+// @Tags 认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Success 200 {object} common.Resp{data=response.TwoFactorStatusResponse} "两步验证状态"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/auth/2fa [get]
+func GetTwoFactorStatus(c *gin.Context) {
+	user := common.GetUser(c)
+	resp := response.TwoFactorStatusResponse{Enabled: user.OtpEnabled}
+	if user.OtpEnabled {
+		count, err := op.CountRecoveryCodes(user.ID)
+		if err != nil {
+			common.ErrorResp(c, http.StatusInternalServerError, err)
+			return
+		}
+		resp.RecoveryCodesRemaining = count
+	}
+	common.SuccessResp(c, resp)
+}
+
+// EnrollTwoFactor 开始绑定两步验证
+// @Summary 绑定两步验证
+// @Description 生成 TOTP 密钥并返回 otpauth 链接，使用验证器应用扫码后调用 /api/auth/2fa/enable 启用
+// @Tags 认证
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Success 200 {object} common.Resp{data=response.TwoFactorEnrollResponse} "密钥与 otpauth 链接"
+// @Failure 400 {object} common.Resp "已启用两步验证"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 403 {object} common.Resp "游客不能启用两步验证"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/auth/2fa/enroll [post]
+func EnrollTwoFactor(c *gin.Context) {
+	secret, uri, err := service.EnrollTwoFactor(common.GetUser(c))
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	common.SuccessResp(c, response.TwoFactorEnrollResponse{Secret: secret, URI: uri})
+}
+
+// EnableTwoFactor 启用两步验证
+// @Summary 启用两步验证
+// @Description 校验验证器应用生成的验证码后启用两步验证，返回的恢复码只展示一次
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param request body OtpCodeReq true "验证码"
+// @Success 200 {object} common.Resp{data=response.RecoveryCodesResponse} "恢复码"
+// @Failure 400 {object} common.Resp "验证码错误或未绑定"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/auth/2fa/enable [post]
+func EnableTwoFactor(c *gin.Context) {
+	var req OtpCodeReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	codes, err := service.EnableTwoFactor(common.GetUser(c), req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	common.SuccessResp(c, response.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTwoFactor 关闭两步验证
+// @Summary 关闭两步验证
+// @Description 校验密码与验证码（或恢复码）后关闭两步验证。LDAP 用户校验目录中的密码，没有本地密码的 SSO 用户只校验验证码
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param request body DisableOtpReq true "密码与验证码"
+// @Success 200 {object} common.Resp "关闭成功"
+// @Failure 400 {object} common.Resp "密码或验证码错误"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/auth/2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	var req DisableOtpReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	if err := service.DisableTwoFactor(common.GetUser(c), req.Password, req.Code); err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+// @Summary 重新生成恢复码
+// @Description 校验验证码后重新生成恢复码，旧的恢复码全部失效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param request body OtpCodeReq true "验证码"
+// @Success 200 {object} common.Resp{data=response.RecoveryCodesResponse} "新的恢复码"
+// @Failure 400 {object} common.Resp "验证码错误或未启用"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 500 {object} common.Resp "服务器错误"
+// @Router /api/auth/2fa/recovery_codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req OtpCodeReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+	codes, err := service.RegenerateRecoveryCodes(common.GetUser(c), req.Code)
+	if err != nil {
+		twoFactorError(c, err)
+		return
+	}
+	common.SuccessResp(c, response.RecoveryCodesResponse{RecoveryCodes: codes})
+}
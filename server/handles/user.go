@@ -3,9 +3,13 @@ package handles
 import (
 	"github.com/FXAZfung/image-board/internal/errs"
 	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/request"
+	"github.com/FXAZfung/image-board/internal/model/response"
 	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/service"
 	"github.com/FXAZfung/image-board/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	"net/http"
 	"strconv"
 )
@@ -13,44 +17,46 @@ import (
 type RegisterReq struct {
 	Username string `json:"username" binding:"required" example:"newuser"`     // 用户名
 	Password string `json:"password" binding:"required" example:"password123"` // 密码
-	Role     int    `json:"role" example:"1"`                                  // 角色：2-管理员，0-普通用户 1-游客（只能存在一个）
+	Role     int    `json:"role" example:"0"`                                  // 角色：只能创建普通用户（0），管理员与游客均只存在一个
 }
 
-type UpdateUserReq struct {
-	Password string `json:"password" example:"newpassword123"` // 新密码
-	Role     *int   `json:"role" example:"1"`                  // 角色
-	Disable  *bool  `json:"disable" example:"true"`            // 禁用
+type ChangePasswordReq struct {
+	OldPassword string `json:"old_password" binding:"required" example:"password123"`    // 旧密码
+	NewPassword string `json:"new_password" binding:"required" example:"newpassword123"` // 新密码
 }
 
 // Register 注册用户
 // @Summary 注册新用户
-// @Description 创建新用户账号
+// @Description 创建新的普通用户账号（需要 manage_users 权限），默认拥有上传与删除自己图片的权限。管理员与游客均只存在一个，不能创建
 // @Tags 用户
 // @Accept json
 // @Produce json
 // @Param user body RegisterReq true "用户信息"
 // @Success 200 {object} common.Resp{data=model.User} "注册成功"
 // @Failure 403 {object} common.Resp "需要管理权限"
-// @Failure 400 {object} common.Resp "参数错误"
+// @Failure 400 {object} common.Resp "参数错误或角色无效"
 // @Failure 409 {object} common.Resp "用户已存在"
-// @Router /api/public/register [post]
+// @Security ApiKeyAuth
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Router /api/auth/users [post]
 func Register(c *gin.Context) {
-	// 检查权限
-	currentUser, _ := c.Get("user")
-	if currentUser.(*model.User).Role != model.ADMIN {
-		common.ErrorStrResp(c, http.StatusForbidden, "需要管理员权限")
-		return
-	}
 	var req RegisterReq
 	if err := c.ShouldBind(&req); err != nil {
 		common.ErrorResp(c, http.StatusBadRequest, err)
 		return
 	}
 
+	// 管理员与游客均只存在一个，不能再创建
+	if req.Role != model.GENERAL {
+		common.ErrorResp(c, http.StatusBadRequest, errs.ErrInvalidRole)
+		return
+	}
+
 	// 创建用户模型
 	user := &model.User{
-		Username: req.Username,
-		Role:     req.Role,
+		Username:   req.Username,
+		Role:       model.GENERAL,
+		Permission: model.DefaultPermission,
 	}
 
 	// 设置密码哈希
@@ -66,38 +72,72 @@ func Register(c *gin.Context) {
 		return
 	}
 
-	// 清除密码哈希后返回用户信息
-	user.PwdHash = ""
 	common.SuccessResp(c, user)
 }
 
 // GetUserInfo 获取当前用户信息
 // @Summary 获取当前登录用户信息
-// @Description 获取当前登录用户的详细信息
+// @Description 获取当前登录用户的详细信息与拥有的权限
 // @Tags 认证
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Bearer 用户令牌"
-// @Success 200 {object} common.Resp{data=model.User} "用户信息"
+// @Success 200 {object} common.Resp{data=response.UserInfoResponse} "用户信息"
 // @Failure 401 {object} common.Resp "未授权"
 // @Security ApiKeyAuth
-// @Router /api/auth/user/info [get]
+// @Router /api/auth/me [get]
 func GetUserInfo(c *gin.Context) {
-	user, exists := c.Get("user")
-	if !exists {
+	user := common.GetUser(c)
+	if user == nil {
 		common.ErrorStrResp(c, http.StatusUnauthorized, "未登录")
 		return
 	}
 
-	// 返回当前用户信息，不包含密码
-	userData := user.(*model.User)
-	userData.PwdHash = ""
-	common.SuccessResp(c, userData)
+	common.SuccessResp(c, response.UserInfoResponse{
+		User:        user,
+		Permissions: user.Permissions(),
+	})
+}
+
+// ChangePassword 修改当前用户密码
+// @Summary 修改密码
+// @Description 校验旧密码后修改当前登录用户的密码，其他登录会话全部失效
+// @Tags 认证
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Bearer 用户令牌"
+// @Param request body ChangePasswordReq true "密码信息"
+// @Success 200 {object} common.Resp "修改成功"
+// @Failure 400 {object} common.Resp "参数错误或旧密码错误"
+// @Failure 401 {object} common.Resp "未授权"
+// @Failure 403 {object} common.Resp "游客不能修改密码"
+// @Security ApiKeyAuth
+// @Router /api/auth/me/password [post]
+func ChangePassword(c *gin.Context) {
+	var req ChangePasswordReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := service.ChangePassword(common.GetUser(c), req.OldPassword, req.NewPassword, common.GetSessionID(c)); err != nil {
+		switch {
+		case errors.Is(err, errs.ErrPermissionDenied):
+			common.ErrorResp(c, http.StatusForbidden, err)
+		case errors.Is(err, errs.ErrUsernameOrPassword):
+			common.ErrorResp(c, http.StatusBadRequest, err)
+		default:
+			common.ErrorResp(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	common.SuccessResp(c)
 }
 
 // GetUserByID 根据ID获取用户
 // @Summary 根据ID获取用户信息
-// @Description 根据用户ID获取用户详细信息（需要管理员权限）
+// @Description 根据用户ID获取用户详细信息（需要 manage_users 权限）
 // @Tags 认证
 // @Accept json
 // @Produce json
@@ -110,13 +150,6 @@ func GetUserInfo(c *gin.Context) {
 // @Security ApiKeyAuth
 // @Router /api/auth/users/{id} [get]
 func GetUserByID(c *gin.Context) {
-	// 检查权限
-	currentUser, _ := c.Get("user")
-	if currentUser.(*model.User).Role != model.ADMIN {
-		common.ErrorStrResp(c, http.StatusForbidden, "需要管理员权限")
-		return
-	}
-
 	// 解析ID
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -132,14 +165,12 @@ func GetUserByID(c *gin.Context) {
 		return
 	}
 
-	// 返回用户信息，不包含密码
-	user.PwdHash = ""
 	common.SuccessResp(c, user)
 }
 
 // ListUsers 获取用户列表
 // @Summary 分页获取用户列表
-// @Description 分页获取所有用户（需要管理员权限）
+// @Description 分页获取所有用户（需要 manage_users 权限）
 // @Tags 认证
 // @Accept json
 // @Produce json
@@ -152,13 +183,6 @@ func GetUserByID(c *gin.Context) {
 // @Security ApiKeyAuth
 // @Router /api/auth/users [get]
 func ListUsers(c *gin.Context) {
-	// 检查权限
-	currentUser, _ := c.Get("user")
-	if currentUser.(*model.User).Role != model.ADMIN {
-		common.ErrorStrResp(c, http.StatusForbidden, "需要管理员权限")
-		return
-	}
-
 	var req model.PageReq
 	if err := c.ShouldBind(&req); err != nil {
 		common.ErrorResp(c, 400, err)
@@ -173,11 +197,6 @@ func ListUsers(c *gin.Context) {
 		return
 	}
 
-	// 清除敏感信息
-	for i := range users {
-		users[i].PwdHash = ""
-	}
-
 	common.SuccessResp(c, common.PageResp{
 		Content: users,
 		Total:   total,
@@ -186,12 +205,12 @@ func ListUsers(c *gin.Context) {
 
 // UpdateUser 更新用户信息
 // @Summary 更新用户信息
-// @Description 更新指定用户的信息（需要管理员权限或为自己的账号）
+// @Description 更新指定用户的信息（需要 manage_users 权限，非管理员只能修改权限不超过自己的用户，只能授予/撤销自己拥有的权限，修改自己的密码请使用 /api/auth/me/password。重置密码或禁用后该用户的全部会话与 API 密钥失效）
 // @Tags 认证
 // @Accept json
 // @Produce json
 // @Param id path int true "用户ID" minimum(1)
-// @Param user body UpdateUserReq true "用户信息"
+// @Param user body request.UpdateUserReq true "用户信息"
 // @Param Authorization header string true "Bearer 用户令牌"
 // @Success 200 {object} common.Resp{data=model.User} "更新后的用户信息"
 // @Failure 400 {object} common.Resp "参数错误"
@@ -201,8 +220,7 @@ func ListUsers(c *gin.Context) {
 // @Router /api/auth/users/{id} [put]
 func UpdateUser(c *gin.Context) {
 	// 获取当前用户
-	currentUser, _ := c.Get("user")
-	currentUserData := currentUser.(*model.User)
+	currentUserData := common.GetUser(c)
 
 	// 解析目标用户ID
 	idStr := c.Param("id")
@@ -212,69 +230,47 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
-	// 检查权限（只能更新自己或管理员更新任何人）
-	if currentUserData.ID != uint(id) && currentUserData.Role != model.ADMIN {
-		common.ErrorStrResp(c, http.StatusForbidden, "无权限修改其他用户")
-		return
-	}
-
-	// 获取用户
-	user, err := op.GetUserById(uint(id))
-	if err != nil {
-		common.ErrorResp(c, http.StatusNotFound, err)
-		return
-	}
-
 	// 解析请求
-	var req UpdateUserReq
+	var req request.UpdateUserReq
 	if err := c.ShouldBind(&req); err != nil {
 		common.ErrorResp(c, http.StatusBadRequest, err)
 		return
 	}
 
-	// 更新密码
-	if req.Password != "" {
-		user.SetPassword(req.Password)
-	}
-
-	// 更新角色（只有管理员能更改角色）
-	if req.Role != nil && currentUserData.Role == model.ADMIN {
-		user.Role = *req.Role
-	}
-
-	// 保存更新
-	if err := op.UpdateUser(user); err != nil {
-		common.ErrorResp(c, http.StatusInternalServerError, err)
+	user, err := service.UpdateUser(currentUserData, uint(id), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, errs.ErrNotFound):
+			common.ErrorResp(c, http.StatusNotFound, err)
+		case errors.Is(err, errs.ErrPermissionDenied):
+			common.ErrorResp(c, http.StatusForbidden, err)
+		case errors.Is(err, errs.ErrInvalidRole), errors.Is(err, errs.ErrUnknownPermission):
+			common.ErrorResp(c, http.StatusBadRequest, err)
+		default:
+			common.ErrorResp(c, http.StatusInternalServerError, err)
+		}
 		return
 	}
 
-	// 返回更新后的用户，不包含密码
-	user.PwdHash = ""
 	common.SuccessResp(c, user)
 }
 
 // DeleteUser 删除用户
 // @Summary 删除用户
-// @Description 删除指定用户（需要管理员权限）
+// @Description 删除指定用户（需要 manage_users 权限，非管理员只能删除权限不超过自己的用户），其上传的图片默认转移给管理员，也可一并删除
 // @Tags 认证
 // @Accept json
 // @Produce json
 // @Param id path int true "用户ID" minimum(1)
+// @Param images query string false "图片处理方式" Enums(reassign, delete) default(reassign)
 // @Param Authorization header string true "Bearer 用户令牌"
 // @Success 200 {object} common.Resp "删除成功"
-// @Failure 400 {object} common.Resp "ID格式错误"
-// @Failure 403 {object} common.Resp "权限不足"
+// @Failure 400 {object} common.Resp "ID格式错误或图片处理方式无效"
+// @Failure 403 {object} common.Resp "权限不足、目标用户拥有自己没有的权限或删除内置用户"
 // @Failure 404 {object} common.Resp "用户不存在"
 // @Security ApiKeyAuth
 // @Router /api/auth/users/{id} [delete]
 func DeleteUser(c *gin.Context) {
-	// 检查权限
-	currentUser, _ := c.Get("user")
-	if currentUser.(*model.User).Role != model.ADMIN {
-		common.ErrorStrResp(c, http.StatusForbidden, "需要管理员权限")
-		return
-	}
-
 	// 解析ID
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -283,15 +279,24 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
+	mode := c.DefaultQuery("images", service.UserImagesReassign)
+	if mode != service.UserImagesReassign && mode != service.UserImagesDelete {
+		common.ErrorStrResp(c, http.StatusBadRequest, "无效的图片处理方式")
+		return
+	}
+
 	// 检查用户是否存在
-	_, err = op.GetUserById(uint(id))
-	if err != nil {
+	if _, err := op.GetUserById(uint(id)); err != nil {
 		common.ErrorResp(c, http.StatusNotFound, err)
 		return
 	}
 
 	// 删除用户
-	if err := op.DeleteUser(uint(id)); err != nil {
+	if err := service.DeleteUser(common.GetUser(c), uint(id), mode); err != nil {
+		if errors.Is(err, errs.ErrBuiltinUserDelete) || errors.Is(err, errs.ErrPermissionDenied) {
+			common.ErrorResp(c, http.StatusForbidden, err)
+			return
+		}
 		common.ErrorResp(c, http.StatusInternalServerError, err)
 		return
 	}
@@ -301,7 +306,7 @@ func DeleteUser(c *gin.Context) {
 
 // GetUserCount 获取用户总数
 // @Summary 获取用户总数
-// @Description 获取系统中的用户总数（需要管理员权限）
+// @Description 获取系统中的用户总数（需要 manage_users 权限）
 // @Tags 认证
 // @Produce json
 // @Param Authorization header string true "Bearer 用户令牌"
@@ -311,13 +316,6 @@ func DeleteUser(c *gin.Context) {
 // @Security ApiKeyAuth
 // @Router /api/auth/users/count [get]
 func GetUserCount(c *gin.Context) {
-	// 检查权限
-	currentUser, _ := c.Get("user")
-	if currentUser.(*model.User).Role != model.ADMIN {
-		common.ErrorStrResp(c, http.StatusForbidden, "需要管理员权限")
-		return
-	}
-
 	// 获取用户总数
 	count, err := op.GetUserCount()
 	if err != nil {
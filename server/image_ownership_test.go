@@ -0,0 +1,66 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
+)
+
+// TestImageOwnership 删除与修改标签需要是上传者，或拥有 delete_any、manage_tags 权限
+func TestImageOwnership(t *testing.T) {
+	createTestUser(t, "own_owner", model.DefaultPermission)
+	createTestUser(t, "own_other", model.DefaultPermission)
+	createTestUser(t, "own_tagger", model.DefaultPermission|model.PermManageTags)
+	createTestUser(t, "own_deleter", model.DefaultPermission|model.PermDeleteAny)
+	owner := login(t, "own_owner", "own_owner").Token
+	other := login(t, "own_other", "own_other").Token
+	tagger := login(t, "own_tagger", "own_tagger").Token
+	deleter := login(t, "own_deleter", "own_deleter").Token
+
+	img := uploadTestImage(t, owner)
+	addTag := func(token, tag string) *testResp {
+		return call(t, http.MethodPost, "/api/image/tag/add", token, map[string]any{"id": img.ID, "tag": tag})
+	}
+	removeTag := func(token string, tagID uint) *testResp {
+		return call(t, http.MethodPost, "/api/image/tag/remove", token, map[string]any{"image_id": img.ID, "tag_id": tagID})
+	}
+	deleteImage := func(token string, id uint) *testResp {
+		return call(t, http.MethodPost, "/api/image/delete", token, map[string]any{"id": id})
+	}
+
+	t.Run("tags", func(t *testing.T) {
+		expectCode(t, addTag("", "own_anon"), http.StatusUnauthorized)
+		expectCode(t, addTag(other, "own_other"), http.StatusForbidden)
+
+		resp := addTag(owner, "own_owner")
+		expectCode(t, resp, http.StatusOK)
+		var tag model.Tag
+		resp.decode(t, &tag)
+		expectCode(t, removeTag(other, tag.ID), http.StatusForbidden)
+		expectCode(t, addTag(tagger, "own_tagger"), http.StatusOK)
+		expectCode(t, removeTag(tagger, tag.ID), http.StatusOK)
+	})
+
+	t.Run("private", func(t *testing.T) {
+		// 无权查看的私有图片与不存在一样
+		private := uploadTestImage(t, owner)
+		setImagePublic(t, private, false)
+		expectCode(t, deleteImage(other, private.ID), http.StatusNotFound)
+		expectCode(t, call(t, http.MethodPost, "/api/image/tag/add", tagger, map[string]any{"id": private.ID, "tag": "own_private"}), http.StatusNotFound)
+		expectCode(t, deleteImage(owner, private.ID), http.StatusOK)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		expectCode(t, deleteImage(other, img.ID), http.StatusForbidden)
+		expectCode(t, deleteImage(tagger, img.ID), http.StatusForbidden)
+		if _, err := op.GetImageByID(img.ID); err != nil {
+			t.Fatalf("image deleted by a user without permission: %v", err)
+		}
+		expectCode(t, deleteImage(deleter, img.ID), http.StatusOK)
+		if _, err := op.GetImageByID(img.ID); err == nil {
+			t.Fatal("image still exists after delete")
+		}
+	})
+}
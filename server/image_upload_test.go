@@ -0,0 +1,113 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
+)
+
+// multipartImage 构造以 image 字段上传指定 PNG 内容的请求，fields 为其他表单字段
+func multipartImage(t *testing.T, path, filename string, data []byte, fields map[string]string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		_ = mw.WriteField(k, v)
+	}
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", `form-data; name="image"; filename="`+filename+`"`)
+	h.Set("Content-Type", "image/png")
+	fw, err := mw.CreatePart(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fw.Write(data)
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func setSetting(t *testing.T, key, value string) {
+	t.Helper()
+	item, err := op.GetSettingItemByKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	item.Value = value
+	if err := op.SaveSettingItem(item); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestImageMaxPixels 文件很小但像素数超出限制的图片在解码前被拒绝
+func TestImageMaxPixels(t *testing.T) {
+	createTestUser(t, "pixels_user", model.DefaultPermission)
+	token := login(t, "pixels_user", "pixels_user").Token
+
+	// 纯色图片压缩后只有几 KB，解码后的位图约 8 MB
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4000, 2000))); err != nil {
+		t.Fatal(err)
+	}
+	setSetting(t, conf.ImageMaxPixels, "1")
+	defer setSetting(t, conf.ImageMaxPixels, "100")
+
+	expectCode(t, serve(t, multipartImage(t, "/api/image/upload", "pixels.png", buf.Bytes(), nil), token), http.StatusRequestEntityTooLarge)
+	expectCode(t, serve(t, multipartImage(t, "/api/image/search/similar", "pixels.png", buf.Bytes(), nil), token), http.StatusRequestEntityTooLarge)
+	// 未超出限制的图片不受影响
+	expectCode(t, serve(t, multipartImage(t, "/api/image/upload", "small.png", testPNG(t), nil), token), http.StatusOK)
+}
+
+// xmpPNG 在 testPNG 中插入带作者的 XMP 块
+func xmpPNG(t *testing.T) []byte {
+	t.Helper()
+	data := testPNG(t)
+	xmp := append([]byte("XML:com.adobe.xmp"), 0, 0, 0, 0, 0)
+	xmp = append(xmp, `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:creator><rdf:Seq><rdf:li>Alice</rdf:li></rdf:Seq></dc:creator></rdf:Description>
+</rdf:RDF></x:xmpmeta>`...)
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(xmp)))
+	chunk = append(append(chunk, "iTXt"...), xmp...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	// 签名与 IHDR 之后插入
+	return append(append(append([]byte{}, data[:33]...), chunk...), data[33:]...)
+}
+
+// TestStripDuplicate 按去除元数据后的内容去重，以更严格的程度重新上传时不会得到保留了更多元数据的副本
+func TestStripDuplicate(t *testing.T) {
+	createTestUser(t, "strip_user", model.DefaultPermission)
+	token := login(t, "strip_user", "strip_user").Token
+	data := xmpPNG(t)
+	upload := func(level string) *testResp {
+		return serve(t, multipartImage(t, "/api/image/upload", "strip.png", data, map[string]string{"strip_metadata": level}), token)
+	}
+	stored := func(resp *testResp) []byte {
+		t.Helper()
+		expectCode(t, resp, http.StatusOK)
+		var img model.Image
+		resp.decode(t, &img)
+		file := call(t, http.MethodGet, "/images/image/"+img.FileName, token, nil)
+		expectCode(t, file, http.StatusOK)
+		return file.body
+	}
+
+	if !bytes.Contains(stored(upload("gps")), []byte("Alice")) {
+		t.Fatal("gps level removed the author")
+	}
+	if bytes.Contains(stored(upload("all")), []byte("Alice")) {
+		t.Fatal("stricter level returned the copy that still has the author")
+	}
+	expectCode(t, upload("all"), http.StatusConflict)
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
+)
+
+type testPage struct {
+	Content []model.Image `json:"content"`
+	Total   int64         `json:"total"`
+}
+
+func containsImage(images []model.Image, id uint) bool {
+	for _, img := range images {
+		if img.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// TestPrivateImageVisibility 私有图片只对上传者与拥有 view_private 权限的用户可见，列表缓存按访问者区分
+func TestPrivateImageVisibility(t *testing.T) {
+	createTestUser(t, "vis_owner", model.DefaultPermission)
+	createTestUser(t, "vis_other", model.DefaultPermission)
+	createTestUser(t, "vis_viewer", model.DefaultPermission|model.PermViewPrivate)
+	ownerToken := login(t, "vis_owner", "vis_owner").Token
+	viewers := []struct {
+		name    string
+		token   string
+		visible bool
+	}{
+		// 上传者先访问，缓存的列表不能返回给其他访问者
+		{"owner", ownerToken, true},
+		{"anonymous", "", false},
+		{"other", login(t, "vis_other", "vis_other").Token, false},
+		{"view_private", login(t, "vis_viewer", "vis_viewer").Token, true},
+		{"admin", loginAdmin(t), true},
+	}
+
+	private := uploadTestImage(t, ownerToken)
+	public := uploadTestImage(t, ownerToken)
+	setImagePublic(t, private, false)
+	if _, err := op.AddTagToImage(private.ID, "vis_tag"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := op.AddTagToImage(public.ID, "vis_tag"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range viewers {
+		t.Run(v.name, func(t *testing.T) {
+			resp := call(t, http.MethodPost, "/api/image/list", v.token, model.PageReq{Page: 1, PerPage: 100})
+			expectCode(t, resp, http.StatusOK)
+			var page testPage
+			resp.decode(t, &page)
+			if containsImage(page.Content, private.ID) != v.visible || !containsImage(page.Content, public.ID) {
+				t.Errorf("list: private visible = %v, want %v", !v.visible, v.visible)
+			}
+
+			resp = call(t, http.MethodPost, "/api/image/tag/list?tag=vis_tag", v.token, model.PageReq{Page: 1, PerPage: 100})
+			expectCode(t, resp, http.StatusOK)
+			page = testPage{}
+			resp.decode(t, &page)
+			want := int64(1)
+			if v.visible {
+				want = 2
+			}
+			if page.Total != want || containsImage(page.Content, private.ID) != v.visible {
+				t.Errorf("tag list: total = %d, want %d", page.Total, want)
+			}
+
+			code := http.StatusNotFound
+			if v.visible {
+				code = http.StatusOK
+			}
+			resp = call(t, http.MethodGet, "/api/image/info/"+itoa(private.ID), v.token, nil)
+			expectCode(t, resp, code)
+			for _, path := range []string{"/images/image/", "/images/thumbnail/"} {
+				resp = call(t, http.MethodGet, path+private.FileName, v.token, nil)
+				expectCode(t, resp, code)
+				if v.visible && resp.header.Get("Cache-Control") != "private, no-cache" {
+					t.Errorf("%s: Cache-Control = %q, private images must not be cached by shared caches", path, resp.header.Get("Cache-Control"))
+				}
+			}
+		})
+	}
+
+	t.Run("revoke", func(t *testing.T) {
+		// 公开图片的缓存过期后需要重新验证，改为私有后共享缓存不能继续使用
+		img := uploadTestImage(t, ownerToken)
+		resp := call(t, http.MethodGet, "/images/image/"+img.FileName, "", nil)
+		expectCode(t, resp, http.StatusOK)
+		if cc := resp.header.Get("Cache-Control"); strings.Contains(cc, "immutable") || !strings.Contains(cc, "must-revalidate") {
+			t.Fatalf("Cache-Control = %q, public images must be revalidated", cc)
+		}
+		etag := resp.header.Get("ETag")
+		revalidate := func() *testResp {
+			req := httptest.NewRequest(http.MethodGet, "/images/image/"+img.FileName, nil)
+			req.Header.Set("If-None-Match", etag)
+			return serve(t, req, "")
+		}
+		expectCode(t, revalidate(), http.StatusNotModified)
+		setImagePublic(t, img, false)
+		expectCode(t, revalidate(), http.StatusNotFound)
+	})
+
+	t.Run("random", func(t *testing.T) {
+		for _, v := range viewers {
+			if v.visible {
+				continue
+			}
+			for i := 0; i < 5; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/images/image/random", nil)
+				req.RemoteAddr = "192.0.2." + itoa(uint(10+i)) + ":1234"
+				resp := serve(t, req, v.token)
+				if resp.Code == http.StatusOK && resp.header.Get("ETag") == `"`+private.Hash+`"` {
+					t.Fatalf("%s: random returned a private image", v.name)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/FXAZfung/image-board/cmd/flags"
+	"github.com/FXAZfung/image-board/internal/db"
+	"github.com/FXAZfung/image-board/internal/initialize"
+	"github.com/FXAZfung/image-board/internal/initialize/data"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/pkg/utils"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const testAdminPassword = "admin-pass"
+
+var testRouter *gin.Engine
+
+// TestMain 在临时目录中使用 sqlite 初始化完整的服务，各测试通过路由发起请求
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "image-board-server-test-*")
+	if err != nil {
+		panic(err)
+	}
+	flags.DataDir = dir
+	log.SetLevel(log.ErrorLevel)
+	utils.Log.SetLevel(log.ErrorLevel)
+	gin.SetMode(gin.TestMode)
+	_ = os.Setenv("IMAGE_BOARD_ADMIN_PASSWORD", testAdminPassword)
+
+	initialize.InitConfig()
+	initialize.InitDB()
+	initialize.InitStorage()
+	data.InitData()
+	testRouter = gin.New()
+	Init(testRouter)
+
+	code := m.Run()
+	db.Close()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// testResp 解析后的响应，code 为响应体中的状态码
+type testResp struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	header  http.Header
+	body    []byte
+}
+
+func (r *testResp) decode(t *testing.T, v any) {
+	t.Helper()
+	if err := json.Unmarshal(r.Data, v); err != nil {
+		t.Fatalf("decode %s: %v", r.Data, err)
+	}
+}
+
+// serve 发起请求，Authorization 为空时匿名访问
+func serve(t *testing.T, req *http.Request, token string) *testResp {
+	t.Helper()
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+	resp := &testResp{Code: w.Code, header: w.Header(), body: w.Body.Bytes()}
+	if w.Header().Get("Content-Type") == "application/json; charset=utf-8" && w.Body.Len() > 0 {
+		if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+			t.Fatalf("%s %s: decode response: %v", req.Method, req.URL, err)
+		}
+	}
+	return resp
+}
+
+// call 以 JSON 请求体调用接口，body 为 nil 时不带请求体
+func call(t *testing.T, method, path, token string, body any) *testResp {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = bytes.NewReader(b)
+	}
+	req := httptest.NewRequest(method, path, r)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return serve(t, req, token)
+}
+
+func expectCode(t *testing.T, resp *testResp, code int) {
+	t.Helper()
+	if resp.Code != code {
+		t.Fatalf("code = %d, want %d: %s", resp.Code, code, resp.Message)
+	}
+}
+
+// createTestUser 直接创建普通用户，密码与用户名相同
+func createTestUser(t *testing.T, name string, perm int32) *model.User {
+	t.Helper()
+	user := &model.User{Username: name, Role: model.GENERAL, Permission: perm}
+	user.SetPassword(name)
+	if err := op.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+type testTokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func login(t *testing.T, username, password string) testTokens {
+	t.Helper()
+	resp := call(t, http.MethodPost, "/api/auth/login", "", map[string]string{"username": username, "password": password})
+	expectCode(t, resp, http.StatusOK)
+	var tokens testTokens
+	resp.decode(t, &tokens)
+	return tokens
+}
+
+func loginAdmin(t *testing.T) string {
+	return login(t, "admin", testAdminPassword).Token
+}
+
+func itoa(n uint) string {
+	return strconv.FormatUint(uint64(n), 10)
+}
+
+var imageSeed atomic.Int32
+
+// testPNG 生成内容各不相同的 PNG 图片
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+	seed := byte(imageSeed.Add(1))
+	img := image.NewRGBA(image.Rect(0, 0, 48, 32))
+	for y := 0; y < 32; y++ {
+		for x := 0; x < 48; x++ {
+			img.Set(x, y, color.RGBA{R: byte(x*5) ^ seed, G: byte(y*7) + seed*13, B: byte(x*y) ^ seed*31, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testMultipartImage 构造上传一张新图片的请求
+func testMultipartImage(t *testing.T) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="image"; filename="test-%d.png"`, imageSeed.Load()+1))
+	h.Set("Content-Type", "image/png")
+	fw, err := mw.CreatePart(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = fw.Write(testPNG(t))
+	_ = mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/api/image/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+// uploadTestImage 通过上传接口上传一张新图片
+func uploadTestImage(t *testing.T, token string) *model.Image {
+	t.Helper()
+	resp := serve(t, testMultipartImage(t), token)
+	expectCode(t, resp, http.StatusOK)
+	var img model.Image
+	resp.decode(t, &img)
+	return &img
+}
+
+// setImagePublic 修改图片可见性，接口未提供该操作
+func setImagePublic(t *testing.T, img *model.Image, public bool) {
+	t.Helper()
+	stored, err := op.GetImageByID(img.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated := *stored
+	updated.IsPublic = public
+	if err := op.UpdateImage(&updated); err != nil {
+		t.Fatal(err)
+	}
+}
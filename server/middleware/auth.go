@@ -2,60 +2,142 @@ package middleware
 
 import (
 	"crypto/subtle"
+	"net/http"
+	"strings"
+
 	"github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
 	"github.com/FXAZfung/image-board/internal/op"
 	"github.com/FXAZfung/image-board/internal/setting"
 	"github.com/FXAZfung/image-board/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
-	"net/http"
 )
 
+// identity 认证得到的身份，使用 API 密钥时 user 为按作用域限制权限后的副本
+type identity struct {
+	user      *model.User
+	sessionID string
+	apiKey    *model.APIKey
+}
+
 func AuthMiddleware(c *gin.Context) {
+	id, code, err := authenticate(c.GetHeader("Authorization"))
+	if err != nil {
+		common.ErrorResp(c, code, err)
+		c.Abort()
+		return
+	}
+	setIdentity(c, id)
+	c.Next()
+}
+
+// OptionalAuthMiddleware 携带有效令牌时注入当前用户，否则以匿名身份继续
+func OptionalAuthMiddleware(c *gin.Context) {
 	token := c.GetHeader("Authorization")
+	if token == "" {
+		c.Next()
+		return
+	}
+	id, _, err := authenticate(token)
+	if err != nil {
+		log.Debugf("ignore invalid token: %v", err)
+		c.Next()
+		return
+	}
+	setIdentity(c, id)
+	c.Next()
+}
+
+func setIdentity(c *gin.Context, id *identity) {
+	c.Set("user", id.user)
+	if id.sessionID != "" {
+		c.Set("session_id", id.sessionID)
+	}
+	if id.apiKey != nil {
+		c.Set("api_key", id.apiKey)
+	}
+}
+
+// RequirePermission 要求当前用户拥有全部指定权限，需在 AuthMiddleware 之后使用
+func RequirePermission(perms ...int32) gin.HandlerFunc {
+	var need int32
+	for _, p := range perms {
+		need |= p
+	}
+	return func(c *gin.Context) {
+		user := common.GetUser(c)
+		if user == nil || !user.HasPermission(need) {
+			common.ErrorResp(c, http.StatusForbidden, errs.ErrPermissionDenied)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope 使用 API 密钥访问时要求密钥拥有全部指定作用域，其他认证方式直接放行
+func RequireScope(scopes ...int32) gin.HandlerFunc {
+	var need int32
+	for _, s := range scopes {
+		need |= s
+	}
+	return func(c *gin.Context) {
+		if key := common.GetAPIKey(c); key != nil && !key.HasScope(need) {
+			common.ErrorResp(c, http.StatusForbidden, errs.ErrAPIKeyScope)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RejectAPIKey 拒绝使用 API 密钥访问，用于账户、会话及密钥自身的管理接口
+func RejectAPIKey(c *gin.Context) {
+	if common.GetAPIKey(c) != nil {
+		common.ErrorResp(c, http.StatusForbidden, errs.ErrAPIKeyScope)
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// authenticate 根据令牌获取用户身份，失败时返回对应的状态码
+func authenticate(token string) (*identity, int, error) {
+	if strings.HasPrefix(token, model.APIKeyPrefix) {
+		return authenticateAPIKey(token)
+	}
 	// 判断使用的是admin token还是普通token
 	if subtle.ConstantTimeCompare([]byte(token), []byte(setting.GetStr(config.Token))) == 1 {
 		admin, err := op.GetAdmin()
 		if err != nil {
-			common.ErrorResp(c, http.StatusInternalServerError, err)
-			c.Abort()
-			return
+			return nil, http.StatusInternalServerError, err
 		}
-		c.Set("user", admin)
 		log.Debugf("use admin token: %+v", admin)
-		c.Next()
-		return
+		return &identity{user: admin}, 0, nil
 	}
 	// 判断是否使用的是空token
 	if token == "" {
 		guest, err := op.GetGuest()
 		if err != nil {
-			common.ErrorResp(c, http.StatusInternalServerError, err)
-			c.Abort()
-			return
+			return nil, http.StatusInternalServerError, err
 		}
 		if guest.Disabled {
-			common.ErrorStrResp(c, http.StatusUnauthorized, "Guest user is disabled, login please")
-			c.Abort()
-			return
+			return nil, http.StatusUnauthorized, errors.New("Guest user is disabled, login please")
 		}
-		c.Set("user", guest)
 		log.Debugf("use empty token: %+v", guest)
-		c.Next()
-		return
+		return &identity{user: guest}, 0, nil
 	}
 	// 获取用户信息
 	userClaims, err := common.ParseToken(token)
 	if err != nil {
-		common.ErrorResp(c, http.StatusUnauthorized, err)
-		c.Abort()
-		return
+		return nil, http.StatusUnauthorized, err
 	}
 	user, err := op.GetUserByName(userClaims.Username)
 	if err != nil {
-		common.ErrorResp(c, http.StatusUnauthorized, err)
-		c.Abort()
-		return
+		return nil, http.StatusUnauthorized, err
 	}
 	// validate password timestamp
 	//if userClaims.PwdTS != user.PwdTS {
@@ -64,11 +146,26 @@ func AuthMiddleware(c *gin.Context) {
 	//	return
 	//}
 	if user.Disabled {
-		common.ErrorStrResp(c, http.StatusUnauthorized, "Current user is disabled, replace please")
-		c.Abort()
-		return
+		return nil, http.StatusUnauthorized, errors.New("Current user is disabled, replace please")
 	}
-	c.Set("user", user)
 	log.Debugf("use login token: %+v", user)
-	c.Next()
+	return &identity{user: user, sessionID: userClaims.ID}, 0, nil
+}
+
+// authenticateAPIKey 使用 API 密钥认证，用户权限被限制在密钥作用域内
+func authenticateAPIKey(token string) (*identity, int, error) {
+	key, err := op.GetAPIKey(token)
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+	user, err := op.GetUserById(key.UserID)
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+	if user.Disabled {
+		return nil, http.StatusUnauthorized, errors.New("Current user is disabled, replace please")
+	}
+	op.TouchAPIKey(key)
+	log.Debugf("use api key %s of user %s", key.Prefix, user.Username)
+	return &identity{user: user.Restrict(key.Permission()), apiKey: key}, 0, nil
 }
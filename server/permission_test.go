@@ -0,0 +1,141 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/op"
+)
+
+// TestPermissionBits 接口按权限位放行
+func TestPermissionBits(t *testing.T) {
+	createTestUser(t, "perm_none", 0)
+	createTestUser(t, "perm_users", model.PermManageUsers)
+	createTestUser(t, "perm_settings", model.PermManageSettings)
+	none := login(t, "perm_none", "perm_none").Token
+	users := login(t, "perm_users", "perm_users").Token
+	settings := login(t, "perm_settings", "perm_settings").Token
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		code   int
+	}{
+		{"no permission lists users", http.MethodGet, "/api/auth/users", none, http.StatusForbidden},
+		{"manage_users lists users", http.MethodGet, "/api/auth/users", users, http.StatusOK},
+		{"manage_settings lists users", http.MethodGet, "/api/auth/users", settings, http.StatusForbidden},
+		{"no permission lists settings", http.MethodGet, "/api/setting/list", none, http.StatusForbidden},
+		{"manage_users lists settings", http.MethodGet, "/api/setting/list", users, http.StatusForbidden},
+		{"manage_settings lists settings", http.MethodGet, "/api/setting/list", settings, http.StatusOK},
+		{"no permission lists duplicates", http.MethodGet, "/api/image/duplicates", none, http.StatusForbidden},
+		{"anonymous lists users", http.MethodGet, "/api/auth/users", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectCode(t, call(t, tt.method, tt.path, tt.token, nil), tt.code)
+		})
+	}
+
+	t.Run("upload", func(t *testing.T) {
+		expectCode(t, serve(t, testMultipartImage(t), none), http.StatusForbidden)
+	})
+}
+
+// TestGrantRevokeLimits 只能授予或撤销自己拥有的权限，校验失败时不修改用户
+func TestGrantRevokeLimits(t *testing.T) {
+	createTestUser(t, "grant_manager", model.DefaultPermission|model.PermManageUsers)
+	target := createTestUser(t, "grant_target", model.DefaultPermission)
+	manager := login(t, "grant_manager", "grant_manager").Token
+	admin := loginAdmin(t)
+	targetToken := login(t, "grant_target", "grant_target").Token
+	path := "/api/auth/users/" + itoa(target.ID)
+
+	expectPermission := func(t *testing.T, want int32) {
+		t.Helper()
+		user, err := op.GetUserById(target.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Permission != want {
+			t.Fatalf("permission = %b, want %b", user.Permission, want)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		body  map[string]any
+		code  int
+		want  int32
+	}{
+		{"grant not owned", manager, map[string]any{"grant": []string{"manage_settings"}}, http.StatusForbidden, model.DefaultPermission},
+		{"revoke not owned", manager, map[string]any{"revoke": []string{"manage_settings"}}, http.StatusForbidden, model.DefaultPermission},
+		{"unknown permission", manager, map[string]any{"grant": []string{"upload", "fly"}}, http.StatusBadRequest, model.DefaultPermission},
+		// 部分权限不允许时整个请求失败，密码也不会被修改
+		{"partly not owned", manager, map[string]any{"grant": []string{"upload", "delete_any"}, "password": "changed"}, http.StatusForbidden, model.DefaultPermission},
+		{"grant owned", manager, map[string]any{"grant": []string{"manage_users"}}, http.StatusOK, model.DefaultPermission | model.PermManageUsers},
+		{"revoke owned", manager, map[string]any{"revoke": []string{"upload"}}, http.StatusOK, model.PermDeleteOwn | model.PermManageUsers},
+		{"admin role", admin, map[string]any{"role": model.ADMIN}, http.StatusBadRequest, model.PermDeleteOwn | model.PermManageUsers},
+		{"admin grants any", admin, map[string]any{"grant": []string{"manage_settings"}, "revoke": []string{"manage_users"}}, http.StatusOK, model.PermDeleteOwn | model.PermManageSettings},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectCode(t, call(t, http.MethodPut, path, tt.token, tt.body), tt.code)
+			expectPermission(t, tt.want)
+		})
+	}
+
+	t.Run("password unchanged", func(t *testing.T) {
+		login(t, "grant_target", "grant_target")
+	})
+	t.Run("manager can't update admin", func(t *testing.T) {
+		adminUser, err := op.GetAdmin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectCode(t, call(t, http.MethodPut, "/api/auth/users/"+itoa(adminUser.ID), manager, map[string]any{"password": "taken"}), http.StatusForbidden)
+	})
+	t.Run("revoked permission applies to existing sessions", func(t *testing.T) {
+		expectCode(t, serve(t, testMultipartImage(t), targetToken), http.StatusForbidden)
+	})
+}
+
+// TestManageUsersTarget 非管理员不能修改或删除拥有自己没有的权限的用户，也不能创建内置角色的用户
+func TestManageUsersTarget(t *testing.T) {
+	createTestUser(t, "target_manager", model.DefaultPermission|model.PermManageUsers)
+	stronger := createTestUser(t, "target_stronger", model.DefaultPermission|model.PermManageSettings)
+	weaker := createTestUser(t, "target_weaker", model.DefaultPermission)
+	manager := login(t, "target_manager", "target_manager").Token
+	admin := loginAdmin(t)
+	path := "/api/auth/users/" + itoa(stronger.ID)
+
+	t.Run("stronger", func(t *testing.T) {
+		expectCode(t, call(t, http.MethodPut, path, manager, map[string]any{"password": "taken"}), http.StatusForbidden)
+		expectCode(t, call(t, http.MethodPut, path, manager, map[string]any{"disable": true}), http.StatusForbidden)
+		expectCode(t, call(t, http.MethodDelete, path, manager, nil), http.StatusForbidden)
+		login(t, "target_stronger", "target_stronger")
+	})
+	t.Run("weaker", func(t *testing.T) {
+		expectCode(t, call(t, http.MethodPut, "/api/auth/users/"+itoa(weaker.ID), manager, map[string]any{"password": "reset"}), http.StatusOK)
+		expectCode(t, call(t, http.MethodDelete, "/api/auth/users/"+itoa(weaker.ID), manager, nil), http.StatusOK)
+	})
+	t.Run("admin", func(t *testing.T) {
+		expectCode(t, call(t, http.MethodDelete, path, admin, nil), http.StatusOK)
+	})
+	t.Run("builtin role", func(t *testing.T) {
+		for _, role := range []int{model.ADMIN, model.GUEST} {
+			body := map[string]any{"username": "target_role_" + itoa(uint(role)), "password": "pass", "role": role}
+			expectCode(t, call(t, http.MethodPost, "/api/auth/users", admin, body), http.StatusBadRequest)
+		}
+		resp := call(t, http.MethodPost, "/api/auth/users", manager, map[string]any{"username": "target_created", "password": "pass"})
+		expectCode(t, resp, http.StatusOK)
+		var user model.User
+		resp.decode(t, &user)
+		if user.Role != model.GENERAL || user.Permission != model.DefaultPermission {
+			t.Fatalf("role/permission = %d/%b, want general with default permission", user.Role, user.Permission)
+		}
+	})
+}
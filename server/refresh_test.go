@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/FXAZfung/image-board/internal/errs"
+	"github.com/FXAZfung/image-board/internal/model"
+)
+
+func refresh(t *testing.T, refreshToken string) *testResp {
+	t.Helper()
+	return call(t, http.MethodPost, "/api/auth/refresh", "", map[string]string{"refresh_token": refreshToken})
+}
+
+// TestRefreshRotation 刷新令牌每次使用后轮换，旧的刷新令牌被重复使用时撤销整个会话
+func TestRefreshRotation(t *testing.T) {
+	createTestUser(t, "refresh_user", model.DefaultPermission)
+	first := login(t, "refresh_user", "refresh_user")
+	other := login(t, "refresh_user", "refresh_user")
+
+	t.Run("invalid", func(t *testing.T) {
+		expectCode(t, refresh(t, "not a token"), http.StatusUnauthorized)
+		// 访问令牌不能用于刷新，刷新令牌也不能用于访问接口
+		expectCode(t, refresh(t, first.Token), http.StatusUnauthorized)
+		expectSession(t, first.RefreshToken, http.StatusUnauthorized)
+	})
+
+	resp := refresh(t, first.RefreshToken)
+	expectCode(t, resp, http.StatusOK)
+	var second testTokens
+	resp.decode(t, &second)
+	if second.RefreshToken == first.RefreshToken {
+		t.Fatal("refresh token not rotated")
+	}
+	expectSession(t, second.Token, http.StatusOK)
+
+	resp = refresh(t, second.RefreshToken)
+	expectCode(t, resp, http.StatusOK)
+	var third testTokens
+	resp.decode(t, &third)
+
+	t.Run("reuse", func(t *testing.T) {
+		resp := refresh(t, first.RefreshToken)
+		expectCode(t, resp, http.StatusUnauthorized)
+		if resp.Message != errs.ErrRefreshTokenReused.Error() {
+			t.Fatalf("message = %q, want %q", resp.Message, errs.ErrRefreshTokenReused)
+		}
+		// 同一会话最新的令牌也随之失效
+		expectCode(t, refresh(t, third.RefreshToken), http.StatusUnauthorized)
+		expectSession(t, third.Token, http.StatusUnauthorized)
+		// 其他会话不受影响
+		expectSession(t, other.Token, http.StatusOK)
+		expectCode(t, refresh(t, other.RefreshToken), http.StatusOK)
+	})
+}
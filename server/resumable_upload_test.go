@@ -0,0 +1,212 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/db"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/response"
+	"github.com/FXAZfung/image-board/internal/service"
+	"github.com/pkg/errors"
+)
+
+const resumablePath = "/api/image/upload/resumable"
+
+func createUpload(t *testing.T, token string, size int) response.UploadSessionResponse {
+	t.Helper()
+	resp := call(t, http.MethodPost, resumablePath, token, map[string]any{"filename": "resumable.png", "size": size})
+	expectCode(t, resp, http.StatusOK)
+	var session response.UploadSessionResponse
+	resp.decode(t, &session)
+	return session
+}
+
+func patchUpload(t *testing.T, token, id string, offset int, body io.Reader) *testResp {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPatch, resumablePath+"/"+id, body)
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", strconv.Itoa(offset))
+	return serve(t, req, token)
+}
+
+// uploadOffset 通过 HEAD 请求获取已接收的字节数
+func uploadOffset(t *testing.T, token, id string) int {
+	t.Helper()
+	resp := serve(t, httptest.NewRequest(http.MethodHead, resumablePath+"/"+id, nil), token)
+	expectCode(t, resp, http.StatusOK)
+	offset, err := strconv.Atoi(resp.header.Get("Upload-Offset"))
+	if err != nil {
+		t.Fatalf("Upload-Offset: %v", err)
+	}
+	return offset
+}
+
+// interruptedReader 读完数据后返回错误，模拟连接中断
+type interruptedReader struct {
+	r io.Reader
+}
+
+func (r interruptedReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+// TestResumableUpload 分片必须从已接收的位置开始且不能超出声明的大小，中断后可以从已接收的位置继续
+func TestResumableUpload(t *testing.T) {
+	createTestUser(t, "resume_user", model.DefaultPermission)
+	createTestUser(t, "resume_other", model.DefaultPermission)
+	createTestUser(t, "resume_none", 0)
+	token := login(t, "resume_user", "resume_user").Token
+	other := login(t, "resume_other", "resume_other").Token
+	data := testPNG(t)
+	half := len(data) / 2
+
+	t.Run("create", func(t *testing.T) {
+		create := func(name string, size int) *testResp {
+			return call(t, http.MethodPost, resumablePath, token, map[string]any{"filename": name, "size": size})
+		}
+		expectCode(t, create("resumable.txt", len(data)), http.StatusBadRequest)
+		expectCode(t, create("resumable.png", -1), http.StatusRequestEntityTooLarge)
+		expectCode(t, create("resumable.png", 1<<40), http.StatusRequestEntityTooLarge)
+		none := login(t, "resume_none", "resume_none").Token
+		expectCode(t, call(t, http.MethodPost, resumablePath, none, map[string]any{"filename": "resumable.png", "size": len(data)}), http.StatusForbidden)
+	})
+
+	session := createUpload(t, token, len(data))
+	path := resumablePath + "/" + session.ID
+
+	t.Run("other user", func(t *testing.T) {
+		expectCode(t, call(t, http.MethodGet, path, other, nil), http.StatusNotFound)
+		expectCode(t, patchUpload(t, other, session.ID, 0, bytes.NewReader(data)), http.StatusNotFound)
+		expectCode(t, call(t, http.MethodPost, path+"/finish", other, nil), http.StatusNotFound)
+		expectCode(t, call(t, http.MethodDelete, path, other, nil), http.StatusNotFound)
+	})
+	t.Run("offset mismatch", func(t *testing.T) {
+		resp := patchUpload(t, token, session.ID, 1, bytes.NewReader(data[1:]))
+		expectCode(t, resp, http.StatusConflict)
+		if resp.header.Get("Upload-Offset") != "0" {
+			t.Fatalf("Upload-Offset = %q, want 0", resp.header.Get("Upload-Offset"))
+		}
+	})
+	t.Run("interrupted", func(t *testing.T) {
+		resp := patchUpload(t, token, session.ID, 0, interruptedReader{bytes.NewReader(data[:half])})
+		if resp.Code == http.StatusOK {
+			t.Fatal("interrupted chunk reported success")
+		}
+		if offset := uploadOffset(t, token, session.ID); offset != half {
+			t.Fatalf("offset = %d, want %d", offset, half)
+		}
+		expectCode(t, call(t, http.MethodPost, path+"/finish", token, nil), http.StatusBadRequest)
+	})
+	t.Run("overflow", func(t *testing.T) {
+		extra := append(bytes.Clone(data[half:]), 0)
+		expectCode(t, patchUpload(t, token, session.ID, half, bytes.NewReader(extra)), http.StatusRequestEntityTooLarge)
+
+		overflow := createUpload(t, token, 4)
+		expectCode(t, patchUpload(t, token, overflow.ID, 0, bytes.NewReader(data[:8])), http.StatusRequestEntityTooLarge)
+		expectCode(t, call(t, http.MethodDelete, resumablePath+"/"+overflow.ID, token, nil), http.StatusOK)
+		expectCode(t, call(t, http.MethodGet, resumablePath+"/"+overflow.ID, token, nil), http.StatusNotFound)
+	})
+
+	// 越界的分片已接收声明大小内的部分，重新上传一个完整的文件
+	session = createUpload(t, token, len(data))
+	path = resumablePath + "/" + session.ID
+	expectCode(t, patchUpload(t, token, session.ID, 0, bytes.NewReader(data[:half])), http.StatusOK)
+
+	t.Run("resume", func(t *testing.T) {
+		resp := call(t, http.MethodGet, path, token, nil)
+		expectCode(t, resp, http.StatusOK)
+		var got response.UploadSessionResponse
+		resp.decode(t, &got)
+		if got.Offset != int64(half) || got.Size != int64(len(data)) {
+			t.Fatalf("offset/size = %d/%d, want %d/%d", got.Offset, got.Size, half, len(data))
+		}
+		expectCode(t, patchUpload(t, token, session.ID, int(got.Offset), bytes.NewReader(data[got.Offset:])), http.StatusOK)
+		if offset := uploadOffset(t, token, session.ID); offset != len(data) {
+			t.Fatalf("offset = %d, want %d", offset, len(data))
+		}
+	})
+	t.Run("finish", func(t *testing.T) {
+		resp := call(t, http.MethodPost, path+"/finish", token, nil)
+		expectCode(t, resp, http.StatusOK)
+		var img model.Image
+		resp.decode(t, &img)
+		sum := sha256.Sum256(data)
+		if img.Hash != hex.EncodeToString(sum[:]) {
+			t.Fatalf("hash = %s, want %x", img.Hash, sum)
+		}
+		file := call(t, http.MethodGet, "/images/image/"+img.FileName, token, nil)
+		expectCode(t, file, http.StatusOK)
+		if !bytes.Equal(file.body, data) {
+			t.Fatal("stored image differs from uploaded data")
+		}
+		expectCode(t, call(t, http.MethodGet, path, token, nil), http.StatusNotFound)
+	})
+}
+
+// TestExpiredUploads 过期的上传不计入数量限制，清理时删除会话与已接收的数据
+func TestExpiredUploads(t *testing.T) {
+	user := createTestUser(t, "expire_user", model.DefaultPermission)
+	token := login(t, "expire_user", "expire_user").Token
+
+	var ids []string
+	for i := 0; i < 10; i++ {
+		ids = append(ids, createUpload(t, token, 16).ID)
+	}
+	expectCode(t, call(t, http.MethodPost, resumablePath, token, map[string]any{"filename": "resumable.png", "size": 16}), http.StatusTooManyRequests)
+
+	for _, id := range ids {
+		session, err := db.GetUploadSession(user.ID, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		session.ExpiresAt = time.Now().Add(-time.Minute)
+		if err := db.UpdateUploadSessionProgress(session); err != nil {
+			t.Fatal(err)
+		}
+	}
+	active := createUpload(t, token, 16)
+	expectCode(t, call(t, http.MethodGet, resumablePath+"/"+ids[0], token, nil), http.StatusNotFound)
+
+	service.CleanExpiredUploads()
+	remaining, err := db.GetUploadSessionIDs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range remaining {
+		if contains(ids, id) {
+			t.Fatalf("expired upload %s not removed", id)
+		}
+	}
+	if !contains(remaining, active.ID) {
+		t.Fatal("active upload removed")
+	}
+	for _, id := range ids {
+		if _, err := os.Stat(filepath.Join(conf.Conf.DataImage.CacheDir, "uploads", id+".part")); !os.IsNotExist(err) {
+			t.Fatalf("data of expired upload %s not removed: %v", id, err)
+		}
+	}
+}
+
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
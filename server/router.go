@@ -1,6 +1,9 @@
 package server
 
 import (
+	conf "github.com/FXAZfung/image-board/internal/config"
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/server/common"
 	"github.com/FXAZfung/image-board/server/handles"
 	"github.com/FXAZfung/image-board/server/middleware"
 	"github.com/gin-gonic/gin"
@@ -9,6 +12,8 @@ import (
 )
 
 func Init(router *gin.Engine) {
+	common.SecretKey = []byte(conf.Conf.JwtSecret)
+
 	// 处理跨域
 	Cors(router)
 
@@ -16,7 +21,7 @@ func Init(router *gin.Engine) {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// 直接访问图片相关路由（无需认证）
-	imagesGroup := router.Group("/images")
+	imagesGroup := router.Group("/images").Use(middleware.OptionalAuthMiddleware, middleware.RequireScope(model.ScopeRead))
 	{
 		imagesGroup.GET("/image/:name", handles.GetImageByName)
 		imagesGroup.GET("/image/random", handles.GetRandomImage)
@@ -29,7 +34,40 @@ func Init(router *gin.Engine) {
 	authApi := api.Group("/auth")
 	{
 		authApi.POST("/login", handles.Login)
-		authApi.POST("/logout", handles.Logout).Use(middleware.AuthMiddleware)
+		authApi.POST("/login/otp", handles.LoginOtp)
+		authApi.POST("/refresh", handles.Refresh)
+		authApi.GET("/sso/login", handles.SSOLogin)
+		authApi.GET("/sso/callback", handles.SSOCallback)
+		authApi.GET("/me", middleware.AuthMiddleware, handles.GetUserInfo)
+		// 账户、会话及密钥管理不允许使用 API 密钥
+		authApiAuth := authApi.Group("").Use(middleware.AuthMiddleware, middleware.RejectAPIKey)
+		{
+			authApiAuth.POST("/logout", handles.Logout)
+			authApiAuth.POST("/me/password", handles.ChangePassword)
+			authApiAuth.GET("/sessions", handles.ListSessions)
+			authApiAuth.DELETE("/sessions", handles.RevokeAllSessions)
+			authApiAuth.DELETE("/sessions/:id", handles.RevokeSession)
+			authApiAuth.GET("/apikeys", handles.ListAPIKeys)
+			authApiAuth.POST("/apikeys", handles.CreateAPIKey)
+			authApiAuth.DELETE("/apikeys/:id", handles.DeleteAPIKey)
+			authApiAuth.GET("/2fa", handles.GetTwoFactorStatus)
+			authApiAuth.POST("/2fa/enroll", handles.EnrollTwoFactor)
+			authApiAuth.POST("/2fa/enable", handles.EnableTwoFactor)
+			authApiAuth.POST("/2fa/disable", handles.DisableTwoFactor)
+			authApiAuth.POST("/2fa/recovery_codes", handles.RegenerateRecoveryCodes)
+			authApiAuth.GET("/sso/bind", handles.SSOBind)
+			authApiAuth.DELETE("/sso/bind", handles.SSOUnbind)
+		}
+		// 用户管理
+		userApi := authApi.Group("/users").Use(middleware.AuthMiddleware, middleware.RequirePermission(model.PermManageUsers))
+		{
+			userApi.GET("", handles.ListUsers)
+			userApi.POST("", handles.Register)
+			userApi.GET("/count", handles.GetUserCount)
+			userApi.GET("/:id", handles.GetUserByID)
+			userApi.PUT("/:id", handles.UpdateUser)
+			userApi.DELETE("/:id", handles.DeleteUser)
+		}
 	}
 
 	// 图片
@@ -37,25 +75,48 @@ func Init(router *gin.Engine) {
 	{
 		imageApiAuth := imageApi.Group("").Use(middleware.AuthMiddleware)
 		{
-			imageApiAuth.POST("/upload", handles.UploadImage)
+			imageApiAuth.POST("/upload", middleware.RequireScope(model.ScopeUpload), handles.UploadImage)
+			imageApiAuth.POST("/upload/batch", middleware.RequireScope(model.ScopeUpload), handles.BatchUploadImages)
+			imageApiAuth.POST("/upload/url", middleware.RequireScope(model.ScopeUpload), handles.ImportImage)
 			imageApiAuth.POST("/delete", handles.DeleteImage)
-			imageApiAuth.POST("/tag/add", handles.AddTagToImage)
-			imageApiAuth.POST("/tag/remove", handles.RemoveTagFromImage)
+			imageApiAuth.POST("/tag/add", middleware.RequireScope(model.ScopeTags), handles.AddTagToImage)
+			imageApiAuth.POST("/tag/remove", middleware.RequireScope(model.ScopeTags), handles.RemoveTagFromImage)
+			imageApiAuth.POST("/sign", middleware.RequireScope(model.ScopeRead), handles.SignImage)
+			imageApiAuth.POST("/search/similar", middleware.RequireScope(model.ScopeRead), handles.SearchSimilarImages)
+			imageApiAuth.GET("/duplicates", middleware.RequirePermission(model.PermDeleteAny, model.PermViewPrivate),
+				middleware.RequireScope(model.ScopeRead), handles.ListDuplicateClusters)
+		}
+		// 断点续传
+		resumableApi := imageApi.Group("/upload/resumable").Use(middleware.AuthMiddleware, middleware.RequireScope(model.ScopeUpload))
+		{
+			resumableApi.POST("", handles.CreateUpload)
+			resumableApi.GET("/:id", handles.GetUpload)
+			resumableApi.HEAD("/:id", handles.HeadUpload)
+			resumableApi.PATCH("/:id", handles.PatchUpload)
+			resumableApi.DELETE("/:id", handles.AbortUpload)
+			resumableApi.POST("/:id/finish", handles.FinishUpload)
+		}
+		imageApiPublic := imageApi.Group("").Use(middleware.OptionalAuthMiddleware, middleware.RequireScope(model.ScopeRead))
+		{
+			imageApiPublic.POST("/list", handles.ListImages)
+			imageApiPublic.GET("/count", handles.GetImageCount)
+			imageApiPublic.POST("/tag/list", handles.GetImagesByTag)
+			imageApiPublic.GET("/info/:id", handles.GetImageDetail)
+			imageApiPublic.POST("/metadata/search", handles.SearchImagesByMetadata)
+			imageApiPublic.GET("/metadata/facets", handles.GetMetadataFacets)
 		}
-		imageApi.POST("/list", handles.ListImages)
-		imageApi.GET("/count", handles.GetImageCount)
-		imageApi.POST("/tag/list", handles.GetImagesByTag)
 	}
 
 	// 设置
 	settingApi := api.Group("/setting")
 	{
 		settingApi.GET("", handles.PublicSettings)
-		settingApiAuth := settingApi.Group("").Use(middleware.AuthMiddleware)
+		settingApiAuth := settingApi.Group("").Use(middleware.AuthMiddleware, middleware.RequirePermission(model.PermManageSettings))
 		{
 			//settingApiAuth.GET("/name", handles.GetSetting)
 			settingApiAuth.POST("/save", handles.SaveSettings)
 			settingApiAuth.GET("/list", handles.ListSettings)
+			settingApiAuth.POST("/sign_secret", handles.ResetSignSecret)
 			//settingApiAuth.DELETE("/delete", handles.DeleteSetting)
 		}
 	}
@@ -65,9 +126,10 @@ func Init(router *gin.Engine) {
 		tagApi.POST("/list", handles.ListTags)
 		tagApi.GET("/popular", handles.MostPopularTags)
 		tagApi.GET("/search", handles.SearchTags)
-		tagApi.GET("/image/:image_id", handles.GetTagsByImage)
+		tagApi.GET("/image/:image_id", middleware.OptionalAuthMiddleware, middleware.RequireScope(model.ScopeRead), handles.GetTagsByImage)
 		tagApi.GET("/name", handles.GetTagByName)
 		tagApi.GET("/:id", handles.GetTagByID)
+		tagApi.DELETE("/delete/:id", middleware.AuthMiddleware, middleware.RequirePermission(model.PermManageTags), handles.DeleteTag)
 	}
 }
 
@@ -75,8 +137,9 @@ func Init(router *gin.Engine) {
 func Cors(router *gin.Engine) {
 	router.Use(func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Upload-Offset")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Location, Upload-Offset, Upload-Length")
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(200)
 			return
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/FXAZfung/image-board/internal/db"
+	"github.com/FXAZfung/image-board/internal/model"
+)
+
+func listSessions(t *testing.T, token string) []model.Session {
+	t.Helper()
+	resp := call(t, http.MethodGet, "/api/auth/sessions", token, nil)
+	expectCode(t, resp, http.StatusOK)
+	var sessions []model.Session
+	resp.decode(t, &sessions)
+	return sessions
+}
+
+// currentSessionID 返回 token 对应的会话 ID
+func currentSessionID(t *testing.T, token string) string {
+	t.Helper()
+	for _, s := range listSessions(t, token) {
+		if s.Current {
+			return s.ID
+		}
+	}
+	t.Fatal("no current session")
+	return ""
+}
+
+func expectSession(t *testing.T, token string, code int) {
+	t.Helper()
+	expectCode(t, call(t, http.MethodGet, "/api/auth/me", token, nil), code)
+}
+
+// TestSessionRevoke 撤销会话后对应的访问令牌与刷新令牌立即失效，只能撤销自己的会话
+func TestSessionRevoke(t *testing.T) {
+	createTestUser(t, "sess_user", model.DefaultPermission)
+	createTestUser(t, "sess_other", model.DefaultPermission)
+	first := login(t, "sess_user", "sess_user")
+	second := login(t, "sess_user", "sess_user")
+	third := login(t, "sess_user", "sess_user")
+	other := login(t, "sess_other", "sess_other").Token
+
+	if n := len(listSessions(t, first.Token)); n != 3 {
+		t.Fatalf("sessions = %d, want 3", n)
+	}
+	secondID := currentSessionID(t, second.Token)
+
+	t.Run("other user", func(t *testing.T) {
+		expectCode(t, call(t, http.MethodDelete, "/api/auth/sessions/"+secondID, other, nil), http.StatusNotFound)
+		expectSession(t, second.Token, http.StatusOK)
+	})
+	t.Run("revoke one", func(t *testing.T) {
+		expectCode(t, call(t, http.MethodDelete, "/api/auth/sessions/"+secondID, first.Token, nil), http.StatusOK)
+		expectSession(t, second.Token, http.StatusUnauthorized)
+		expectCode(t, call(t, http.MethodPost, "/api/auth/refresh", "", map[string]string{"refresh_token": second.RefreshToken}), http.StatusUnauthorized)
+		expectSession(t, first.Token, http.StatusOK)
+		// 撤销记录在数据库中，不依赖进程内缓存
+		if _, err := db.GetSession(secondID); err == nil {
+			t.Fatal("revoked session still stored")
+		}
+		expectCode(t, call(t, http.MethodDelete, "/api/auth/sessions/"+secondID, first.Token, nil), http.StatusNotFound)
+	})
+	t.Run("revoke all but current", func(t *testing.T) {
+		expectCode(t, call(t, http.MethodDelete, "/api/auth/sessions?keep_current=true", first.Token, nil), http.StatusOK)
+		expectSession(t, third.Token, http.StatusUnauthorized)
+		expectSession(t, first.Token, http.StatusOK)
+		expectSession(t, other, http.StatusOK)
+	})
+	t.Run("revoke all", func(t *testing.T) {
+		expectCode(t, call(t, http.MethodDelete, "/api/auth/sessions", first.Token, nil), http.StatusOK)
+		expectSession(t, first.Token, http.StatusUnauthorized)
+	})
+}
+
+// TestPasswordChangeRevokesSessions 修改密码后保留当前会话，其他会话失效；管理员重置密码时全部会话失效
+func TestPasswordChangeRevokesSessions(t *testing.T) {
+	target := createTestUser(t, "pwd_user", model.DefaultPermission)
+	current := login(t, "pwd_user", "pwd_user")
+	old := login(t, "pwd_user", "pwd_user")
+
+	t.Run("wrong old password", func(t *testing.T) {
+		body := map[string]string{"old_password": "wrong", "new_password": "pwd_new"}
+		expectCode(t, call(t, http.MethodPost, "/api/auth/me/password", current.Token, body), http.StatusBadRequest)
+		expectSession(t, old.Token, http.StatusOK)
+	})
+	t.Run("change", func(t *testing.T) {
+		body := map[string]string{"old_password": "pwd_user", "new_password": "pwd_new"}
+		expectCode(t, call(t, http.MethodPost, "/api/auth/me/password", current.Token, body), http.StatusOK)
+		expectSession(t, current.Token, http.StatusOK)
+		expectSession(t, old.Token, http.StatusUnauthorized)
+		expectCode(t, call(t, http.MethodPost, "/api/auth/refresh", "", map[string]string{"refresh_token": old.RefreshToken}), http.StatusUnauthorized)
+		login(t, "pwd_user", "pwd_new")
+	})
+	t.Run("admin reset", func(t *testing.T) {
+		body := map[string]any{"password": "pwd_reset"}
+		expectCode(t, call(t, http.MethodPut, "/api/auth/users/"+itoa(target.ID), loginAdmin(t), body), http.StatusOK)
+		expectSession(t, current.Token, http.StatusUnauthorized)
+		login(t, "pwd_user", "pwd_reset")
+	})
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/FXAZfung/image-board/internal/model"
+	"github.com/FXAZfung/image-board/internal/model/response"
+	"github.com/FXAZfung/image-board/internal/op"
+	"github.com/FXAZfung/image-board/internal/service"
+	"github.com/FXAZfung/image-board/pkg/totp"
+)
+
+func totpCode(t *testing.T, secret string, counter int64) string {
+	t.Helper()
+	code, err := totp.GenerateCode(secret, counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// loginOtpToken 完成第一步登录，返回第二步的验证凭据
+func loginOtpToken(t *testing.T, username string) string {
+	t.Helper()
+	resp := call(t, http.MethodPost, "/api/auth/login", "", map[string]string{"username": username, "password": username})
+	expectCode(t, resp, http.StatusOK)
+	var otp struct {
+		OtpToken string `json:"otp_token"`
+	}
+	resp.decode(t, &otp)
+	if otp.OtpToken == "" {
+		t.Fatal("otp_token missing")
+	}
+	return otp.OtpToken
+}
+
+// loginOtp 使用不同的 IP 提交第二步验证，模拟分散在多个 IP 的猜测
+func loginOtp(t *testing.T, otpToken, code string, ip int) *testResp {
+	t.Helper()
+	body, err := json.Marshal(map[string]string{"otp_token": otpToken, "code": code})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/login/otp", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "198.51.100." + itoa(uint(ip)) + ":1234"
+	return serve(t, req, "")
+}
+
+// TestTwoFactor 两步验证只更新自身字段，同一验证码只能使用一次，每个验证凭据的尝试次数有限
+func TestTwoFactor(t *testing.T) {
+	user := createTestUser(t, "otp_user", model.DefaultPermission)
+	token := login(t, "otp_user", "otp_user").Token
+
+	resp := call(t, http.MethodPost, "/api/auth/2fa/enroll", token, nil)
+	expectCode(t, resp, http.StatusOK)
+	var enroll response.TwoFactorEnrollResponse
+	resp.decode(t, &enroll)
+
+	// 启用前取得的用户为旧数据，启用时不能覆盖管理员同时授予的权限
+	stale, err := op.GetUserById(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectCode(t, call(t, http.MethodPut, "/api/auth/users/"+itoa(user.ID), loginAdmin(t), map[string]any{"grant": []string{"manage_tags"}}), http.StatusOK)
+	now := totp.Counter(time.Now())
+	codes, err := service.EnableTwoFactor(stale, totpCode(t, enroll.Secret, now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fresh, err := op.GetUserById(user.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fresh.OtpEnabled || !fresh.HasPermission(model.PermManageTags) {
+		t.Fatalf("otp enabled = %v, permission = %b: enabling two-factor overwrote other fields", fresh.OtpEnabled, fresh.Permission)
+	}
+
+	t.Run("parallel replay", func(t *testing.T) {
+		code := totpCode(t, enroll.Secret, now+1)
+		var wg sync.WaitGroup
+		errs := make([]error, 8)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = service.VerifyTwoFactor(fresh, code)
+			}(i)
+		}
+		wg.Wait()
+		accepted := 0
+		for _, err := range errs {
+			if err == nil {
+				accepted++
+			}
+		}
+		if accepted != 1 {
+			t.Fatalf("code accepted %d times, want 1", accepted)
+		}
+		expectCode(t, loginOtp(t, loginOtpToken(t, "otp_user"), code, 1), http.StatusBadRequest)
+	})
+	t.Run("attempts", func(t *testing.T) {
+		otpToken := loginOtpToken(t, "otp_user")
+		for i := 0; i < 5; i++ {
+			expectCode(t, loginOtp(t, otpToken, "wrong-code", 10+i), http.StatusBadRequest)
+		}
+		expectCode(t, loginOtp(t, otpToken, codes[0], 20), http.StatusUnauthorized)
+	})
+	t.Run("recovery code", func(t *testing.T) {
+		expectCode(t, loginOtp(t, loginOtpToken(t, "otp_user"), codes[0], 30), http.StatusOK)
+	})
+}